@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/tliron/commonlog"
 	_ "github.com/tliron/commonlog/simple"
@@ -20,6 +21,7 @@ var (
 	showVersion = flag.Bool("version", false, "Show version and exit")
 	logLevel    = flag.Int("log-level", 0, "Logging verbosity (0=errors, 1=info, 2=debug, 3=trace)")
 	tcp         = flag.String("tcp", "", "Use TCP transport (e.g., 'localhost:8080')")
+	debounce    = flag.Duration("debounce", 300*time.Millisecond, "Delay before validating a document after an edit")
 )
 
 func main() {
@@ -35,6 +37,7 @@ func main() {
 
 	// Create and run LSP server
 	srv := server.New(name, version)
+	srv.SetDebounce(*debounce)
 
 	if *tcp != "" {
 		if err := srv.RunTCP(*tcp); err != nil {
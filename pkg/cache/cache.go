@@ -1,8 +1,11 @@
 package cache
 
 import (
+	"slices"
 	"sync"
 
+	"github.com/vdemeester/tekton-lsp-go/pkg/index"
+	"github.com/vdemeester/tekton-lsp-go/pkg/model"
 	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
 )
 
@@ -12,24 +15,131 @@ type Entry struct {
 	LanguageID string
 	Version    int32
 	Content    string
-	parsed     []*parser.Document
+	// Open is true while the document is open in the editor, in which case
+	// Content is the editor buffer rather than the file on disk.
+	Open bool
+	// Summary is true for files loaded from disk that hold no Tekton
+	// resource (Helm values, CI configuration...). Their Content is dropped
+	// and their documents only keep what the index needs.
+	Summary bool
+	parsed  []*parser.Document
 }
 
 // Cache is a thread-safe cache for open documents and their parsed ASTs.
+// It keeps a resource index of its documents up to date.
 type Cache struct {
 	mu      sync.RWMutex
 	entries map[string]*Entry
+	index   *index.Index
 }
 
 // New creates a new empty document cache.
 func New() *Cache {
 	return &Cache{
 		entries: make(map[string]*Entry),
+		index:   index.New(),
 	}
 }
 
+// Index returns the resource index of the cached documents.
+func (c *Cache) Index() *index.Index {
+	return c.index
+}
+
 // Insert adds or replaces a document in the cache and parses it.
 func (c *Cache) Insert(uri, languageID string, version int32, content string) {
+	c.insert(uri, languageID, version, content, false)
+}
+
+// Open adds or replaces a document opened in the editor. Its content takes
+// precedence over the file on disk until Close is called.
+func (c *Cache) Open(uri, languageID string, version int32, content string) {
+	c.insert(uri, languageID, version, content, true)
+}
+
+// Load adds or replaces a document with its content on disk. Documents open
+// in the editor are left untouched. It returns false if the document is open.
+// Files without Tekton resources are kept as a summary, see Entry.Summary.
+func (c *Cache) Load(uri, content string) bool {
+	parsed, _ := parser.ParseAllYAML(uri, content)
+	summary := !slices.ContainsFunc(parsed, model.IsTekton)
+	if summary {
+		content = ""
+		for i, doc := range parsed {
+			parsed[i] = summarize(doc)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[uri]; ok && e.Open {
+		return false
+	}
+	c.entries[uri] = &Entry{
+		URI:        uri,
+		LanguageID: "yaml",
+		Content:    content,
+		Summary:    summary,
+		parsed:     parsed,
+	}
+	c.index.Update(uri, parsed)
+	return true
+}
+
+// summarize returns a copy of doc reduced to its apiVersion, kind and
+// metadata name and namespace, so a ConfigMap or ServiceAccount stays
+// indexed without holding on to the whole tree.
+func summarize(doc *parser.Document) *parser.Document {
+	summary := &parser.Document{
+		Filename:   doc.Filename,
+		APIVersion: doc.APIVersion,
+		Kind:       doc.Kind,
+		Index:      doc.Index,
+	}
+	if doc.Root == nil || !doc.Root.IsMapping() {
+		return summary
+	}
+	summary.Root = keep(doc.Root, "apiVersion", "kind", "metadata")
+	if metadata := summary.Root.Get("metadata"); metadata.IsMapping() {
+		summary.Root.MappingChildren["metadata"] = keep(metadata, "name", "namespace")
+	}
+	return summary
+}
+
+// keep returns a shallow copy of a mapping node with only the given keys.
+func keep(n *parser.Node, keys ...string) *parser.Node {
+	kept := *n
+	kept.MappingChildren = make(map[string]*parser.Node, len(keys))
+	kept.Merges = nil
+	kept.LeadingComments = nil
+	kept.TrailingComment = nil
+	for _, key := range keys {
+		if child, ok := n.MappingChildren[key]; ok {
+			kept.MappingChildren[key] = child
+		}
+	}
+	return &kept
+}
+
+// Close marks a document as no longer open in the editor. The entry is kept;
+// callers reload it from disk with Load or drop it with Remove.
+func (c *Cache) Close(uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[uri]; ok {
+		e.Open = false
+	}
+}
+
+// IsOpen reports whether a document is open in the editor.
+func (c *Cache) IsOpen(uri string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[uri]
+	return ok && e.Open
+}
+
+func (c *Cache) insert(uri, languageID string, version int32, content string, open bool) {
 	parsed, _ := parser.ParseAllYAML(uri, content)
 
 	c.mu.Lock()
@@ -39,8 +149,10 @@ func (c *Cache) Insert(uri, languageID string, version int32, content string) {
 		LanguageID: languageID,
 		Version:    version,
 		Content:    content,
+		Open:       open,
 		parsed:     parsed,
 	}
+	c.index.Update(uri, parsed)
 }
 
 // Get returns the raw document entry for a URI.
@@ -87,6 +199,7 @@ func (c *Cache) Update(uri string, version int32, content string) {
 		e.Version = version
 		e.Content = content
 		e.parsed = parsed
+		c.index.Update(uri, parsed)
 	}
 }
 
@@ -95,6 +208,7 @@ func (c *Cache) Remove(uri string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	delete(c.entries, uri)
+	c.index.Remove(uri)
 }
 
 // All returns all cached document entries.
@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/vdemeester/tekton-lsp-go/pkg/index"
 	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
 )
 
@@ -140,3 +141,64 @@ func TestDocumentCache_ParserIntegration(t *testing.T) {
 	assert.Equal(t, doc.Kind, parsed.Kind)
 	assert.Equal(t, doc.APIVersion, parsed.APIVersion)
 }
+
+func TestDocumentCache_KeepsIndexUpToDate(t *testing.T) {
+	c := New()
+	task := index.Key{Group: "tekton.dev", Kind: "Task", Name: "build"}
+
+	c.Insert("file:///task.yaml", "yaml", 1, "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build\n")
+	require.Len(t, c.Index().Lookup(task), 1)
+
+	c.Update("file:///task.yaml", 2, "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: compile\n")
+	assert.Empty(t, c.Index().Lookup(task))
+	assert.Len(t, c.Index().Lookup(index.Key{Group: "tekton.dev", Kind: "Task", Name: "compile"}), 1)
+
+	c.Remove("file:///task.yaml")
+	assert.Empty(t, c.Index().ResourcesIn("file:///task.yaml"))
+}
+
+func TestDocumentCache_OpenBufferWinsOverDisk(t *testing.T) {
+	c := New()
+
+	c.Open("file:///task.yaml", "yaml", 3, "kind: Task # editor\n")
+	assert.True(t, c.IsOpen("file:///task.yaml"))
+	assert.False(t, c.Load("file:///task.yaml", "apiVersion: tekton.dev/v1\nkind: Task # disk\n"), "disk content must not replace an open buffer")
+
+	entry, ok := c.Get("file:///task.yaml")
+	require.True(t, ok)
+	assert.Equal(t, "kind: Task # editor\n", entry.Content)
+
+	c.Close("file:///task.yaml")
+	assert.False(t, c.IsOpen("file:///task.yaml"))
+	_, ok = c.Get("file:///task.yaml")
+	assert.True(t, ok, "closing keeps the entry")
+
+	assert.True(t, c.Load("file:///task.yaml", "apiVersion: tekton.dev/v1\nkind: Task # disk\n"))
+	entry, _ = c.Get("file:///task.yaml")
+	assert.Equal(t, "apiVersion: tekton.dev/v1\nkind: Task # disk\n", entry.Content)
+	assert.Equal(t, int32(0), entry.Version)
+}
+
+func TestDocumentCache_SummarizesNonTekton(t *testing.T) {
+	c := New()
+	content := "apiVersion: v1\nkind: ServiceAccount\nmetadata:\n  name: builder\n  namespace: ci\n  labels:\n    app: ci\nsecrets:\n  - name: token\n"
+
+	require.True(t, c.Load("file:///sa.yaml", content))
+	entry, ok := c.Get("file:///sa.yaml")
+	require.True(t, ok)
+	assert.True(t, entry.Summary)
+	assert.Empty(t, entry.Content)
+
+	docs, ok := c.GetAllParsed("file:///sa.yaml")
+	require.True(t, ok)
+	require.Len(t, docs, 1)
+	assert.Nil(t, docs[0].Root.Get("secrets"), "only what the index needs is kept")
+	assert.Nil(t, docs[0].Root.Get("metadata").Get("labels"))
+	assert.Len(t, c.Index().Lookup(index.Key{Kind: "ServiceAccount", Namespace: "ci", Name: "builder"}), 1)
+
+	// Open documents always keep their content.
+	c.Open("file:///sa.yaml", "yaml", 1, content)
+	entry, _ = c.Get("file:///sa.yaml")
+	assert.False(t, entry.Summary)
+	assert.Equal(t, content, entry.Content)
+}
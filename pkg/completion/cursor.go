@@ -0,0 +1,375 @@
+package completion
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+// step is one step of the path from a document's root to the cursor.
+type step struct {
+	// Key is the mapping key; it is empty for a sequence item.
+	Key string
+	// Line is the line of the key, or of the item's "-" indicator.
+	Line uint32
+}
+
+// cursor describes where completion was requested. It is worked out from
+// the source lines rather than the AST alone: the content being typed is
+// often incomplete, and an empty "- " item or a key without a value has no
+// node, or one whose range ends before the cursor.
+type cursor struct {
+	doc *parser.Document
+	pos parser.Position
+	// line is the text of the cursor's line.
+	line string
+	// path leads from the root to the mapping or sequence the cursor is in.
+	path []step
+	// node is the parsed node at path, nil when there is none yet.
+	node *parser.Node
+	// value is true when a value is typed rather than a key: after "key:",
+	// in which case key is set, or as a sequence item.
+	value bool
+	key   string
+	// bare is true for text after "- " without a colon, which is either a
+	// scalar item or the first key of a mapping item.
+	bare bool
+	// block is true inside a block scalar such as "script: |".
+	block bool
+	// prefix is the text typed so far, up to the cursor.
+	prefix string
+	// rng is the range of the key or value the completion replaces.
+	rng parser.Range
+}
+
+// locate works out the cursor at pos in doc. It returns nil if pos is not
+// in doc, e.g. in another document of the same file.
+func locate(doc *parser.Document, pos parser.Position) *cursor {
+	lines := strings.Split(doc.Content, "\n")
+	if doc.Root == nil || !owns(doc, lines, pos) {
+		return nil
+	}
+	line := strings.TrimSuffix(lines[pos.Line], "\r")
+	c := &cursor{doc: doc, pos: pos, line: line}
+	ch := min(int(pos.Character), len(line))
+
+	if n := doc.FindNodeAtPosition(pos); n.IsScalar() && isBlock(n) && pos.Line > n.Range.Start.Line {
+		// Inside a block scalar the lines are text, not YAML: the path is the
+		// one of the block's key.
+		key := scanLine(strings.TrimSuffix(lines[n.Range.Start.Line], "\r"))
+		c.value, c.key, c.block = true, n.Key, true
+		c.prefix = strings.TrimLeft(line[:ch], " ")
+		c.rng = lineRange(pos.Line, ch, ch)
+		c.path = append(climb(lines, n.Range.Start.Line, key), items(key, n.Range.Start.Line)...)
+		c.node = resolve(doc.Root, c.path)
+		return c
+	}
+
+	li := scanLine(line)
+	if li.blank {
+		// On an empty line the cursor column says which mapping it is in.
+		li = lineInfo{indent: ch, col: ch}
+	} else if ch < li.col {
+		return nil
+	}
+	switch {
+	case li.hasKey && ch > li.col+len(li.key):
+		c.value, c.key = true, li.key
+		c.prefix = line[min(li.valueCol, ch):ch]
+		c.rng = lineRange(pos.Line, min(li.valueCol, ch), max(li.valueCol+len(li.value), ch))
+	case li.hasKey:
+		c.prefix = line[li.col:ch]
+		c.rng = lineRange(pos.Line, li.col, li.col+len(li.key))
+	default:
+		text := strings.TrimRight(stripComment(line[li.col:]), " ")
+		c.prefix = line[li.col:ch]
+		c.rng = lineRange(pos.Line, li.col, max(li.col+len(text), ch))
+		if len(li.dashes) > 0 {
+			c.value, c.bare = true, true
+		}
+	}
+	c.path = append(climb(lines, pos.Line, li), items(li, pos.Line)...)
+	c.node = resolve(doc.Root, c.path)
+	return c
+}
+
+// closeFlow returns doc with the flow collections left open on the line of
+// pos closed, as in "runAfter: [fetch, ": tree-sitter does not recover from
+// them and loses the rest of the document. The file is reparsed with the
+// closing brackets added and the document at pos returned; doc itself is
+// returned when nothing is left open.
+func closeFlow(doc *parser.Document, pos parser.Position) *parser.Document {
+	lines := strings.Split(doc.Content, "\n")
+	if int(pos.Line) >= len(lines) {
+		return doc
+	}
+	line := strings.TrimSuffix(lines[pos.Line], "\r")
+	closers := unclosed(line)
+	if closers == "" {
+		return doc
+	}
+	lines[pos.Line] = line + closers
+	docs, err := parser.ParseAllYAML(doc.Filename, strings.Join(lines, "\n"))
+	if err != nil {
+		return doc
+	}
+	var found *parser.Document
+	for _, d := range docs {
+		if d.Root != nil && d.Root.Range.Start.Line <= pos.Line {
+			found = d
+		}
+	}
+	if found == nil {
+		return doc
+	}
+	// Positions past the added brackets are the same in both texts.
+	found.Content = doc.Content
+	return found
+}
+
+// unclosed returns the brackets closing the flow collections a line opens
+// and leaves open, ignoring quoted text and comments.
+func unclosed(line string) string {
+	var open []byte
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		b := line[i]
+		switch {
+		case quote != 0:
+			if b == quote {
+				quote = 0
+			}
+		case b == '"' || b == '\'':
+			quote = b
+		case b == '#' && (i == 0 || line[i-1] == ' '):
+			i = len(line)
+		case b == '[':
+			open = append(open, ']')
+		case b == '{':
+			open = append(open, '}')
+		case (b == ']' || b == '}') && len(open) > 0 && open[len(open)-1] == b:
+			open = open[:len(open)-1]
+		}
+	}
+	slices.Reverse(open)
+	return string(open)
+}
+
+// in reports whether the cursor's path ends with the given keys, "[]"
+// standing for a sequence item.
+func (c *cursor) in(keys ...string) bool {
+	if len(keys) > len(c.path) {
+		return false
+	}
+	tail := c.path[len(c.path)-len(keys):]
+	for i, key := range keys {
+		if key == "[]" && tail[i].Key != "" || key != "[]" && tail[i].Key != key {
+			return false
+		}
+	}
+	return true
+}
+
+// within reports whether n is one of the nodes on the cursor's path, i.e.
+// whether the cursor is inside it.
+func (c *cursor) within(n *parser.Node) bool {
+	if n == nil {
+		return false
+	}
+	for _, s := range c.path {
+		if s.Line == n.Range.Start.Line && s.Key == n.Key {
+			return true
+		}
+	}
+	return false
+}
+
+// owns reports whether pos is in doc: at or after its first line and
+// before the next document separator.
+func owns(doc *parser.Document, lines []string, pos parser.Position) bool {
+	start := doc.Root.Range.Start.Line
+	if pos.Line < start || int(pos.Line) >= len(lines) {
+		return false
+	}
+	for l := start + 1; l <= pos.Line; l++ {
+		if separator(lines[l]) {
+			return false
+		}
+	}
+	return true
+}
+
+// climb returns the path to the collection holding the content of line at
+// the column described by li, from the lines above it.
+func climb(lines []string, line uint32, li lineInfo) []step {
+	level, dash := li.col, false
+	if len(li.dashes) > 0 {
+		level, dash = li.dashes[0], true
+	}
+	var path []step
+	for l := int(line) - 1; l >= 0 && (level > 0 || dash); l-- {
+		text := strings.TrimSuffix(lines[l], "\r")
+		if separator(text) {
+			break
+		}
+		parent := scanLine(text)
+		if parent.blank || parent.indent > level {
+			continue
+		}
+		if parent.indent == level {
+			// A sequence may be written at the indentation of its key
+			// ("tasks:\n- name: a"); anything else is a sibling.
+			if dash && len(parent.dashes) == 0 && parent.hasKey && parent.value == "" {
+				path = append(path, step{Key: parent.key, Line: uint32(l)})
+				dash = false
+			}
+			continue
+		}
+		if parent.hasKey && parent.col < level {
+			path = append(path, step{Key: parent.key, Line: uint32(l)})
+		}
+		for i := len(parent.dashes) - 1; i >= 0; i-- {
+			if parent.dashes[i] < level {
+				path = append(path, step{Line: uint32(l)})
+			}
+		}
+		level, dash = parent.indent, len(parent.dashes) > 0
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// items returns the sequence item steps opened by the dashes of a line.
+func items(li lineInfo, line uint32) []step {
+	path := make([]step, len(li.dashes))
+	for i := range path {
+		path[i] = step{Line: line}
+	}
+	return path
+}
+
+// resolve follows path from root, returning nil when a step has no node.
+func resolve(root *parser.Node, path []step) *parser.Node {
+	n := root
+	for _, s := range path {
+		if s.Key != "" {
+			n = n.Get(s.Key)
+			continue
+		}
+		var found *parser.Node
+		for _, item := range n.AsSequence() {
+			if item.Range.Start.Line == s.Line {
+				found = item
+				break
+			}
+		}
+		if n = found; n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// lineInfo is the structure of a YAML line.
+type lineInfo struct {
+	// indent is the column of the first non-blank character.
+	indent int
+	// dashes are the columns of the "- " sequence indicators.
+	dashes []int
+	// col is the column of the content after the indicators.
+	col int
+	// key is set for "key:" and "key: value" content; valueCol is the
+	// column of the value and value its text, without a trailing comment.
+	key      string
+	hasKey   bool
+	valueCol int
+	value    string
+	// blank is true for lines with only spaces or a comment.
+	blank bool
+}
+
+func scanLine(line string) lineInfo {
+	var li lineInfo
+	i := 0
+	for i < len(line) && line[i] == ' ' {
+		i++
+	}
+	li.indent = i
+	for i < len(line) && line[i] == '-' && (i+1 == len(line) || line[i+1] == ' ') {
+		li.dashes = append(li.dashes, i)
+		for i++; i < len(line) && line[i] == ' '; i++ {
+		}
+	}
+	li.col = i
+	content := line[i:]
+	if len(li.dashes) == 0 && (strings.TrimSpace(content) == "" || content[0] == '#') {
+		li.blank = true
+		return li
+	}
+	if k := keyLength(content); k > 0 {
+		li.key, li.hasKey = content[:k], true
+		j := i + k + 1
+		for j < len(line) && line[j] == ' ' {
+			j++
+		}
+		li.valueCol = j
+		li.value = strings.TrimRight(stripComment(line[j:]), " ")
+	}
+	return li
+}
+
+// keyLength returns the length of the key of "key:" or "key: value"
+// content, or -1 if it is not a plain key.
+func keyLength(content string) int {
+	if content == "" || strings.ContainsRune("\"'{[#&*!|>%@`", rune(content[0])) {
+		return -1
+	}
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case ':':
+			if i > 0 && (i+1 == len(content) || content[i+1] == ' ') {
+				return i
+			}
+		case '#':
+			if content[i-1] == ' ' {
+				return -1
+			}
+		}
+	}
+	return -1
+}
+
+// stripComment drops a trailing " # comment" from a line.
+func stripComment(s string) string {
+	if strings.HasPrefix(s, "#") {
+		return ""
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// separator reports whether a line separates YAML documents.
+func separator(line string) bool {
+	for _, marker := range []string{"---", "..."} {
+		if rest, ok := strings.CutPrefix(line, marker); ok && (rest == "" || rest[0] == ' ' || rest[0] == '\r') {
+			return true
+		}
+	}
+	return false
+}
+
+func isBlock(n *parser.Node) bool {
+	return n.Style == parser.ScalarStyleLiteral || n.Style == parser.ScalarStyleFolded
+}
+
+func lineRange(line uint32, start, end int) parser.Range {
+	return parser.Range{
+		Start: parser.Position{Line: line, Character: uint32(start)},
+		End:   parser.Position{Line: line, Character: uint32(end)},
+	}
+}
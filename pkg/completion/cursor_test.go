@@ -0,0 +1,180 @@
+package completion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+// keys returns the path of a cursor with "[]" for sequence items.
+func keys(c *cursor) []string {
+	var result []string
+	for _, s := range c.path {
+		if s.Key == "" {
+			result = append(result, "[]")
+		} else {
+			result = append(result, s.Key)
+		}
+	}
+	return result
+}
+
+func TestLocate(t *testing.T) {
+	doc := parse(t, `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: test
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: bu
+    - name: test
+      runAfter:
+      - bu
+      
+    - 
+  finally:
+  - name: notify
+    taskSpec:
+      steps:
+        - name: send
+          script: |
+            echo $(params.
+`)
+
+	tests := []struct {
+		name   string
+		pos    parser.Position
+		path   []string
+		value  bool
+		key    string
+		bare   bool
+		prefix string
+		rng    parser.Range
+	}{
+		{
+			name: "value", pos: parser.Position{Line: 8, Character: 16},
+			path: []string{"spec", "tasks", "[]", "taskRef"}, value: true, key: "name", prefix: "bu",
+			rng: lineRange(8, 14, 16),
+		},
+		{
+			name: "start of value", pos: parser.Position{Line: 8, Character: 14},
+			path: []string{"spec", "tasks", "[]", "taskRef"}, value: true, key: "name", prefix: "",
+			rng: lineRange(8, 14, 16),
+		},
+		{
+			name: "key", pos: parser.Position{Line: 7, Character: 9},
+			path: []string{"spec", "tasks", "[]"}, prefix: "tas", rng: lineRange(7, 6, 13),
+		},
+		{
+			name: "sequence at the key's indentation", pos: parser.Position{Line: 11, Character: 10},
+			path: []string{"spec", "tasks", "[]", "runAfter", "[]"}, value: true, bare: true, prefix: "bu",
+			rng: lineRange(11, 8, 10),
+		},
+		{
+			name: "blank line", pos: parser.Position{Line: 12, Character: 6},
+			path: []string{"spec", "tasks", "[]"}, rng: lineRange(12, 6, 6),
+		},
+		{
+			name: "empty item", pos: parser.Position{Line: 13, Character: 6},
+			path: []string{"spec", "tasks", "[]"}, value: true, bare: true, rng: lineRange(13, 6, 6),
+		},
+		{
+			name: "block scalar", pos: parser.Position{Line: 20, Character: 26},
+			path: []string{"spec", "finally", "[]", "taskSpec", "steps", "[]"}, value: true, key: "script", prefix: "echo $(params.",
+			rng: lineRange(20, 26, 26),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := locate(doc, tt.pos)
+			require.NotNil(t, c)
+			assert.Equal(t, tt.path, keys(c))
+			assert.Equal(t, tt.value, c.value, "value")
+			assert.Equal(t, tt.key, c.key)
+			assert.Equal(t, tt.bare, c.bare, "bare")
+			assert.Equal(t, tt.prefix, c.prefix)
+			assert.Equal(t, tt.rng, c.rng)
+		})
+	}
+}
+
+func TestLocate_Node(t *testing.T) {
+	doc := parse(t, `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        kind: ClusterTask
+        name: 
+    - name: test
+`)
+	c := locate(doc, parser.Position{Line: 7, Character: 14})
+	require.NotNil(t, c)
+	require.NotNil(t, c.node, "the taskRef mapping of the first task")
+	assert.Equal(t, "ClusterTask", c.node.Get("kind").AsScalar())
+	assert.True(t, c.in("tasks", "[]", "taskRef"))
+	assert.False(t, c.in("finally", "[]", "taskRef"))
+}
+
+func TestLocate_OtherDocument(t *testing.T) {
+	docs, err := parser.ParseAllYAML("test.yaml", `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: a
+---
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  
+`)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	pos := parser.Position{Line: 8, Character: 2}
+	assert.Nil(t, locate(docs[0], pos), "the position is in the second document")
+	c := locate(docs[1], pos)
+	require.NotNil(t, c)
+	assert.Equal(t, []string{"metadata"}, keys(c))
+}
+
+func TestUnclosed(t *testing.T) {
+	for line, want := range map[string]string{
+		"runAfter: [fetch, ":          "]",
+		"runAfter: [fetch]":           "",
+		"value: {a: [1, ":             "]}",
+		`values: ["[", `:              "]",
+		"script: echo # [":            "",
+		"args: [$(params.flags[*]), ": "]",
+	} {
+		assert.Equal(t, want, unclosed(line), line)
+	}
+}
+
+func TestCloseFlow(t *testing.T) {
+	docs, err := parser.ParseAllYAML("test.yaml", `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: a
+---
+apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: test
+      runAfter: [lint, 
+`)
+	require.NoError(t, err)
+	pos := parser.Position{Line: 10, Character: 23}
+	doc := closeFlow(docs[0], pos)
+	assert.Equal(t, "Pipeline", doc.Kind, "the document at the position")
+	assert.Equal(t, docs[0].Content, doc.Content, "the text is left as typed")
+	assert.NotNil(t, doc.Root.Get("spec").Get("tasks"))
+
+	assert.Same(t, docs[0], closeFlow(docs[0], parser.Position{Line: 3, Character: 2}))
+}
@@ -0,0 +1,64 @@
+package completion
+
+import "github.com/vdemeester/tekton-lsp-go/pkg/parser"
+
+// Category says what a completion item completes.
+type Category int
+
+const (
+	// CategoryField is a field name; Kind gives the field's type.
+	CategoryField Category = iota
+	// CategoryReference is the name of another resource or declaration.
+	CategoryReference
+	// CategoryVariable is a $(...) variable or a segment of one.
+	CategoryVariable
+	// CategorySnippet is a template of a resource or a block.
+	CategorySnippet
+	// CategoryValue is one of the fixed values of a field.
+	CategoryValue
+)
+
+// CompletionItem represents a single completion suggestion.
+type CompletionItem struct {
+	Label  string
+	Detail string
+	Kind   FieldType
+	// Category is what the item completes.
+	Category Category
+	// Required is true for fields the resource must set.
+	Required bool
+	// Documentation is shown alongside the item, as markdown. Items whose
+	// documentation is costly leave it empty and compute it in Describe.
+	Documentation string
+	describe      func() string
+	// Edit, when set, replaces the text being typed with the item.
+	Edit *TextEdit
+	// Snippet is true when the text inserted is a snippet, with "$1" or
+	// "${1:default}" tab stops and a final "$0" position.
+	Snippet bool
+	// Deprecated is true for fields and values kept for compatibility only.
+	Deprecated bool
+	// SortText orders the items; FilterText, when set, is matched against
+	// the text typed instead of the label.
+	SortText   string
+	FilterText string
+}
+
+// Lazy is true when the item's documentation is only computed by Describe.
+func (i CompletionItem) Lazy() bool {
+	return i.Documentation == "" && i.describe != nil
+}
+
+// Describe returns the documentation of the item, computing it if needed.
+func (i CompletionItem) Describe() string {
+	if i.Lazy() {
+		return i.describe()
+	}
+	return i.Documentation
+}
+
+// TextEdit replaces the text in Range with NewText.
+type TextEdit struct {
+	Range   parser.Range
+	NewText string
+}
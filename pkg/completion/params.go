@@ -0,0 +1,97 @@
+package completion
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/model"
+	"github.com/vdemeester/tekton-lsp-go/pkg/variables"
+)
+
+// completeParamName offers the params of the Task a pipeline task runs as
+// the names of its params, leaving out those already passed. The value key
+// is inserted too, with a skeleton matching the param's type.
+func completeParamName(c *cursor, opts Options) []CompletionItem {
+	if c.key != "name" || !c.in("tasks", "[]", "params", "[]") && !c.in("finally", "[]", "params", "[]") {
+		return nil
+	}
+	_, task := pipelineTaskAt(c)
+	if task == nil {
+		return nil
+	}
+	taskSpec := opts.Index.TaskSpec(task, c.doc, opts.Scope)
+	if taskSpec == nil {
+		return nil
+	}
+
+	passed := make(map[string]bool)
+	for _, p := range task.Params {
+		if p.Name.Node != nil && p.Name.Node.Range.Start.Line != c.pos.Line {
+			passed[p.Name.Value] = true
+		}
+	}
+	// The value key goes below "name", where the client puts the lines
+	// after the first at the indentation of the cursor line.
+	var skeleton string
+	if c.node.Get("value") == nil {
+		li := scanLine(c.line)
+		skeleton = "\n" + strings.Repeat(" ", li.col-li.indent)
+	}
+
+	var items []CompletionItem
+	for _, p := range taskSpec.Params {
+		name := p.Name.Value
+		if name == "" || passed[name] {
+			continue
+		}
+		text := name
+		if skeleton != "" {
+			text += valueSkeleton(p, skeleton)
+		}
+		items = append(items, CompletionItem{
+			Label:         name,
+			Detail:        p.ParamType() + " param",
+			Kind:          FieldTypeString,
+			Category:      CategoryReference,
+			Documentation: paramDocumentation(p),
+			Edit:          &TextEdit{Range: c.rng, NewText: text},
+			Snippet:       skeleton != "",
+		})
+	}
+	return items
+}
+
+// valueSkeleton returns the snippet of the value key of a param, starting
+// each line with indent.
+func valueSkeleton(p *model.ParamSpec, indent string) string {
+	switch p.ParamType() {
+	case "array":
+		return indent + "value:" + indent + "  - $1"
+	case "object":
+		if len(p.Properties) == 0 {
+			return indent + "value:" + indent + "  $1"
+		}
+		var b strings.Builder
+		b.WriteString(indent + "value:")
+		for i, prop := range p.Properties {
+			fmt.Fprintf(&b, "%s  %s: $%d", indent, prop.Name.Value, i+1)
+		}
+		return b.String()
+	default:
+		return indent + "value: $1"
+	}
+}
+
+func paramDocumentation(p *model.ParamSpec) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s** (%s)", p.Name.Value, p.ParamType())
+	if d := strings.TrimSpace(p.Description.Value); d != "" {
+		fmt.Fprintf(&b, "\n\n%s", d)
+	}
+	if p.Default != nil {
+		fmt.Fprintf(&b, "\n\nDefault: `%s`", variables.Value(p.Default))
+	} else {
+		b.WriteString("\n\nRequired: the param has no default.")
+	}
+	return b.String()
+}
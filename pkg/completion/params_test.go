@@ -0,0 +1,112 @@
+package completion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const paramsTask = `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: deploy
+spec:
+  params:
+    - name: revision
+      description: The revision to deploy.
+      default: main
+    - name: flags
+      type: array
+    - name: image
+      type: object
+      properties:
+        url: {}
+        digest: {}
+  steps:
+    - name: deploy
+      image: alpine
+`
+
+func TestComplete_ParamName(t *testing.T) {
+	opts := Options{Index: testIndex(t, map[string]string{"file:///ws/deploy.yaml": paramsTask})}
+
+	doc, pos := parseAt(t, `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: deploy
+      taskRef:
+        name: deploy
+      params:
+        - name: flags
+          value: [-v]
+        - name: ‸
+`)
+	items := CompleteWithOptions(doc, pos, opts)
+	require.Equal(t, []string{"revision", "image"}, completionLabels(items), "params already passed are left out")
+
+	revision := items[0]
+	assert.Equal(t, "string param", revision.Detail)
+	assert.True(t, revision.Snippet)
+	assert.Equal(t, &TextEdit{Range: lineRange(pos.Line, 16, 16), NewText: "revision\n  value: $1"}, revision.Edit)
+	assert.Contains(t, revision.Documentation, "The revision to deploy.")
+	assert.Contains(t, revision.Documentation, "Default: `main`")
+	assert.Equal(t, "image\n  value:\n    url: $1\n    digest: $2", items[1].Edit.NewText)
+}
+
+func TestComplete_ParamNameInlineSpec(t *testing.T) {
+	doc, pos := parseAt(t, `apiVersion: tekton.dev/v1
+kind: PipelineRun
+spec:
+  pipelineSpec:
+    finally:
+      - name: notify
+        taskSpec:
+          params:
+            - name: targets
+              type: array
+          steps:
+            - name: a
+              image: alpine
+        params:
+          -
+            name: t‸
+`)
+	items := CompleteWithOptions(doc, pos, Options{})
+	require.Equal(t, []string{"targets"}, completionLabels(items))
+	assert.Equal(t, "targets\nvalue:\n  - $1", items[0].Edit.NewText)
+	assert.Contains(t, items[0].Documentation, "Required")
+}
+
+func TestComplete_ParamNameWithValue(t *testing.T) {
+	opts := Options{Index: testIndex(t, map[string]string{"file:///ws/deploy.yaml": paramsTask})}
+	doc, pos := parseAt(t, `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: deploy
+      taskRef:
+        name: deploy
+      params:
+        - name: re‸
+          value: v1
+`)
+	items := CompleteWithOptions(doc, pos, opts)
+	require.Equal(t, []string{"revision", "flags", "image"}, completionLabels(items))
+	assert.Equal(t, "revision", items[0].Edit.NewText, "the value is already there")
+	assert.False(t, items[0].Snippet)
+
+	// Unknown tasks have nothing to offer.
+	doc, pos = parseAt(t, `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: deploy
+      taskRef:
+        name: missing
+      params:
+        - name: ‸
+`)
+	assert.Empty(t, CompleteWithOptions(doc, pos, opts))
+}
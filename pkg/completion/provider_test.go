@@ -1,12 +1,15 @@
 package completion
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+	"github.com/vdemeester/tekton-lsp-go/pkg/validator"
 )
 
 func parse(t *testing.T, yaml string) *parser.Document {
@@ -155,3 +158,377 @@ spec:
 		assert.NotEmpty(t, item.Detail, "completion item '%s' should have detail", item.Label)
 	}
 }
+
+func TestComplete_SchemaPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		contains []string
+		absent   []string
+	}{
+		{
+			name: "taskRef",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+        ‸
+`,
+			contains: []string{"kind", "resolver", "params"},
+			absent:   []string{"name"},
+		},
+		{
+			name: "when expression",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: build
+      when:
+        - ‸
+`,
+			contains: []string{"input", "operator", "values", "cel"},
+		},
+		{
+			name: "matrix",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: build
+      matrix:
+        ‸
+`,
+			contains: []string{"params", "include"},
+		},
+		{
+			name: "pipeline task workspaces",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: build
+      workspaces:
+        - name: source
+          ‸
+`,
+			contains: []string{"workspace", "subPath"},
+			absent:   []string{"name", "mountPath"},
+		},
+		{
+			name: "sidecar",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  sidecars:
+    - name: db
+      ‸
+`,
+			contains: []string{"image", "ports", "readinessProbe"},
+		},
+		{
+			name: "stepTemplate",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  stepTemplate:
+    env‸
+`,
+			contains: []string{"env", "image", "computeResources"},
+			absent:   []string{"name", "script"},
+		},
+		{
+			name: "PipelineRun",
+			yaml: `apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  generateName: ci-
+spec:
+  ‸
+`,
+			contains: []string{"pipelineRef", "pipelineSpec", "params", "workspaces", "timeouts", "taskRunTemplate"},
+		},
+		{
+			name: "PipelineRun workspace binding",
+			yaml: `apiVersion: tekton.dev/v1
+kind: PipelineRun
+spec:
+  workspaces:
+    - name: source
+      persistentVolumeClaim:
+        ‸
+`,
+			contains: []string{"claimName", "readOnly"},
+		},
+		{
+			name: "TaskRun",
+			yaml: `apiVersion: tekton.dev/v1
+kind: TaskRun
+spec:
+  taskRef:
+    name: build
+  ‸
+`,
+			contains: []string{"taskSpec", "params", "serviceAccountName", "podTemplate"},
+			absent:   []string{"taskRef"},
+		},
+		{
+			name: "EventListener trigger",
+			yaml: `apiVersion: triggers.tekton.dev/v1beta1
+kind: EventListener
+spec:
+  triggers:
+    - name: push
+      ‸
+`,
+			contains: []string{"bindings", "template", "interceptors"},
+		},
+		{
+			name: "TriggerTemplate",
+			yaml: `apiVersion: triggers.tekton.dev/v1beta1
+kind: TriggerTemplate
+spec:
+  ‸
+`,
+			contains: []string{"params", "resourcetemplates"},
+		},
+		{
+			name: "free-form mapping",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  labels:
+    ‸
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, pos := parseAt(t, tt.yaml)
+			labels := completionLabels(Complete(doc, pos))
+			if len(tt.contains) == 0 {
+				assert.Empty(t, labels)
+			}
+			for _, label := range tt.contains {
+				assert.Contains(t, labels, label)
+			}
+			for _, label := range tt.absent {
+				assert.NotContains(t, labels, label)
+			}
+		})
+	}
+}
+
+func TestComplete_ExistingAndRequiredFields(t *testing.T) {
+	doc, pos := parseAt(t, `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  steps:
+    - name: build
+      scr‸
+      image: golang
+`)
+	items := Complete(doc, pos)
+	labels := completionLabels(items)
+	assert.NotContains(t, labels, "name", "keys already set are left out")
+	assert.NotContains(t, labels, "image", "keys already set are left out")
+	require.Contains(t, labels, "script")
+	for _, item := range items {
+		if item.Label == "script" {
+			assert.False(t, item.Required)
+			assert.Equal(t, &TextEdit{Range: lineRange(5, 6, 9), NewText: "script: $1"}, item.Edit)
+		}
+	}
+
+	doc, pos = parseAt(t, `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  ‸
+`)
+	for _, item := range Complete(doc, pos) {
+		if item.Label == "tasks" {
+			assert.True(t, item.Required)
+			assert.Contains(t, item.Detail, "(required)")
+			return
+		}
+	}
+	t.Fatal("tasks not offered")
+}
+
+func TestComplete_FieldSnippets(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want map[string]string
+	}{
+		{
+			name: "blank line",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  ‸
+`,
+			want: map[string]string{
+				"steps":       "steps:\n  - name: $1\n    image: $2",
+				"description": "description: $1",
+				"params":      "params:\n  - name: $1",
+			},
+		},
+		{
+			name: "after a dash",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - na‸
+`,
+			want: map[string]string{
+				"taskRef": "taskRef:\n    name: $1",
+				"when":    "when:\n    - $1",
+				"timeout": "timeout: $1",
+			},
+		},
+		{
+			name: "nested required fields",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  steps:
+    - name: a
+      ‸
+`,
+			want: map[string]string{
+				"volumeMounts": "volumeMounts:\n  - name: $1\n    mountPath: $2",
+				"stdoutConfig": "stdoutConfig:\n  $1",
+			},
+		},
+		{
+			name: "existing key",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  ste‸:
+`,
+			want: map[string]string{"steps": "steps"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, pos := parseAt(t, tt.yaml)
+			got := make(map[string]CompletionItem)
+			for _, item := range Complete(doc, pos) {
+				got[item.Label] = item
+			}
+			for label, text := range tt.want {
+				require.Contains(t, got, label)
+				assert.Equal(t, text, got[label].Edit.NewText, label)
+				assert.Equal(t, text != label, got[label].Snippet, label)
+			}
+		})
+	}
+}
+
+func TestComplete_Ranking(t *testing.T) {
+	doc, pos := parseAt(t, `apiVersion: tekton.dev/v1
+kind: PipelineRun
+spec:
+  ‸
+`)
+	items := Complete(doc, pos)
+	require.NotEmpty(t, items)
+	position := make(map[string]int)
+	for i, item := range items {
+		position[item.Label] = i
+		assert.Equal(t, fmt.Sprintf("%04d", i), item.SortText)
+	}
+	last := items[len(items)-1]
+	assert.True(t, last.Deprecated, "deprecated fields come last")
+	assert.Less(t, position["timeouts"], position["timeout"])
+	assert.Less(t, position["taskRunTemplate"], position["serviceAccountName"])
+
+	doc, pos = parseAt(t, `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  steps:
+    - ‸
+`)
+	items = Complete(doc, pos)
+	require.NotEmpty(t, items)
+	assert.Equal(t, "image", items[0].Label, "required fields come first")
+}
+
+func TestComplete_FieldDocumentation(t *testing.T) {
+	doc, pos := parseAt(t, `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  steps:
+    - name: a
+      ‸
+`)
+	for _, item := range Complete(doc, pos) {
+		if item.Label == "script" {
+			assert.True(t, item.Lazy(), "documentation is computed on resolve")
+			assert.Contains(t, item.Describe(), "**script**")
+			return
+		}
+	}
+	t.Fatal("script not offered")
+}
+
+// Every field completion offers is accepted by the validator, which checks
+// fields against the same schema.
+func TestComplete_FieldsPassValidation(t *testing.T) {
+	pipeline := `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: ci
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+`
+	task := `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  steps:
+    - name: build
+      image: golang
+`
+	for name, yaml := range map[string]string{
+		"pipeline spec":      pipeline + "  ‸\n",
+		"pipeline task":      strings.Replace(pipeline, "    - name: build\n", "    - name: build\n      ‸\n", 1),
+		"finally task":       pipeline + "  finally:\n    - name: notify\n      ‸\n",
+		"pipeline param":     pipeline + "  params:\n    - name: revision\n      ‸\n",
+		"pipeline workspace": pipeline + "  workspaces:\n    - name: source\n      ‸\n",
+		"pipeline result":    pipeline + "  results:\n    - name: digest\n      ‸\n",
+		"task spec":          task + "  ‸\n",
+		"step":               strings.Replace(task, "      image: golang\n", "      image: golang\n      ‸\n", 1),
+		"task param":         task + "  params:\n    - name: revision\n      ‸\n",
+		"task workspace":     task + "  workspaces:\n    - name: source\n      ‸\n",
+		"task result":        task + "  results:\n    - name: digest\n      ‸\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			doc, pos := parseAt(t, yaml)
+			var fields []string
+			for _, item := range Complete(doc, pos) {
+				if item.Category == CategoryField {
+					fields = append(fields, item.Label)
+				}
+			}
+			require.NotEmpty(t, fields)
+
+			for _, field := range fields {
+				doc := parse(t, strings.Replace(yaml, "‸", field+": x", 1))
+				for _, d := range validator.Validate(doc) {
+					assert.NotEqual(t, validator.RuleUnknownField, d.Code, "%s: %s", field, d.Message)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package completion
+
+import (
+	"github.com/vdemeester/tekton-lsp-go/pkg/hover"
+	"github.com/vdemeester/tekton-lsp-go/pkg/index"
+	"github.com/vdemeester/tekton-lsp-go/pkg/model"
+)
+
+// completeRefName offers the indexed resources a taskRef, pipelineRef or
+// step ref can name, filtered by the ref's kind and apiVersion.
+func completeRefName(c *cursor, opts Options) []CompletionItem {
+	if c.key != "name" || opts.Index == nil {
+		return nil
+	}
+	var defaultKind string
+	switch {
+	case c.in("taskRef"):
+		defaultKind = "Task"
+	case c.in("pipelineRef"):
+		defaultKind = "Pipeline"
+	case c.in("steps", "[]", "ref"):
+		defaultKind = "StepAction"
+	default:
+		return nil
+	}
+	kind, group := defaultKind, "tekton.dev"
+	if ref := model.NewRef(c.node); ref != nil {
+		if ref.Resolver.IsSet() {
+			// Resolved remotely, not from the workspace.
+			return nil
+		}
+		if ref.Kind.Value != "" {
+			kind = ref.Kind.Value
+		}
+		if ref.APIVersion.Value != "" {
+			group = index.Group(ref.APIVersion.Value)
+		}
+	}
+
+	var items []CompletionItem
+	seen := make(map[string]bool)
+	for _, r := range opts.Index.All(group, kind) {
+		if seen[r.Name] || opts.Scope != nil && !opts.Scope(r.URI) {
+			continue
+		}
+		seen[r.Name] = true
+		items = append(items, CompletionItem{
+			Label:    r.Name,
+			Detail:   r.Kind,
+			Kind:     FieldTypeString,
+			Category: CategoryReference,
+			Edit:     &TextEdit{Range: c.rng, NewText: r.Name},
+			describe: describeResource(r),
+		})
+	}
+	return items
+}
+
+// describeResource describes a referenced resource as hovering a reference
+// to it does, once the item is resolved.
+func describeResource(r *index.Resource) func() string {
+	return func() string {
+		return hover.ResourceDocumentation(r.Name, r.Kind, r.URI, r.Doc)
+	}
+}
@@ -0,0 +1,208 @@
+package completion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/index"
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+// testIndex indexes the given files, keyed by URI.
+func testIndex(t *testing.T, files map[string]string) *index.Index {
+	t.Helper()
+	idx := index.New()
+	for uri, content := range files {
+		docs, err := parser.ParseAllYAML(uri, content)
+		require.NoError(t, err)
+		idx.Update(uri, docs)
+	}
+	return idx
+}
+
+var workspaceFiles = map[string]string{
+	"file:///ws/tasks/build.yaml": `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  description: Builds the sources.
+  params:
+    - name: revision
+      description: Revision to build
+    - name: flags
+      type: array
+  results:
+    - name: digest
+      description: The image digest.
+  steps:
+    - name: build
+      image: golang
+`,
+	"file:///ws/tasks/lint.yaml": `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: lint
+spec:
+  steps:
+    - name: lint
+      image: golang
+`,
+	"file:///ws/cluster.yaml": `apiVersion: tekton.dev/v1beta1
+kind: ClusterTask
+metadata:
+  name: git-clone
+spec:
+  steps:
+    - name: clone
+      image: git
+`,
+	"file:///ws/pipeline.yaml": `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: ci
+spec:
+  tasks: []
+`,
+	"file:///ws/action.yaml": `apiVersion: tekton.dev/v1beta1
+kind: StepAction
+metadata:
+  name: checkout
+spec:
+  image: git
+`,
+	"file:///other/task.yaml": `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: deploy
+spec:
+  steps: []
+`,
+}
+
+func TestComplete_RefName(t *testing.T) {
+	opts := Options{Index: testIndex(t, workspaceFiles)}
+
+	tests := []struct {
+		name   string
+		yaml   string
+		pos    parser.Position
+		labels []string
+	}{
+		{
+			name: "taskRef",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: a
+      taskRef:
+        name: 
+`,
+			pos:    parser.Position{Line: 6, Character: 14},
+			labels: []string{"build", "deploy", "lint"},
+		},
+		{
+			name: "taskRef kind",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: a
+      taskRef:
+        kind: ClusterTask
+        name: g
+`,
+			pos:    parser.Position{Line: 7, Character: 15},
+			labels: []string{"git-clone"},
+		},
+		{
+			name: "pipelineRef",
+			yaml: `apiVersion: tekton.dev/v1
+kind: PipelineRun
+spec:
+  pipelineRef:
+    name: 
+`,
+			pos:    parser.Position{Line: 4, Character: 10},
+			labels: []string{"ci"},
+		},
+		{
+			name: "step ref",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  steps:
+    - name: a
+      ref:
+        name: 
+`,
+			pos:    parser.Position{Line: 6, Character: 14},
+			labels: []string{"checkout"},
+		},
+		{
+			name: "resolver",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: a
+      taskRef:
+        resolver: git
+        name: 
+`,
+			pos: parser.Position{Line: 7, Character: 14},
+		},
+		{
+			name: "task name",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: 
+`,
+			pos: parser.Position{Line: 4, Character: 12},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := CompleteWithOptions(parse(t, tt.yaml), tt.pos, opts)
+			if len(tt.labels) == 0 {
+				assert.Empty(t, items)
+				return
+			}
+			assert.Equal(t, tt.labels, completionLabels(items))
+		})
+	}
+}
+
+func TestComplete_RefNameItem(t *testing.T) {
+	opts := Options{
+		Index: testIndex(t, workspaceFiles),
+		Scope: func(uri string) bool { return uri != "file:///other/task.yaml" },
+	}
+	doc := parse(t, `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: a
+      taskRef:
+        name: bu
+`)
+
+	items := CompleteWithOptions(doc, parser.Position{Line: 6, Character: 16}, opts)
+	require.Equal(t, []string{"build", "lint"}, completionLabels(items), "out of scope resources are left out")
+
+	build := items[0]
+	assert.Equal(t, "Task", build.Detail)
+	assert.Equal(t, CategoryReference, build.Category)
+	assert.Equal(t, &TextEdit{Range: lineRange(6, 14, 16), NewText: "build"}, build.Edit)
+	require.True(t, build.Lazy(), "the documentation is computed on resolve")
+	documentation := build.Describe()
+	assert.Contains(t, documentation, "Builds the sources.")
+	assert.Contains(t, documentation, "- `revision` (string): Revision to build")
+	assert.Contains(t, documentation, "- `flags` (array)")
+	assert.Contains(t, documentation, "[build.yaml](file:///ws/tasks/build.yaml)")
+}
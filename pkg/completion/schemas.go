@@ -1,5 +1,13 @@
 package completion
 
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/hover"
+	"github.com/vdemeester/tekton-lsp-go/pkg/schema"
+)
+
 // FieldType represents the type of a Tekton schema field.
 type FieldType int
 
@@ -10,65 +18,140 @@ const (
 	FieldTypeBoolean
 )
 
-// FieldSchema defines a completable field with its metadata.
-type FieldSchema struct {
-	Name        string
-	Description string
-	Type        FieldType
-	Required    bool
+// fieldType returns the completion type of a schema type.
+func fieldType(t schema.Type) FieldType {
+	switch t {
+	case schema.Array:
+		return FieldTypeArray
+	case schema.Object, schema.Map:
+		return FieldTypeObject
+	case schema.Boolean:
+		return FieldTypeBoolean
+	default:
+		return FieldTypeString
+	}
 }
 
-// CompletionItem represents a single completion suggestion.
-type CompletionItem struct {
-	Label  string
-	Detail string
-	Kind   FieldType
-}
+// completeField offers the fields of the resource's schema at the cursor's
+// path, leaving out the keys the mapping already has. On a line without a
+// key yet, the item inserts the key with a skeleton of its value.
+func completeField(c *cursor) []CompletionItem {
+	f := fieldAt(c.doc.Kind, c.path)
+	if f == nil || f.Type != schema.Object {
+		return nil
+	}
+	// The client puts the lines after the first at the indentation of the
+	// cursor line; the value goes below the key, which may follow a dash.
+	var indent string
+	if li := scanLine(c.line); !li.hasKey {
+		indent = "\n" + strings.Repeat(" ", li.col-li.indent)
+	}
 
-var metadataFields = []FieldSchema{
-	{Name: "name", Description: "Resource name (required)", Type: FieldTypeString, Required: true},
-	{Name: "namespace", Description: "Resource namespace", Type: FieldTypeString},
-	{Name: "labels", Description: "Resource labels", Type: FieldTypeObject},
-	{Name: "annotations", Description: "Resource annotations", Type: FieldTypeObject},
+	keys := make([]string, len(c.path))
+	for i, s := range c.path {
+		keys[i] = s.Key
+	}
+	var items []CompletionItem
+	for _, field := range f.Fields {
+		if c.node.Get(field.Name) != nil {
+			continue
+		}
+		detail := field.Type.String()
+		if field.Required {
+			detail += " (required)"
+		}
+		text := field.Name
+		if indent != "" {
+			text = fieldSnippet(field, indent)
+		}
+		kind, path := c.doc.Kind, append(keys[:len(keys):len(keys)], field.Name)
+		items = append(items, CompletionItem{
+			Label:      field.Name,
+			Detail:     detail,
+			Kind:       fieldType(field.Type),
+			Category:   CategoryField,
+			Required:   field.Required,
+			Deprecated: field.Deprecated != "",
+			Edit:       &TextEdit{Range: c.rng, NewText: text},
+			Snippet:    indent != "",
+			describe: func() string {
+				return hover.FieldDocumentation(kind, path...)
+			},
+		})
+	}
+	return items
 }
 
-var pipelineSpecFields = []FieldSchema{
-	{Name: "tasks", Description: "Pipeline tasks to execute", Type: FieldTypeArray, Required: true},
-	{Name: "finally", Description: "Tasks to run after all other tasks", Type: FieldTypeArray},
-	{Name: "params", Description: "Pipeline parameters", Type: FieldTypeArray},
-	{Name: "workspaces", Description: "Pipeline workspaces", Type: FieldTypeArray},
-	{Name: "results", Description: "Pipeline results", Type: FieldTypeArray},
-	{Name: "description", Description: "Pipeline description", Type: FieldTypeString},
+// fieldSnippet returns the snippet of a field's key and value, starting the
+// lines of the value with indent: the fields the value must have, or a tab
+// stop where the value goes.
+func fieldSnippet(f *schema.Field, indent string) string {
+	n := 0
+	return f.Name + ":" + valueSnippet(f, indent, &n)
 }
 
-var pipelineTaskFields = []FieldSchema{
-	{Name: "name", Description: "Task name (required)", Type: FieldTypeString, Required: true},
-	{Name: "taskRef", Description: "Reference to an existing Task", Type: FieldTypeObject},
-	{Name: "taskSpec", Description: "Inline Task specification", Type: FieldTypeObject},
-	{Name: "params", Description: "Task parameters", Type: FieldTypeArray},
-	{Name: "workspaces", Description: "Workspace bindings", Type: FieldTypeArray},
-	{Name: "runAfter", Description: "Tasks that must complete before this task", Type: FieldTypeArray},
-	{Name: "when", Description: "Conditional execution expressions", Type: FieldTypeArray},
-	{Name: "matrix", Description: "Matrix parameters for fan-out", Type: FieldTypeObject},
+func valueSnippet(f *schema.Field, indent string, n *int) string {
+	*n++
+	stop := fmt.Sprintf("$%d", *n)
+	switch f.Type {
+	case schema.Boolean:
+		return fmt.Sprintf(" ${%d|true,false|}", *n)
+	case schema.Map:
+		return indent + "  " + stop
+	case schema.Object:
+		fields := skeletonFields(f)
+		if len(fields) == 0 {
+			return indent + "  " + stop
+		}
+		*n--
+		var b strings.Builder
+		for _, child := range fields {
+			b.WriteString(indent + "  " + child.Name + ":" + valueSnippet(child, indent+"  ", n))
+		}
+		return b.String()
+	case schema.Array:
+		fields := skeletonFields(f.Items)
+		if len(fields) == 0 {
+			return indent + "  - " + stop
+		}
+		*n--
+		var b strings.Builder
+		for i, child := range fields {
+			prefix := indent + "    "
+			if i == 0 {
+				prefix = indent + "  - "
+			}
+			b.WriteString(prefix + child.Name + ":" + valueSnippet(child, indent+"    ", n))
+		}
+		return b.String()
+	default:
+		return " " + stop
+	}
 }
 
-var taskSpecFields = []FieldSchema{
-	{Name: "steps", Description: "Task steps to execute", Type: FieldTypeArray, Required: true},
-	{Name: "params", Description: "Task parameters", Type: FieldTypeArray},
-	{Name: "workspaces", Description: "Task workspaces", Type: FieldTypeArray},
-	{Name: "results", Description: "Task results", Type: FieldTypeArray},
-	{Name: "volumes", Description: "Kubernetes volumes", Type: FieldTypeArray},
-	{Name: "sidecars", Description: "Sidecar containers", Type: FieldTypeArray},
-	{Name: "stepTemplate", Description: "Template for step defaults", Type: FieldTypeObject},
-	{Name: "description", Description: "Task description", Type: FieldTypeString},
+// skeletonFields are the fields written in the skeleton of an object: its
+// name, then the fields it must have.
+func skeletonFields(f *schema.Field) []*schema.Field {
+	if f == nil || f.Type != schema.Object {
+		return nil
+	}
+	var fields []*schema.Field
+	if name := f.Field("name"); name != nil {
+		fields = append(fields, name)
+	}
+	for _, child := range f.Fields {
+		if child.Required && child.Name != "name" {
+			fields = append(fields, child)
+		}
+	}
+	return fields
 }
 
-var stepFields = []FieldSchema{
-	{Name: "name", Description: "Step name (required)", Type: FieldTypeString, Required: true},
-	{Name: "image", Description: "Container image (required)", Type: FieldTypeString, Required: true},
-	{Name: "script", Description: "Script to execute", Type: FieldTypeString},
-	{Name: "command", Description: "Container entrypoint", Type: FieldTypeArray},
-	{Name: "args", Description: "Container arguments", Type: FieldTypeArray},
-	{Name: "env", Description: "Environment variables", Type: FieldTypeArray},
-	{Name: "workingDir", Description: "Working directory", Type: FieldTypeString},
+// fieldAt returns the schema field of a kind at path, or nil.
+func fieldAt(kind string, path []step) *schema.Field {
+	keys := make([]string, len(path))
+	for i, s := range path {
+		keys[i] = s.Key
+	}
+	return schema.For(kind).At(keys...)
 }
@@ -0,0 +1,196 @@
+package completion
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+	"github.com/vdemeester/tekton-lsp-go/pkg/schema"
+)
+
+// skeleton is the snippet of a new resource.
+type skeleton struct {
+	kind string
+	body string
+}
+
+// skeletons are the resources offered in an empty document. Their tab stops
+// go through the names and images to fill in.
+var skeletons = []skeleton{
+	{kind: "Task", body: `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: ${1:name}
+spec:
+  steps:
+    - name: ${2:step}
+      image: ${3:image}
+      script: |
+        $0`},
+	{kind: "Pipeline", body: `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: ${1:name}
+spec:
+  tasks:
+    - name: ${2:task}
+      taskRef:
+        name: ${3:task-name}$0`},
+	{kind: "PipelineRun", body: `apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  generateName: ${1:name}-
+spec:
+  pipelineRef:
+    name: ${2:pipeline}$0`},
+	{kind: "TaskRun", body: `apiVersion: tekton.dev/v1
+kind: TaskRun
+metadata:
+  generateName: ${1:name}-
+spec:
+  taskRef:
+    name: ${2:task}$0`},
+	{kind: "StepAction", body: `apiVersion: tekton.dev/v1beta1
+kind: StepAction
+metadata:
+  name: ${1:name}
+spec:
+  image: ${2:image}
+  script: |
+    $0`},
+	{kind: "TriggerTemplate", body: `apiVersion: triggers.tekton.dev/v1beta1
+kind: TriggerTemplate
+metadata:
+  name: ${1:name}
+spec:
+  params:
+    - name: ${2:param}
+  resourcetemplates:
+    - apiVersion: tekton.dev/v1
+      kind: PipelineRun
+      metadata:
+        generateName: ${3:run}-
+      spec:
+        pipelineRef:
+          name: ${4:pipeline}$0`},
+	{kind: "EventListener", body: `apiVersion: triggers.tekton.dev/v1beta1
+kind: EventListener
+metadata:
+  name: ${1:name}
+spec:
+  serviceAccountName: ${2:tekton-triggers}
+  triggers:
+    - name: ${3:trigger}
+      bindings:
+        - ref: ${4:binding}
+      template:
+        ref: ${5:template}$0`},
+}
+
+// Skeletons returns the resource snippets to offer at pos in a file's
+// content, when the document at pos is still empty: only blank lines and
+// comments between its separators, and a word being typed on the cursor
+// line. It works on the text because empty documents are not parsed.
+func Skeletons(content string, pos parser.Position) []CompletionItem {
+	lines := strings.Split(content, "\n")
+	if int(pos.Line) >= len(lines) {
+		return nil
+	}
+	line := strings.TrimSuffix(lines[pos.Line], "\r")
+	typed := strings.TrimSpace(line)
+	if separator(line) || strings.ContainsAny(typed, ":-#") || strings.HasPrefix(line, " ") {
+		return nil
+	}
+	empty := func(l int) bool {
+		return scanLine(strings.TrimSuffix(lines[l], "\r")).blank
+	}
+	for l := int(pos.Line) - 1; l >= 0 && !separator(lines[l]); l-- {
+		if !empty(l) {
+			return nil
+		}
+	}
+	for l := int(pos.Line) + 1; l < len(lines) && !separator(lines[l]); l++ {
+		if !empty(l) {
+			return nil
+		}
+	}
+
+	rng := lineRange(pos.Line, 0, len(line))
+	items := make([]CompletionItem, len(skeletons))
+	for i, s := range skeletons {
+		apiVersion, _, _ := strings.Cut(strings.TrimPrefix(s.body, "apiVersion: "), "\n")
+		items[i] = CompletionItem{
+			Label:    s.kind,
+			Detail:   "New " + s.kind + " (" + apiVersion + ")",
+			Kind:     FieldTypeObject,
+			Category: CategorySnippet,
+			Snippet:  true,
+			Edit:     &TextEdit{Range: rng, NewText: s.body},
+			// Typing the first key of the resource finds them too.
+			FilterText: "apiVersion kind " + s.kind,
+			SortText:   fmt.Sprintf("%04d", i),
+		}
+	}
+	return items
+}
+
+// blocks are the snippets of new sequence items, by sequence key. They are
+// written for a blank line at the sequence's indentation; the "- " is left
+// out after an existing dash. Clients indent the following lines like the
+// cursor line.
+var blocks = map[string][]struct {
+	label, detail, body string
+}{
+	"steps": {
+		{"new step", "A step running a script", "- name: ${1:name}\n  image: ${2:image}\n  script: |\n    $0"},
+	},
+	"tasks": {
+		{"new task", "A pipeline task running a Task", "- name: ${1:name}\n  taskRef:\n    name: ${2:task}$0"},
+		{"new inline task", "A pipeline task with an inline taskSpec", "- name: ${1:name}\n  taskSpec:\n    steps:\n      - name: ${2:step}\n        image: ${3:image}\n        script: |\n          $0"},
+	},
+}
+
+// completeBlock offers the snippets of a new step or pipeline task, in the
+// steps of a Task spec or the tasks and finally of a Pipeline spec.
+func completeBlock(c *cursor) []CompletionItem {
+	path, dash := c.path, false
+	if c.bare {
+		if len(path) == 0 || path[len(path)-1].Key != "" {
+			return nil
+		}
+		path, dash = path[:len(path)-1], true
+	} else if c.value || scanLine(c.line).hasKey {
+		return nil
+	}
+	if len(path) == 0 {
+		return nil
+	}
+	key := path[len(path)-1].Key
+	if key == "finally" {
+		key = "tasks"
+	}
+	snippets, ok := blocks[key]
+	if !ok {
+		return nil
+	}
+	if f := fieldAt(c.doc.Kind, path); f == nil || f.Type != schema.Array || f.Items.Type != schema.Object {
+		return nil
+	}
+
+	items := make([]CompletionItem, len(snippets))
+	for i, s := range snippets {
+		body := s.body
+		if dash {
+			body = strings.TrimPrefix(body, "- ")
+		}
+		items[i] = CompletionItem{
+			Label:    s.label,
+			Detail:   s.detail,
+			Kind:     FieldTypeObject,
+			Category: CategorySnippet,
+			Snippet:  true,
+			Edit:     &TextEdit{Range: c.rng, NewText: body},
+		}
+	}
+	return items
+}
@@ -0,0 +1,162 @@
+package completion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+func TestSkeletons(t *testing.T) {
+	kinds := []string{"Task", "Pipeline", "PipelineRun", "TaskRun", "StepAction", "TriggerTemplate", "EventListener"}
+	tests := []struct {
+		name    string
+		content string
+		pos     parser.Position
+		want    bool
+	}{
+		{name: "empty file", content: "", pos: parser.Position{Line: 0, Character: 0}, want: true},
+		{name: "typed word", content: "# ci\nPip", pos: parser.Position{Line: 1, Character: 3}, want: true},
+		{
+			name:    "new document",
+			content: "apiVersion: tekton.dev/v1\nkind: Task\n---\n\n",
+			pos:     parser.Position{Line: 3, Character: 0}, want: true,
+		},
+		{
+			name:    "empty document between others",
+			content: "kind: Task\n---\n\n---\nkind: Pipeline\n",
+			pos:     parser.Position{Line: 2, Character: 0}, want: true,
+		},
+		{
+			name:    "document with content",
+			content: "apiVersion: tekton.dev/v1\n\nkind: Task\n",
+			pos:     parser.Position{Line: 1, Character: 0},
+		},
+		{name: "key", content: "apiVersion: ", pos: parser.Position{Line: 0, Character: 12}},
+		{name: "separator", content: "---", pos: parser.Position{Line: 0, Character: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := Skeletons(tt.content, tt.pos)
+			if !tt.want {
+				assert.Empty(t, items)
+				return
+			}
+			assert.Equal(t, kinds, completionLabels(items))
+		})
+	}
+}
+
+func TestSkeletons_Item(t *testing.T) {
+	items := Skeletons("Ta", parser.Position{Line: 0, Character: 2})
+	require.NotEmpty(t, items)
+	task := items[0]
+	assert.True(t, task.Snippet)
+	assert.Equal(t, CategorySnippet, task.Category)
+	assert.Equal(t, "New Task (tekton.dev/v1)", task.Detail)
+	assert.Equal(t, lineRange(0, 0, 2), task.Edit.Range)
+	assert.Contains(t, task.Edit.NewText, "kind: Task\nmetadata:\n  name: ${1:name}\n")
+	assert.Contains(t, task.Edit.NewText, "image: ${3:image}")
+
+	for _, item := range items {
+		switch item.Label {
+		case "StepAction":
+			assert.Contains(t, item.Edit.NewText, "apiVersion: tekton.dev/v1beta1\n")
+		case "TriggerTemplate", "EventListener":
+			assert.Contains(t, item.Edit.NewText, "apiVersion: triggers.tekton.dev/v1beta1\n")
+		}
+	}
+}
+
+func TestComplete_Blocks(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		// edits are the texts of the snippets, nil when none is offered.
+		edits []string
+	}{
+		{
+			name: "blank line in steps",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  steps:
+    ‸
+`,
+			edits: []string{"- name: ${1:name}\n  image: ${2:image}\n  script: |\n    $0"},
+		},
+		{
+			name: "after a dash in steps",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  steps:
+    - name: a
+      image: alpine
+    - ‸
+`,
+			edits: []string{"name: ${1:name}\n  image: ${2:image}\n  script: |\n    $0"},
+		},
+		{
+			name: "finally",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  finally:
+  - ‸
+`,
+			edits: []string{
+				"name: ${1:name}\n  taskRef:\n    name: ${2:task}$0",
+				"name: ${1:name}\n  taskSpec:\n    steps:\n      - name: ${2:step}\n        image: ${3:image}\n        script: |\n          $0",
+			},
+		},
+		{
+			name: "inline task steps",
+			yaml: `apiVersion: tekton.dev/v1
+kind: PipelineRun
+spec:
+  pipelineSpec:
+    tasks:
+      - name: a
+        taskSpec:
+          steps:
+            ‸
+`,
+			edits: []string{"- name: ${1:name}\n  image: ${2:image}\n  script: |\n    $0"},
+		},
+		{
+			name: "not a list of tasks",
+			yaml: `apiVersion: tekton.dev/v1
+kind: PipelineRun
+spec:
+  timeouts:
+    tasks: ‸
+`,
+		},
+		{
+			name: "inside an item",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  steps:
+    - name: a
+      ‸
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, pos := parseAt(t, tt.yaml)
+			var edits []string
+			for _, item := range Complete(doc, pos) {
+				if item.Category == CategorySnippet {
+					assert.True(t, item.Snippet)
+					edits = append(edits, item.Edit.NewText)
+				}
+			}
+			assert.Equal(t, tt.edits, edits)
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package completion
+
+import (
+	"strings"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/model"
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+// enumValue is one of the legal values of a field.
+type enumValue struct {
+	value string
+	doc   string
+}
+
+// deprecatedValues are the enum values kept for compatibility only.
+var deprecatedValues = map[string]bool{"ClusterTask": true}
+
+var resolvers = []enumValue{
+	{"git", "Fetches the resource from a git repository."},
+	{"bundles", "Fetches the resource from a Tekton bundle in an OCI registry."},
+	{"hub", "Fetches the resource from Artifact Hub or Tekton Hub."},
+	{"cluster", "Fetches the resource from another namespace of the cluster."},
+	{"http", "Fetches the resource from an HTTP URL."},
+}
+
+var types = []enumValue{
+	{"string", "A single string (default)."},
+	{"array", "A list of strings."},
+	{"object", "A mapping of string keys, declared by properties."},
+}
+
+// enums are the fields whose legal values are known, by key and path.
+var enums = []struct {
+	key    string
+	path   []string
+	values []enumValue
+}{
+	{"onError", []string{"[]"}, []enumValue{
+		{"stopAndFail", "Fail when it fails (default)."},
+		{"continue", "Ignore the failure and carry on."},
+	}},
+	{"operator", []string{"when", "[]"}, []enumValue{
+		{"in", "input is one of values."},
+		{"notin", "input is none of values."},
+	}},
+	{"type", []string{"params", "[]"}, types},
+	{"type", []string{"results", "[]"}, types},
+	{"kind", []string{"taskRef"}, []enumValue{
+		{"Task", "A namespaced Task (default)."},
+		{"ClusterTask", "A cluster-scoped Task (deprecated: use the `cluster` resolver)."},
+	}},
+	{"resolver", []string{"taskRef"}, resolvers},
+	{"resolver", []string{"pipelineRef"}, resolvers},
+	{"resolver", []string{"steps", "[]", "ref"}, resolvers},
+	{"imagePullPolicy", []string{"[]"}, []enumValue{
+		{"Always", "Pull the image every time."},
+		{"IfNotPresent", "Pull the image when it is not on the node."},
+		{"Never", "Use the image on the node."},
+	}},
+}
+
+// completeEnum offers the legal values of fields with a fixed set of them.
+func completeEnum(c *cursor, _ Options) []CompletionItem {
+	if c.key == "" {
+		return nil
+	}
+	for _, e := range enums {
+		if c.key != e.key || !c.in(e.path...) {
+			continue
+		}
+		items := make([]CompletionItem, len(e.values))
+		for i, v := range e.values {
+			items[i] = valueItem(c.rng, v.value, v.doc, CategoryValue)
+			items[i].Deprecated = deprecatedValues[v.value]
+		}
+		return items
+	}
+	return nil
+}
+
+// completeRunAfter offers the other tasks of the Pipeline as runAfter
+// items, in block or flow style, leaving out those already listed.
+func completeRunAfter(c *cursor, _ Options) []CompletionItem {
+	rng, listed := c.rng, make(map[string]bool)
+	switch {
+	case c.bare && c.in("tasks", "[]", "runAfter", "[]"):
+		if seq := resolve(c.doc.Root, c.path[:len(c.path)-1]); seq != nil {
+			for _, item := range seq.AsSequence() {
+				if item.Range.Start.Line != c.pos.Line {
+					listed[item.AsScalar()] = true
+				}
+			}
+		}
+	case c.key == "runAfter" && c.in("tasks", "[]") && strings.HasPrefix(c.prefix, "["):
+		// A flow sequence being typed, e.g. "[fetch, bu": complete its
+		// last item.
+		var typed string
+		rng, typed = flowItem(c)
+		for _, name := range strings.Split(strings.Trim(strings.TrimSpace(c.line[strings.Index(c.line, "[")+1:]), "[]"), ",") {
+			if name = strings.TrimSpace(name); name != typed {
+				listed[name] = true
+			}
+		}
+	default:
+		return nil
+	}
+
+	spec, current := pipelineTaskAt(c)
+	if spec == nil || current == nil {
+		return nil
+	}
+	var items []CompletionItem
+	for _, t := range spec.Tasks {
+		name := t.Name.Value
+		if t == current || name == "" || listed[name] {
+			continue
+		}
+		items = append(items, valueItem(rng, name, "Pipeline task `"+name+"`.", CategoryReference))
+	}
+	return items
+}
+
+// flowItem returns the range and text of the item of a flow sequence being
+// typed at c.
+func flowItem(c *cursor) (parser.Range, string) {
+	start := strings.LastIndexAny(c.prefix, "[,") + 1
+	typed := strings.TrimLeft(c.prefix[start:], " ")
+	ch := int(c.pos.Character)
+	end := ch
+	for end < len(c.line) && !strings.ContainsRune(",] ", rune(c.line[end])) {
+		end++
+	}
+	return lineRange(c.pos.Line, ch-len(typed), end), typed + c.line[ch:end]
+}
+
+// completeWorkspace offers the Pipeline's workspaces as the workspace a
+// pipeline task binds.
+func completeWorkspace(c *cursor, _ Options) []CompletionItem {
+	if c.key != "workspace" || !c.in("tasks", "[]", "workspaces", "[]") && !c.in("finally", "[]", "workspaces", "[]") {
+		return nil
+	}
+	spec, _ := pipelineTaskAt(c)
+	if spec == nil {
+		return nil
+	}
+	var items []CompletionItem
+	for _, w := range spec.Workspaces {
+		if name := w.Name.Value; name != "" {
+			doc := "Pipeline workspace `" + name + "`."
+			if d := strings.TrimSpace(w.Description.Value); d != "" {
+				doc += "\n\n" + d
+			}
+			items = append(items, valueItem(c.rng, name, doc, CategoryReference))
+		}
+	}
+	return items
+}
+
+// completeServiceAccount offers the ServiceAccounts of the workspace.
+func completeServiceAccount(c *cursor, opts Options) []CompletionItem {
+	if c.key != "serviceAccountName" || opts.Index == nil {
+		return nil
+	}
+	var items []CompletionItem
+	seen := make(map[string]bool)
+	for _, r := range opts.Index.All("", "ServiceAccount") {
+		if seen[r.Name] || opts.Scope != nil && !opts.Scope(r.URI) {
+			continue
+		}
+		seen[r.Name] = true
+		item := valueItem(c.rng, r.Name, "", CategoryReference)
+		item.Detail = r.Kind
+		item.describe = describeResource(r)
+		items = append(items, item)
+	}
+	return items
+}
+
+// pipelineTaskAt returns the Pipeline spec of the document and the pipeline
+// task the cursor is in, if any.
+func pipelineTaskAt(c *cursor) (*model.PipelineSpec, *model.PipelineTask) {
+	var spec *model.PipelineSpec
+	switch obj := model.FromDocument(c.doc).(type) {
+	case *model.Pipeline:
+		spec = &obj.PipelineSpec
+	case *model.PipelineRun:
+		spec = obj.PipelineSpec
+	}
+	if spec == nil {
+		return nil, nil
+	}
+	var current *model.PipelineTask
+	for _, t := range spec.AllTasks() {
+		if c.within(t.Node) {
+			current = t
+		}
+	}
+	return spec, current
+}
+
+func valueItem(rng parser.Range, value, doc string, category Category) CompletionItem {
+	return CompletionItem{
+		Label:         value,
+		Kind:          FieldTypeString,
+		Category:      category,
+		Documentation: doc,
+		Edit:          &TextEdit{Range: rng, NewText: value},
+	}
+}
@@ -0,0 +1,211 @@
+package completion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComplete_Values(t *testing.T) {
+	opts := Options{Index: testIndex(t, map[string]string{
+		"file:///ws/sa.yaml": `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: builder
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: deployer
+`,
+		"file:///ws/cm.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: settings\n",
+	})}
+
+	tests := []struct {
+		name   string
+		yaml   string
+		labels []string
+	}{
+		{
+			name: "pipeline task onError",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: a
+      onError: ‸
+`,
+			labels: []string{"stopAndFail", "continue"},
+		},
+		{
+			name: "step onError",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  steps:
+    - name: a
+      onError: co‸
+`,
+			labels: []string{"stopAndFail", "continue"},
+		},
+		{
+			name: "when operator",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: a
+      when:
+        - input: $(params.x)
+          operator: ‸
+`,
+			labels: []string{"in", "notin"},
+		},
+		{
+			name: "param type",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  params:
+    - name: a
+      type: ‸
+`,
+			labels: []string{"string", "array", "object"},
+		},
+		{
+			name: "taskRef kind",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: a
+      taskRef:
+        kind: ‸
+`,
+			labels: []string{"Task", "ClusterTask"},
+		},
+		{
+			name: "resolver",
+			yaml: `apiVersion: tekton.dev/v1
+kind: PipelineRun
+spec:
+  pipelineRef:
+    resolver: ‸
+`,
+			labels: []string{"git", "bundles", "hub", "cluster", "http"},
+		},
+		{
+			name: "runAfter items",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: fetch
+    - name: lint
+    - name: test
+      runAfter:
+        - fetch
+        - ‸
+    - name: build
+  finally:
+    - name: notify
+`,
+			labels: []string{"lint", "build"},
+		},
+		{
+			name: "runAfter flow sequence",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: fetch
+    - name: lint
+    - name: test
+      runAfter: [lint, ‸
+`,
+			labels: []string{"fetch"},
+		},
+		{
+			name: "workspace binding",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  workspaces:
+    - name: source
+    - name: cache
+  tasks:
+    - name: a
+      workspaces:
+        - name: output
+          workspace: ‸
+`,
+			labels: []string{"source", "cache"},
+		},
+		{
+			name: "serviceAccountName",
+			yaml: `apiVersion: tekton.dev/v1
+kind: TaskRun
+spec:
+  serviceAccountName: ‸
+`,
+			labels: []string{"builder", "deployer"},
+		},
+		{
+			name: "free value",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  params:
+    - name: a
+      description: ‸
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, pos := parseAt(t, tt.yaml)
+			items := CompleteWithOptions(doc, pos, opts)
+			if len(tt.labels) == 0 {
+				assert.Empty(t, items)
+				return
+			}
+			assert.Equal(t, tt.labels, completionLabels(items))
+		})
+	}
+
+	// ServiceAccounts are documented on resolve, like references.
+	doc, pos := parseAt(t, "apiVersion: tekton.dev/v1\nkind: TaskRun\nspec:\n  serviceAccountName: ‸\n")
+	items := CompleteWithOptions(doc, pos, opts)
+	require.NotEmpty(t, items)
+	require.True(t, items[0].Lazy())
+	assert.Contains(t, items[0].Describe(), "[sa.yaml](file:///ws/sa.yaml)")
+}
+
+func TestComplete_ValueEdits(t *testing.T) {
+	doc, pos := parseAt(t, `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  steps:
+    - name: a
+      onError: co‸nt
+`)
+	items := CompleteWithOptions(doc, pos, Options{})
+	require.Len(t, items, 2)
+	assert.Equal(t, CategoryValue, items[1].Category)
+	assert.Equal(t, &TextEdit{Range: lineRange(5, 15, 19), NewText: "continue"}, items[1].Edit)
+	assert.NotEmpty(t, items[1].Documentation)
+
+	doc, pos = parseAt(t, `apiVersion: tekton.dev/v1
+kind: Pipeline
+spec:
+  tasks:
+    - name: fetch
+    - name: test
+      runAfter: [fe‸]
+`)
+	items = CompleteWithOptions(doc, pos, Options{})
+	require.Len(t, items, 1)
+	assert.Equal(t, CategoryReference, items[0].Category)
+	assert.Equal(t, &TextEdit{Range: lineRange(6, 17, 19), NewText: "fetch"}, items[0].Edit)
+}
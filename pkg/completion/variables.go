@@ -0,0 +1,75 @@
+package completion
+
+import (
+	"strings"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/variables"
+)
+
+// openExpression returns the text typed after the last "$(" of prefix, if
+// that expression is not closed yet.
+func openExpression(prefix string) (string, bool) {
+	i := strings.LastIndex(prefix, "$(")
+	if i < 0 {
+		return "", false
+	}
+	expr := prefix[i+2:]
+	if strings.ContainsAny(expr, ") \"'") {
+		return "", false
+	}
+	return expr, true
+}
+
+// completeVariable offers the $(...) variables valid at c, which is inside
+// an unclosed expression. It completes one dotted segment at a time: after
+// "$(tasks." it offers "tasks.build", then that task's "tasks.build.results"
+// and so on, and closes the expression after the last segment.
+func completeVariable(c *cursor, opts Options) []CompletionItem {
+	expr, _ := openExpression(c.prefix)
+	ch := int(c.pos.Character)
+	end := ch
+	for end < len(c.line) && variableChar(c.line[end]) {
+		end++
+	}
+	closed := end < len(c.line) && c.line[end] == ')'
+	rng := lineRange(c.pos.Line, ch-len(expr), end)
+	base := expr[:strings.LastIndex(expr, ".")+1]
+
+	vars := variables.In(c.doc, c.within, variables.Options{Index: opts.Index, Scope: opts.Scope})
+	var items []CompletionItem
+	seen := make(map[string]bool)
+	for _, v := range vars {
+		rest, ok := strings.CutPrefix(v.Name, base)
+		if !ok {
+			continue
+		}
+		segment, _, partial := strings.Cut(rest, ".")
+		label := base + segment
+		if seen[label] {
+			continue
+		}
+		seen[label] = true
+		item := CompletionItem{
+			Label:    label,
+			Kind:     FieldTypeString,
+			Category: CategoryVariable,
+			Edit:     &TextEdit{Range: rng, NewText: label},
+		}
+		if !partial {
+			item.Detail = v.Detail
+			item.Documentation = v.Documentation
+			if !closed {
+				item.Edit.NewText += ")"
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// variableChar reports whether b can be part of a variable name, so the
+// rest of a name after the cursor is replaced too.
+func variableChar(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' ||
+		strings.IndexByte("_-.[]*", b) >= 0
+}
@@ -0,0 +1,244 @@
+package completion
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+// parseAt parses yaml with the cursor marked by "‸" and returns the cursor
+// position.
+func parseAt(t *testing.T, yaml string) (*parser.Document, parser.Position) {
+	t.Helper()
+	before, after, found := strings.Cut(yaml, "‸")
+	require.True(t, found, "no cursor marker")
+	lines := strings.Split(before, "\n")
+	pos := parser.Position{Line: uint32(len(lines) - 1), Character: uint32(len(lines[len(lines)-1]))}
+	return parse(t, before+after), pos
+}
+
+const variablesPipeline = `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: ci
+spec:
+  params:
+    - name: revision
+      description: The revision to build.
+      default: main
+    - name: flags
+      type: array
+    - name: image
+      type: object
+      properties:
+        url: {}
+        digest: {}
+  workspaces:
+    - name: source
+  tasks:
+    - name: fetch
+      taskSpec:
+        params:
+          - name: depth
+        results:
+          - name: commit
+            description: The fetched commit.
+        steps:
+          - name: clone
+            image: git
+            script: |
+              git fetch --depth $(CURSOR_FETCH
+    - name: build
+      taskRef:
+        name: build
+      params:
+        - name: revision
+          value: $(CURSOR_BUILD
+  finally:
+    - name: notify
+      params:
+        - name: status
+          value: $(CURSOR_NOTIFY
+`
+
+// pipelineAt returns the test pipeline with the cursor after the "$(" of
+// marker, followed by typed.
+func pipelineAt(marker, typed string) string {
+	yaml := strings.ReplaceAll(variablesPipeline, marker, typed+"‸")
+	for _, m := range []string{"CURSOR_FETCH", "CURSOR_BUILD", "CURSOR_NOTIFY"} {
+		yaml = strings.ReplaceAll(yaml, m, "x)")
+	}
+	return yaml
+}
+
+func TestComplete_Variables(t *testing.T) {
+	opts := Options{Index: testIndex(t, workspaceFiles)}
+
+	tests := []struct {
+		name   string
+		yaml   string
+		labels []string
+	}{
+		{
+			name:   "pipeline task",
+			yaml:   pipelineAt("CURSOR_BUILD", ""),
+			labels: []string{"params", "workspaces", "tasks", "context"},
+		},
+		{
+			name:   "pipeline params",
+			yaml:   pipelineAt("CURSOR_BUILD", "params.re"),
+			labels: []string{"params.revision", "params.flags[*]", "params.image[*]", "params.image"},
+		},
+		{
+			name:   "other tasks",
+			yaml:   pipelineAt("CURSOR_BUILD", "tasks."),
+			labels: []string{"tasks.fetch"},
+		},
+		{
+			name:   "inline task results",
+			yaml:   pipelineAt("CURSOR_BUILD", "tasks.fetch.results."),
+			labels: []string{"tasks.fetch.results.commit"},
+		},
+		{
+			name:   "finally tasks",
+			yaml:   pipelineAt("CURSOR_NOTIFY", "tasks."),
+			labels: []string{"tasks.fetch", "tasks.build", "tasks.status"},
+		},
+		{
+			name:   "referenced task in finally",
+			yaml:   pipelineAt("CURSOR_NOTIFY", "tasks.build."),
+			labels: []string{"tasks.build.results", "tasks.build.status", "tasks.build.reason"},
+		},
+		{
+			name:   "referenced task results",
+			yaml:   pipelineAt("CURSOR_NOTIFY", "tasks.build.results."),
+			labels: []string{"tasks.build.results.digest"},
+		},
+		{
+			name:   "inline task",
+			yaml:   pipelineAt("CURSOR_FETCH", "params."),
+			labels: []string{"params.depth", "params.revision", "params.flags[*]", "params.image[*]", "params.image"},
+		},
+		{
+			name:   "object param keys",
+			yaml:   pipelineAt("CURSOR_FETCH", "params.image."),
+			labels: []string{"params.image.url", "params.image.digest"},
+		},
+		{
+			name:   "inline task variables",
+			yaml:   pipelineAt("CURSOR_FETCH", ""),
+			labels: []string{"params", "results", "context", "credentials"},
+		},
+		{
+			name: "task",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  workspaces:
+    - name: src
+  steps:
+    - name: a
+      image: alpine
+      args: ["$(workspaces.src.‸"]
+`,
+			labels: []string{"workspaces.src.path", "workspaces.src.bound", "workspaces.src.claim", "workspaces.src.volume"},
+		},
+		{
+			name: "step results",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  steps:
+    - name: a
+      image: alpine
+      results:
+        - name: out
+    - name: b
+      image: alpine
+      results:
+        - name: sum
+      args:
+        - $(‸
+`,
+			labels: []string{"steps", "step", "context", "credentials"},
+		},
+		{
+			name: "step action",
+			yaml: `apiVersion: tekton.dev/v1beta1
+kind: StepAction
+spec:
+  params:
+    - name: url
+  results:
+    - name: digest
+  image: alpine
+  script: echo $(‸
+`,
+			labels: []string{"params", "step"},
+		},
+		{
+			name: "closed expression",
+			yaml: `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  params:
+    - name: a
+  steps:
+    - name: a
+      image: alpine
+      script: echo $(params.a) ‸
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, pos := parseAt(t, tt.yaml)
+			items := CompleteWithOptions(doc, pos, opts)
+			if len(tt.labels) == 0 {
+				assert.Empty(t, items)
+				return
+			}
+			assert.Equal(t, tt.labels, completionLabels(items))
+			for _, item := range items {
+				assert.Equal(t, CategoryVariable, item.Category)
+			}
+		})
+	}
+}
+
+func TestComplete_VariableEdits(t *testing.T) {
+	doc, pos := parseAt(t, pipelineAt("CURSOR_BUILD", "params.rev"))
+	items := CompleteWithOptions(doc, pos, Options{})
+	require.NotEmpty(t, items)
+	revision := items[0]
+	assert.Equal(t, "params.revision", revision.Label)
+	assert.Equal(t, "string param", revision.Detail)
+	assert.Equal(t, &TextEdit{Range: lineRange(pos.Line, 19, 29), NewText: "params.revision)"}, revision.Edit)
+	assert.Contains(t, revision.Documentation, "The revision to build.")
+	assert.Contains(t, revision.Documentation, "Default: `main`")
+
+	// A segment leading to more variables is not closed.
+	doc, pos = parseAt(t, pipelineAt("CURSOR_BUILD", "tasks.fe"))
+	items = CompleteWithOptions(doc, pos, Options{})
+	require.Len(t, items, 1)
+	assert.Equal(t, &TextEdit{Range: lineRange(pos.Line, 19, 27), NewText: "tasks.fetch"}, items[0].Edit)
+
+	// The rest of the name and an existing ")" are kept in mind.
+	doc, pos = parseAt(t, `apiVersion: tekton.dev/v1
+kind: Task
+spec:
+  params:
+    - name: revision
+  steps:
+    - name: a
+      image: alpine
+      script: echo $(params.r‸evison)
+`)
+	items = CompleteWithOptions(doc, pos, Options{})
+	require.Len(t, items, 1)
+	assert.Equal(t, &TextEdit{Range: lineRange(pos.Line, 21, 35), NewText: "params.revision"}, items[0].Edit)
+}
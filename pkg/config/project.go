@@ -0,0 +1,421 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+	"github.com/vdemeester/tekton-lsp-go/pkg/validator"
+	"github.com/vdemeester/tekton-lsp-go/pkg/workspace"
+)
+
+// ProjectFile is the name of the project configuration file. It applies to
+// the files of the directory holding it and of its subdirectories; the
+// nearest one wins, files are not merged.
+//
+//	rules:
+//	  unknown-field: error
+//	disable: [empty-list]
+//	ignore: ["vendor/**"]
+//	resolvers:
+//	  - resolver: git
+//	    params: {pathInRepo: task/build/build.yaml}
+//	    path: task/build/build.yaml
+//	catalogs: [../catalog]
+//	features:
+//	  formatting: false
+//	tektonVersion: v1
+const ProjectFile = ".tekton-lsp.yaml"
+
+// RuleInvalidConfig reports invalid values in a project configuration file.
+const RuleInvalidConfig = "invalid-config"
+
+// Features a project configuration can turn off. They are all on by default.
+const (
+	FeatureDiagnostics = "diagnostics"
+	FeatureCompletion  = "completion"
+	FeatureHover       = "hover"
+	FeatureDefinition  = "definition"
+	FeatureFormatting  = "formatting"
+	FeatureCodeActions = "codeActions"
+	FeatureSymbols     = "symbols"
+)
+
+var features = []string{
+	FeatureDiagnostics, FeatureCompletion, FeatureHover, FeatureDefinition,
+	FeatureFormatting, FeatureCodeActions, FeatureSymbols,
+}
+
+// Project is a project configuration file, shared through the repository
+// rather than per editor. Paths are absolute, resolved against the directory
+// holding the file.
+type Project struct {
+	// Path is the configuration file.
+	Path string
+	// Rules maps a rule name to a severity or "off".
+	Rules map[string]string
+	// Enable turns rules disabled by the editor settings back on; Disable
+	// turns rules off.
+	Enable  []string
+	Disable []string
+	// Ignore lists globs, relative to the file's directory, of files that
+	// are neither indexed nor validated.
+	Ignore []string
+	// Resolvers map remote resolver references to local files.
+	Resolvers []ResolverMapping
+	// Catalogs are directories indexed in addition to the workspace, e.g. a
+	// checkout of a shared task catalog.
+	Catalogs []string
+	// Features turns editor features on or off, by name.
+	Features map[string]bool
+	// TektonVersion is the targeted tekton.dev API version.
+	TektonVersion string
+}
+
+// Dir returns the directory the project configuration applies to.
+func (p *Project) Dir() string {
+	return filepath.Dir(p.Path)
+}
+
+// Enabled reports whether feature is turned on. A nil project enables
+// everything.
+func (p *Project) Enabled(feature string) bool {
+	if p == nil {
+		return true
+	}
+	enabled, ok := p.Features[feature]
+	return !ok || enabled
+}
+
+// Ignores reports whether the file at path is ignored by the project.
+func (p *Project) Ignores(path string) bool {
+	if p == nil || len(p.Ignore) == 0 {
+		return false
+	}
+	rel, err := filepath.Rel(p.Dir(), path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	return !workspace.Options{Exclude: p.Ignore}.Selects(rel)
+}
+
+// Apply returns settings overridden by the project: rules are merged, the
+// project winning, and resolver mappings are tried before the editor's.
+func (p *Project) Apply(s Settings) Settings {
+	if p == nil {
+		return s
+	}
+	rules := make(map[string]string, len(s.Rules)+len(p.Rules))
+	for rule, severity := range s.Rules {
+		rules[rule] = severity
+	}
+	for _, rule := range p.Enable {
+		if rules[rule] == SeverityOff {
+			delete(rules, rule)
+		}
+	}
+	for rule, severity := range p.Rules {
+		rules[rule] = severity
+	}
+	for _, rule := range p.Disable {
+		rules[rule] = SeverityOff
+	}
+	s.Rules = rules
+	s.Resolvers = append(slices.Clone(p.Resolvers), s.Resolvers...)
+	if p.TektonVersion != "" {
+		s.TektonVersion = p.TektonVersion
+	}
+	return s
+}
+
+// FindProject returns the project configuration file nearest to dir: in dir
+// or in one of its parents, up to stop. An empty stop searches up to the
+// filesystem root.
+func FindProject(dir, stop string) (string, bool) {
+	dir = filepath.Clean(dir)
+	if stop != "" {
+		stop = filepath.Clean(stop)
+	}
+	for {
+		path := filepath.Join(dir, ProjectFile)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+		parent := filepath.Dir(dir)
+		if dir == stop || parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// LoadProject reads and parses the project configuration file at path.
+func LoadProject(path string) (*Project, []validator.Diagnostic, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, diags := ParseProject(path, string(content))
+	return p, diags, nil
+}
+
+// ParseProject parses the content of the project configuration file at path.
+// Invalid entries are reported as diagnostics and left out of the project.
+func ParseProject(path, content string) (*Project, []validator.Diagnostic) {
+	pp := &projectParser{p: &Project{Path: path}}
+	if blank(content) {
+		return pp.p, nil
+	}
+	doc, err := parser.ParseYAML(path, content)
+	if err != nil {
+		pp.errorf(parser.Range{}, "Invalid YAML: %v", err)
+		return pp.p, pp.diags
+	}
+	root := doc.Root
+	if root == nil || root.Kind == parser.NodeKindNull {
+		return pp.p, nil
+	}
+	if !root.IsMapping() {
+		pp.errorf(root.Range, "%s must be a mapping", ProjectFile)
+		return pp.p, pp.diags
+	}
+
+	for _, key := range sortedKeys(root) {
+		n := root.MappingChildren[key]
+		switch key {
+		case "rules":
+			pp.rules(n)
+		case "enable":
+			pp.p.Enable = pp.ruleNames(n)
+		case "disable":
+			pp.p.Disable = pp.ruleNames(n)
+		case "ignore":
+			pp.p.Ignore = pp.strings(n)
+		case "resolvers":
+			pp.resolvers(n)
+		case "catalogs":
+			for _, dir := range pp.strings(n) {
+				pp.p.Catalogs = append(pp.p.Catalogs, pp.abs(dir))
+			}
+		case "features":
+			pp.features(n)
+		case "tektonVersion":
+			if v, ok := pp.scalar(n); ok {
+				if _, known := tektonVersions[v]; !known {
+					pp.errorf(valueRange(n), "Unknown Tekton API version %q, expected v1, v1beta1 or v1alpha1", v)
+				} else {
+					pp.p.TektonVersion = v
+				}
+			}
+		default:
+			pp.diags = append(pp.diags, validator.Diagnostic{
+				Range:    n.Range,
+				Severity: validator.SeverityWarning,
+				Source:   "tekton-lsp",
+				Code:     validator.RuleUnknownField,
+				Message:  fmt.Sprintf("Unknown field '%s' in %s", key, ProjectFile),
+			})
+		}
+	}
+	return pp.p, pp.diags
+}
+
+// tektonVersions are the accepted tektonVersion values.
+var tektonVersions = map[string]struct{}{"v1": {}, "v1beta1": {}, "v1alpha1": {}}
+
+// projectParser accumulates a Project and the problems found reading it.
+type projectParser struct {
+	p     *Project
+	diags []validator.Diagnostic
+}
+
+func (pp *projectParser) errorf(r parser.Range, format string, args ...any) {
+	pp.diags = append(pp.diags, validator.Diagnostic{
+		Range:    r,
+		Severity: validator.SeverityError,
+		Source:   "tekton-lsp",
+		Code:     RuleInvalidConfig,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (pp *projectParser) typeError(n *parser.Node, want string) {
+	pp.diags = append(pp.diags, validator.Diagnostic{
+		Range:    n.Range,
+		Severity: validator.SeverityError,
+		Source:   "tekton-lsp",
+		Code:     validator.RuleInvalidType,
+		Message:  fmt.Sprintf("'%s' must be %s", n.Key, want),
+	})
+}
+
+func (pp *projectParser) abs(path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(pp.p.Dir(), path)
+}
+
+func (pp *projectParser) scalar(n *parser.Node) (string, bool) {
+	if !n.IsScalar() {
+		pp.typeError(n, "a string")
+		return "", false
+	}
+	return n.ScalarValue, true
+}
+
+func (pp *projectParser) strings(n *parser.Node) []string {
+	if !n.IsSequence() {
+		pp.typeError(n, "a list of strings")
+		return nil
+	}
+	var values []string
+	for _, item := range n.SequenceChildren {
+		if !item.IsScalar() {
+			pp.errorf(item.Range, "'%s' entries must be strings", n.Key)
+			continue
+		}
+		values = append(values, item.ScalarValue)
+	}
+	return values
+}
+
+func (pp *projectParser) knownRule(n *parser.Node, rule string) bool {
+	if slices.Contains(validator.Rules, rule) {
+		return true
+	}
+	r := n.Range
+	if n.IsScalar() {
+		r = valueRange(n)
+	}
+	pp.errorf(r, "Unknown rule %q", rule)
+	return false
+}
+
+func (pp *projectParser) rules(n *parser.Node) {
+	if !n.IsMapping() {
+		pp.typeError(n, "a mapping of rule names to severities")
+		return
+	}
+	pp.p.Rules = make(map[string]string)
+	for _, rule := range sortedKeys(n) {
+		child := n.MappingChildren[rule]
+		if !pp.knownRule(child, rule) {
+			continue
+		}
+		severity, ok := pp.scalar(child)
+		if !ok {
+			continue
+		}
+		if validSeverity(severity) {
+			pp.p.Rules[rule] = strings.ToLower(severity)
+		} else {
+			pp.errorf(valueRange(child), "Invalid severity %q, expected error, warning, info, hint or off", severity)
+		}
+	}
+}
+
+func (pp *projectParser) ruleNames(n *parser.Node) []string {
+	if !n.IsSequence() {
+		pp.typeError(n, "a list of rule names")
+		return nil
+	}
+	var rules []string
+	for _, item := range n.SequenceChildren {
+		if pp.knownRule(item, item.AsScalar()) {
+			rules = append(rules, item.ScalarValue)
+		}
+	}
+	return rules
+}
+
+func (pp *projectParser) resolvers(n *parser.Node) {
+	if !n.IsSequence() {
+		pp.typeError(n, "a list of resolver mappings")
+		return
+	}
+	for _, item := range n.SequenceChildren {
+		if !item.IsMapping() {
+			pp.errorf(item.Range, "Resolver mappings must be mappings with a resolver and a path")
+			continue
+		}
+		m := ResolverMapping{
+			Resolver: item.Get("resolver").AsScalar(),
+			Path:     item.Get("path").AsScalar(),
+		}
+		if m.Resolver == "" || m.Path == "" {
+			pp.errorf(item.Range, "Resolver mappings need a resolver and a path")
+			continue
+		}
+		if params := item.Get("params"); params != nil {
+			if !params.IsMapping() {
+				pp.typeError(params, "a mapping of param names to values")
+				continue
+			}
+			m.Params = make(map[string]string)
+			for name, value := range params.MappingChildren {
+				m.Params[name] = value.AsScalar()
+			}
+		}
+		m.Path = pp.abs(m.Path)
+		pp.p.Resolvers = append(pp.p.Resolvers, m)
+	}
+}
+
+func (pp *projectParser) features(n *parser.Node) {
+	if !n.IsMapping() {
+		pp.typeError(n, "a mapping of feature names to true or false")
+		return
+	}
+	pp.p.Features = make(map[string]bool)
+	for _, name := range sortedKeys(n) {
+		child := n.MappingChildren[name]
+		if !slices.Contains(features, name) {
+			pp.errorf(child.Range, "Unknown feature %q, expected one of %s", name, strings.Join(features, ", "))
+			continue
+		}
+		switch child.AsScalar() {
+		case "true":
+			pp.p.Features[name] = true
+		case "false":
+			pp.p.Features[name] = false
+		default:
+			pp.typeError(child, "true or false")
+		}
+	}
+}
+
+// blank reports whether content holds nothing but comments.
+func blank(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") && line != "---" {
+			return false
+		}
+	}
+	return true
+}
+
+// valueRange returns the range of a scalar's value, without its key.
+func valueRange(n *parser.Node) parser.Range {
+	return n.ValueRange(0, len(n.ScalarValue))
+}
+
+// sortedKeys returns the keys of a mapping in source order, so diagnostics
+// come out in a stable order.
+func sortedKeys(n *parser.Node) []string {
+	keys := make([]string, 0, len(n.MappingChildren))
+	for key := range n.MappingChildren {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b string) int {
+		ra, rb := n.MappingChildren[a].Range.Start, n.MappingChildren[b].Range.Start
+		if ra.Line != rb.Line {
+			return int(ra.Line) - int(rb.Line)
+		}
+		return int(ra.Character) - int(rb.Character)
+	})
+	return keys
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/validator"
+)
+
+func TestParseProject(t *testing.T) {
+	p, diags := ParseProject("/repo/.tekton-lsp.yaml", `rules:
+  unknown-field: Error
+  empty-list: off
+  unknown-param: information
+enable: [undeclared-param]
+disable: [duplicate-task-name]
+ignore: ["vendor/**"]
+resolvers:
+  - resolver: git
+    params:
+      pathInRepo: task/build.yaml
+    path: tasks/build.yaml
+catalogs: [../catalog, /abs/catalog]
+features:
+  formatting: false
+tektonVersion: v1
+`)
+	assert.Empty(t, diags)
+	assert.Equal(t, "/repo", p.Dir())
+	assert.Equal(t, map[string]string{"unknown-field": "error", "empty-list": "off", "unknown-param": "information"}, p.Rules)
+	assert.Equal(t, []string{"undeclared-param"}, p.Enable)
+	assert.Equal(t, []string{"duplicate-task-name"}, p.Disable)
+	assert.Equal(t, []string{"vendor/**"}, p.Ignore)
+	assert.Equal(t, []ResolverMapping{{
+		Resolver: "git",
+		Params:   map[string]string{"pathInRepo": "task/build.yaml"},
+		Path:     "/repo/tasks/build.yaml",
+	}}, p.Resolvers)
+	assert.Equal(t, []string{"/catalog", "/abs/catalog"}, p.Catalogs)
+	assert.False(t, p.Enabled(FeatureFormatting))
+	assert.True(t, p.Enabled(FeatureHover))
+	assert.Equal(t, "v1", p.TektonVersion)
+}
+
+func TestParseProject_Empty(t *testing.T) {
+	p, diags := ParseProject("/repo/.tekton-lsp.yaml", "")
+	assert.Empty(t, diags)
+	assert.True(t, p.Enabled(FeatureDiagnostics))
+}
+
+func TestParseProject_Diagnostics(t *testing.T) {
+	p, diags := ParseProject("/repo/.tekton-lsp.yaml", `rules:
+  unknown-field: fatal
+  no-such-rule: error
+disable: [bogus]
+ignore: vendor
+features:
+  telemetry: true
+  hover: maybe
+tektonVersion: v2
+extra: 1
+`)
+	type problem struct {
+		line    uint32
+		code    string
+		message string
+	}
+	var got []problem
+	for _, d := range diags {
+		got = append(got, problem{d.Range.Start.Line, d.Code, d.Message})
+	}
+	assert.Equal(t, []problem{
+		{1, RuleInvalidConfig, `Invalid severity "fatal", expected error, warning, info, hint or off`},
+		{2, RuleInvalidConfig, `Unknown rule "no-such-rule"`},
+		{3, RuleInvalidConfig, `Unknown rule "bogus"`},
+		{4, validator.RuleInvalidType, "'ignore' must be a list of strings"},
+		{6, RuleInvalidConfig, `Unknown feature "telemetry", expected one of diagnostics, completion, hover, definition, formatting, codeActions, symbols`},
+		{7, validator.RuleInvalidType, "'hover' must be true or false"},
+		{8, RuleInvalidConfig, `Unknown Tekton API version "v2", expected v1, v1beta1 or v1alpha1`},
+		{9, validator.RuleUnknownField, "Unknown field 'extra' in .tekton-lsp.yaml"},
+	}, got)
+	assert.Empty(t, p.Rules, "invalid entries are left out")
+
+	// The severity diagnostic points at the value.
+	assert.Equal(t, uint32(17), diags[0].Range.Start.Character)
+}
+
+func TestProject_Apply(t *testing.T) {
+	settings := Default()
+	settings.Rules = map[string]string{"unknown-field": "off", "empty-list": "hint", "undeclared-param": "off"}
+	settings.Resolvers = []ResolverMapping{{Resolver: "hub", Path: "/editor.yaml"}}
+	settings.TektonVersion = "v1beta1"
+
+	p := &Project{
+		Rules:         map[string]string{"empty-list": "error"},
+		Enable:        []string{"unknown-field"},
+		Disable:       []string{"missing-field"},
+		Resolvers:     []ResolverMapping{{Resolver: "git", Path: "/project.yaml"}},
+		TektonVersion: "v1",
+	}
+	got := p.Apply(settings)
+	assert.Equal(t, map[string]string{"empty-list": "error", "undeclared-param": "off", "missing-field": "off"}, got.Rules)
+	assert.Equal(t, []ResolverMapping{{Resolver: "git", Path: "/project.yaml"}, {Resolver: "hub", Path: "/editor.yaml"}}, got.Resolvers)
+	assert.Equal(t, "v1", got.TektonVersion)
+	assert.Equal(t, "off", settings.Rules["unknown-field"], "the settings are not modified")
+
+	var none *Project
+	assert.Equal(t, settings, none.Apply(settings))
+}
+
+func TestProject_Ignores(t *testing.T) {
+	p := &Project{Path: "/repo/.tekton-lsp.yaml", Ignore: []string{"vendor", "*.generated.yaml"}}
+	assert.True(t, p.Ignores("/repo/vendor/tekton/task.yaml"))
+	assert.True(t, p.Ignores("/repo/tekton/pipeline.generated.yaml"))
+	assert.False(t, p.Ignores("/repo/tekton/pipeline.yaml"))
+	assert.False(t, p.Ignores("/other/vendor/task.yaml"))
+}
+
+func TestFindProject(t *testing.T) {
+	root := t.TempDir()
+	sub := filepath.Join(root, "team", "tekton")
+	require.NoError(t, os.MkdirAll(sub, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ProjectFile), nil, 0o644))
+
+	path, ok := FindProject(sub, root)
+	require.True(t, ok)
+	assert.Equal(t, filepath.Join(root, ProjectFile), path)
+
+	// The nearest file wins.
+	require.NoError(t, os.WriteFile(filepath.Join(root, "team", ProjectFile), nil, 0o644))
+	path, ok = FindProject(sub, root)
+	require.True(t, ok)
+	assert.Equal(t, filepath.Join(root, "team", ProjectFile), path)
+
+	// The search stops at stop.
+	_, ok = FindProject(sub, sub)
+	assert.False(t, ok)
+}
@@ -0,0 +1,163 @@
+// Package config holds the server settings sent by the editor.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/validator"
+)
+
+// Section is the configuration section holding the settings, as requested
+// with workspace/configuration.
+const Section = "tekton"
+
+// Severities accepted in Settings.Rules, besides the other names
+// validator.ParseSeverity knows (such as "information"). Off disables a rule.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+	SeverityHint    = "hint"
+	SeverityOff     = "off"
+)
+
+// Settings is the server configuration. Editors send it as
+// initializationOptions and with workspace/didChangeConfiguration, or the
+// server pulls it with workspace/configuration.
+type Settings struct {
+	// Rules maps a rule name (e.g. "unknown-field") to a severity or "off".
+	Rules map[string]string `json:"rules,omitempty"`
+	// Formatting configures textDocument/formatting.
+	Formatting Formatting `json:"formatting"`
+	// Scan selects the workspace files to index.
+	Scan Scan `json:"scan"`
+	// Resolvers map remote resolver references to local files.
+	Resolvers []ResolverMapping `json:"resolvers,omitempty"`
+	// CrossFolderLookup lets references resolve to resources of other
+	// workspace folders when none is found in the referencing file's folder.
+	CrossFolderLookup bool `json:"crossFolderLookup,omitempty"`
+	// TektonVersion is the targeted tekton.dev API version ("v1",
+	// "v1beta1"). Resources using an older version are reported. Empty
+	// disables the check.
+	TektonVersion string `json:"tektonVersion,omitempty"`
+}
+
+// Formatting configures the formatter.
+type Formatting struct {
+	// IndentSize is the number of spaces per indentation level.
+	IndentSize int `json:"indentSize"`
+}
+
+// Scan selects the files indexed from the workspace, with globs relative to
+// the workspace root ("**" matches any number of directories).
+type Scan struct {
+	// Include restricts indexing to matching files when not empty.
+	Include []string `json:"include,omitempty"`
+	// Exclude skips matching files and directories.
+	Exclude []string `json:"exclude,omitempty"`
+	// GitIgnore skips the files ignored by git.
+	GitIgnore bool `json:"gitignore"`
+	// MaxFileSize skips files larger than this many bytes, typically
+	// generated manifests. Zero means no limit.
+	MaxFileSize int64 `json:"maxFileSize"`
+}
+
+// DefaultMaxFileSize is the default Scan.MaxFileSize: 1 MiB.
+const DefaultMaxFileSize = 1 << 20
+
+// ResolverMapping maps references through a remote resolver to a local file,
+// so go-to-definition works on them. A reference matches when it uses
+// Resolver and passes every param listed in Params with the same value.
+type ResolverMapping struct {
+	Resolver string            `json:"resolver"`
+	Params   map[string]string `json:"params,omitempty"`
+	// Path is the local file, relative to the workspace root or absolute.
+	Path string `json:"path"`
+}
+
+// Default returns the settings used when the editor sends none.
+func Default() Settings {
+	return Settings{
+		Formatting: Formatting{IndentSize: 2},
+		Scan:       Scan{GitIgnore: true, MaxFileSize: DefaultMaxFileSize},
+	}
+}
+
+// Parse decodes settings sent by the editor: either the settings object
+// itself or an object holding it under the "tekton" section. Fields that are
+// not set keep their default. A nil value yields the defaults.
+func Parse(raw any) (Settings, error) {
+	settings := Default()
+	if raw == nil {
+		return settings, nil
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return settings, err
+	}
+	var wrapped map[string]json.RawMessage
+	if err := json.Unmarshal(data, &wrapped); err == nil {
+		if section, ok := wrapped[Section]; ok {
+			data = section
+		}
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return Default(), fmt.Errorf("invalid settings: %w", err)
+	}
+	if err := settings.validate(); err != nil {
+		return Default(), err
+	}
+	return settings, nil
+}
+
+func (s *Settings) validate() error {
+	for rule, severity := range s.Rules {
+		if !validSeverity(severity) {
+			return fmt.Errorf("invalid severity %q for rule %q", severity, rule)
+		}
+		s.Rules[rule] = strings.ToLower(severity)
+	}
+	if s.Formatting.IndentSize <= 0 {
+		s.Formatting.IndentSize = Default().Formatting.IndentSize
+	}
+	if s.Scan.MaxFileSize < 0 {
+		return fmt.Errorf("invalid scan.maxFileSize %d", s.Scan.MaxFileSize)
+	}
+	for _, m := range s.Resolvers {
+		if m.Resolver == "" || m.Path == "" {
+			return fmt.Errorf("resolver mappings need a resolver and a path")
+		}
+	}
+	switch s.TektonVersion {
+	case "", "v1", "v1beta1", "v1alpha1":
+	default:
+		return fmt.Errorf("invalid tektonVersion %q", s.TektonVersion)
+	}
+	return nil
+}
+
+// Matches reports whether a reference through resolver with the given
+// params is mapped by m.
+func (m ResolverMapping) Matches(resolver string, params map[string]string) bool {
+	if m.Resolver != resolver {
+		return false
+	}
+	for name, value := range m.Params {
+		if params[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// validSeverity reports whether severity is "off" or a severity the
+// validator knows, so that every accepted setting is applied.
+func validSeverity(severity string) bool {
+	if strings.EqualFold(severity, SeverityOff) {
+		return true
+	}
+	_, ok := validator.ParseSeverity(severity)
+	return ok
+}
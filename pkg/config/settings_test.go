@@ -0,0 +1,81 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse_Nil(t *testing.T) {
+	settings, err := Parse(nil)
+	require.NoError(t, err)
+	assert.Equal(t, Default(), settings)
+}
+
+func TestParse(t *testing.T) {
+	raw := map[string]any{
+		"rules":         map[string]any{"unknown-field": "Warning", "empty-list": "off"},
+		"formatting":    map[string]any{"indentSize": 4},
+		"scan":          map[string]any{"exclude": []any{"vendor/**"}},
+		"resolvers":     []any{map[string]any{"resolver": "git", "params": map[string]any{"pathInRepo": "task/build.yaml"}, "path": "tasks/build.yaml"}},
+		"tektonVersion": "v1",
+	}
+
+	for name, input := range map[string]any{
+		"unwrapped": raw,
+		"wrapped":   map[string]any{"tekton": raw},
+	} {
+		t.Run(name, func(t *testing.T) {
+			settings, err := Parse(input)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"unknown-field": SeverityWarning, "empty-list": SeverityOff}, settings.Rules)
+			assert.Equal(t, 4, settings.Formatting.IndentSize)
+			assert.Equal(t, []string{"vendor/**"}, settings.Scan.Exclude)
+			assert.True(t, settings.Scan.GitIgnore, "unset fields keep their default")
+			require.Len(t, settings.Resolvers, 1)
+			assert.Equal(t, "tasks/build.yaml", settings.Resolvers[0].Path)
+			assert.Equal(t, "v1", settings.TektonVersion)
+		})
+	}
+}
+
+func TestParse_SeverityNames(t *testing.T) {
+	// Every severity the validator parses is accepted.
+	settings, err := Parse(map[string]any{"rules": map[string]any{"unknown-field": "Information", "empty-list": "hint"}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"unknown-field": "information", "empty-list": SeverityHint}, settings.Rules)
+}
+
+func TestParse_Defaults(t *testing.T) {
+	settings, err := Parse(map[string]any{"formatting": map[string]any{"indentSize": 0}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, settings.Formatting.IndentSize)
+
+	settings, err = Parse(map[string]any{"scan": map[string]any{"gitignore": false}})
+	require.NoError(t, err)
+	assert.False(t, settings.Scan.GitIgnore)
+	assert.Equal(t, int64(DefaultMaxFileSize), settings.Scan.MaxFileSize)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for name, raw := range map[string]any{
+		"severity":      map[string]any{"rules": map[string]any{"unknown-field": "fatal"}},
+		"resolver":      map[string]any{"resolvers": []any{map[string]any{"resolver": "git"}}},
+		"tektonVersion": map[string]any{"tektonVersion": "v2"},
+		"type":          map[string]any{"formatting": "wide"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			settings, err := Parse(raw)
+			assert.Error(t, err)
+			assert.Equal(t, Default(), settings)
+		})
+	}
+}
+
+func TestResolverMapping_Matches(t *testing.T) {
+	m := ResolverMapping{Resolver: "git", Params: map[string]string{"pathInRepo": "task.yaml"}}
+	assert.True(t, m.Matches("git", map[string]string{"pathInRepo": "task.yaml", "revision": "main"}))
+	assert.False(t, m.Matches("git", map[string]string{"pathInRepo": "other.yaml"}))
+	assert.False(t, m.Matches("bundles", map[string]string{"pathInRepo": "task.yaml"}))
+}
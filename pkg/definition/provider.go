@@ -2,7 +2,11 @@ package definition
 
 import (
 	"github.com/vdemeester/tekton-lsp-go/pkg/cache"
+	"github.com/vdemeester/tekton-lsp-go/pkg/config"
+	"github.com/vdemeester/tekton-lsp-go/pkg/index"
+	"github.com/vdemeester/tekton-lsp-go/pkg/model"
 	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+	"github.com/vdemeester/tekton-lsp-go/pkg/workspace"
 )
 
 // Location represents a target definition location.
@@ -11,34 +15,142 @@ type Location struct {
 	Range parser.Range
 }
 
+// Options configures go-to-definition.
+type Options struct {
+	// Resolvers map remote resolver references to local files. Their paths
+	// must be absolute.
+	Resolvers []config.ResolverMapping
+	// Scope, when set, restricts references to resources in the files for
+	// which it returns true, e.g. those of the document's workspace folder.
+	Scope func(uri string) bool
+	// CrossScope falls back to resources outside Scope when none is found
+	// in it.
+	CrossScope bool
+}
+
 // GotoDefinition resolves a taskRef/pipelineRef at the given position to its definition.
+// On a YAML alias (*name) it resolves to the anchor (&name) the alias refers to.
 func GotoDefinition(doc *parser.Document, pos parser.Position, c *cache.Cache) *Location {
+	return GotoDefinitionWithOptions(doc, pos, c, Options{})
+}
+
+// GotoDefinitionWithOptions is GotoDefinition with resolver references
+// followed through opts.Resolvers.
+func GotoDefinitionWithOptions(doc *parser.Document, pos parser.Position, c *cache.Cache, opts Options) *Location {
+	if loc := anchorDefinition(doc, pos); loc != nil {
+		return loc
+	}
+	target := ResolveReference(doc, pos, c, opts)
+	if target == nil {
+		return nil
+	}
+	loc := &Location{URI: target.URI}
+	if target.Doc != nil {
+		loc.Range = target.Doc.Root.Range
+	}
+	return loc
+}
+
+// Target is a taskRef, pipelineRef or step ref and the resource it
+// resolves to.
+type Target struct {
+	// Ref is the reference.
+	Ref *model.Ref
+	// URI is the file defining the resource.
+	URI string
+	// Doc is the document of the resource, nil when a resolver reference is
+	// mapped to a file that is not indexed.
+	Doc *parser.Document
+}
+
+// ResolveReference returns the resource the taskRef, pipelineRef or step
+// ref at pos refers to, or nil when there is no reference at pos or it does
+// not resolve.
+func ResolveReference(doc *parser.Document, pos parser.Position, c *cache.Cache, opts Options) *Target {
 	// Find what reference we're on.
 	ref := findReference(doc.Root, pos)
 	if ref == nil {
 		return nil
 	}
+	if ref.ref.Resolver.IsSet() {
+		return resolverDefinition(ref, c, opts.Resolvers)
+	}
 
-	// Search all cached documents for a matching resource.
-	for _, parsed := range c.AllParsed() {
-		if parsed.Kind == ref.kind {
-			if metadata := parsed.Root.Get("metadata"); metadata != nil {
-				if name := metadata.Get("name"); name != nil && name.AsScalar() == ref.name {
-					return &Location{
-						URI:   parsed.Filename,
-						Range: parsed.Root.Range,
-					}
-				}
+	namespace := doc.Root.Get("metadata").Get("namespace").AsScalar()
+	key, ok := index.RefKey(ref.ref, ref.defaultKind, namespace)
+	if !ok {
+		return nil
+	}
+	target := c.Index().ResolveFrom(key, doc.Filename, opts.Scope)
+	if target == nil && opts.Scope != nil && opts.CrossScope {
+		target = c.Index().ResolveFrom(key, doc.Filename, nil)
+	}
+	if target == nil {
+		return nil
+	}
+	return &Target{Ref: ref.ref, URI: target.URI, Doc: target.Doc}
+}
+
+// resolverDefinition returns the local file a resolver reference is mapped
+// to, with the resource of the expected kind when the file is indexed (or
+// its first resource).
+func resolverDefinition(ref *reference, c *cache.Cache, mappings []config.ResolverMapping) *Target {
+	params := make(map[string]string, len(ref.ref.Params))
+	for _, p := range ref.ref.Params {
+		params[p.Name.Value] = p.Value.AsScalar()
+	}
+	kind := ref.defaultKind
+	if ref.ref.Kind.Value != "" {
+		kind = ref.ref.Kind.Value
+	}
+
+	for _, m := range mappings {
+		if !m.Matches(ref.ref.Resolver.Value, params) {
+			continue
+		}
+		target := &Target{Ref: ref.ref, URI: workspace.URIFromPath(m.Path)}
+		docs, _ := c.GetAllParsed(target.URI)
+		for _, doc := range docs {
+			if doc.Root == nil {
+				continue
+			}
+			if doc.Kind == kind {
+				target.Doc = doc
+				break
+			}
+			if target.Doc == nil {
+				target.Doc = doc
 			}
 		}
+		return target
 	}
-
 	return nil
 }
 
+// anchorDefinition returns the anchor location when pos is on an alias.
+func anchorDefinition(doc *parser.Document, pos parser.Position) *Location {
+	node := doc.FindNodeAtPosition(pos)
+	if node == nil || node.Origin == nil || !posInRange(pos, node.AliasRange) {
+		return nil
+	}
+	return &Location{
+		URI:   doc.Filename,
+		Range: node.Origin.AnchorRange,
+	}
+}
+
+// reference is a taskRef, pipelineRef or step ref found under the cursor.
 type reference struct {
-	kind string
-	name string
+	ref         *model.Ref
+	defaultKind string
+}
+
+// refDefaultKinds maps reference fields to the kind they point to when the
+// ref does not set one.
+var refDefaultKinds = map[string]string{
+	"taskRef":     "Task",
+	"pipelineRef": "Pipeline",
+	"ref":         "StepAction",
 }
 
 // findReference walks the AST looking for a taskRef/pipelineRef at the given position.
@@ -53,19 +165,8 @@ func findReference(node *parser.Node, pos parser.Position) *reference {
 				continue
 			}
 
-			switch key {
-			case "taskRef":
-				if nameNode := child.Get("name"); nameNode != nil {
-					kind := "Task"
-					if k := child.Get("kind"); k != nil && k.AsScalar() != "" {
-						kind = k.AsScalar()
-					}
-					return &reference{kind: kind, name: nameNode.AsScalar()}
-				}
-			case "pipelineRef":
-				if nameNode := child.Get("name"); nameNode != nil {
-					return &reference{kind: "Pipeline", name: nameNode.AsScalar()}
-				}
+			if kind, ok := refDefaultKinds[key]; ok && (child.Get("name") != nil || child.Get("resolver") != nil) {
+				return &reference{ref: model.NewRef(child), defaultKind: kind}
 			}
 
 			// Recurse deeper.
@@ -1,12 +1,14 @@
 package definition
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/vdemeester/tekton-lsp-go/pkg/cache"
+	"github.com/vdemeester/tekton-lsp-go/pkg/config"
 	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
 )
 
@@ -117,3 +119,130 @@ spec:
 	require.NotNil(t, result, "should find definition for pipelineRef")
 	assert.Equal(t, "file:///workspace/pipelines/build.yaml", result.URI)
 }
+
+func TestGotoDefinition_Alias(t *testing.T) {
+	c := cache.New()
+
+	c.Insert("file:///test.yaml", "yaml", 1, `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  stepTemplate: &defaults
+    image: golang:1.25
+  steps:
+    - name: build
+      <<: *defaults
+    - name: test
+      env: *defaults
+`)
+
+	doc, _ := c.GetParsed("file:///test.yaml")
+
+	// On "*defaults" in the merge key (line 9).
+	result := GotoDefinition(doc, parser.Position{Line: 9, Character: 12}, c)
+	require.NotNil(t, result, "should jump from merge alias to anchor")
+	assert.Equal(t, "file:///test.yaml", result.URI)
+	assert.Equal(t, uint32(5), result.Range.Start.Line)
+	assert.Equal(t, uint32(16), result.Range.Start.Character)
+
+	// On "*defaults" as a plain value (line 11).
+	result = GotoDefinition(doc, parser.Position{Line: 11, Character: 13}, c)
+	require.NotNil(t, result, "should jump from alias value to anchor")
+	assert.Equal(t, uint32(5), result.Range.Start.Line)
+
+	// On the "env" key itself, not the alias.
+	result = GotoDefinition(doc, parser.Position{Line: 11, Character: 7}, c)
+	assert.Nil(t, result)
+}
+
+func TestGotoDefinition_ResolverMapping(t *testing.T) {
+	c := cache.New()
+	c.Insert("file:///workspace/catalog/git-clone.yaml", "yaml", 1, `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: git-clone
+`)
+	c.Insert("file:///workspace/pipeline.yaml", "yaml", 1, `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: main
+spec:
+  tasks:
+    - name: clone
+      taskRef:
+        resolver: hub
+        params:
+          - name: name
+            value: git-clone
+`)
+	pipeline, _ := c.GetParsed("file:///workspace/pipeline.yaml")
+	pos := parser.Position{Line: 8, Character: 18}
+
+	assert.Nil(t, GotoDefinition(pipeline, pos, c), "resolver references are not followed without a mapping")
+
+	opts := Options{Resolvers: []config.ResolverMapping{
+		{Resolver: "hub", Params: map[string]string{"name": "buildah"}, Path: "/workspace/catalog/buildah.yaml"},
+		{Resolver: "hub", Params: map[string]string{"name": "git-clone"}, Path: "/workspace/catalog/git-clone.yaml"},
+	}}
+	result := GotoDefinitionWithOptions(pipeline, pos, c, opts)
+	require.NotNil(t, result)
+	assert.Equal(t, "file:///workspace/catalog/git-clone.yaml", result.URI)
+	assert.Equal(t, uint32(0), result.Range.Start.Line)
+}
+
+func TestGotoDefinition_Scope(t *testing.T) {
+	task := "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build\n"
+	c := cache.New()
+	c.Insert("file:///a/task.yaml", "yaml", 1, task)
+	c.Insert("file:///b/pipeline.yaml", "yaml", 1, `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: main
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+`)
+	pipeline, _ := c.GetParsed("file:///b/pipeline.yaml")
+	pos := parser.Position{Line: 8, Character: 15}
+	inB := func(uri string) bool { return strings.HasPrefix(uri, "file:///b/") }
+
+	assert.Nil(t, GotoDefinitionWithOptions(pipeline, pos, c, Options{Scope: inB}), "tasks of other folders are out of scope")
+
+	result := GotoDefinitionWithOptions(pipeline, pos, c, Options{Scope: inB, CrossScope: true})
+	require.NotNil(t, result)
+	assert.Equal(t, "file:///a/task.yaml", result.URI)
+
+	// A task in scope wins over one found across scopes.
+	c.Insert("file:///b/task.yaml", "yaml", 1, task)
+	result = GotoDefinitionWithOptions(pipeline, pos, c, Options{Scope: inB, CrossScope: true})
+	require.NotNil(t, result)
+	assert.Equal(t, "file:///b/task.yaml", result.URI)
+}
+
+func TestResolveReference(t *testing.T) {
+	c := cache.New()
+	c.Insert("file:///workspace/tasks/build.yaml", "yaml", 1, "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build\n")
+	c.Insert("file:///workspace/pipeline.yaml", "yaml", 1, `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: main
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+`)
+	pipeline, _ := c.GetParsed("file:///workspace/pipeline.yaml")
+
+	target := ResolveReference(pipeline, parser.Position{Line: 7, Character: 8}, c, Options{})
+	require.NotNil(t, target, "on the taskRef key")
+	assert.Equal(t, "file:///workspace/tasks/build.yaml", target.URI)
+	require.NotNil(t, target.Doc)
+	assert.Equal(t, "Task", target.Doc.Kind)
+	assert.Equal(t, "build", target.Ref.Name.Value)
+
+	assert.Nil(t, ResolveReference(pipeline, parser.Position{Line: 6, Character: 8}, c, Options{}), "not on a reference")
+}
@@ -10,6 +10,8 @@ import (
 
 // Options controls formatting behavior.
 type Options struct {
+	// IndentSize is the number of spaces per indentation level, 2 when not
+	// set.
 	IndentSize int
 }
 
@@ -20,10 +22,11 @@ func Format(content string, opts Options) (string, error) {
 		return "", nil
 	}
 
-	// YAML convention is 2-space indent. Always use 2 regardless of
-	// what the editor sends (tabSize can be 4 or 8 in many editors).
+	// YAML convention is 2-space indent.
 	indent := 2
-	_ = opts.IndentSize // acknowledged but overridden
+	if opts.IndentSize > 0 {
+		indent = opts.IndentSize
+	}
 
 	// Use Decoder to handle multiple YAML documents in one file.
 	dec := yaml.NewDecoder(strings.NewReader(content))
@@ -103,7 +103,7 @@ spec:
 	assert.Equal(t, 2, strings.Count(result, "---"), "should have 2 document separators for 3 documents")
 }
 
-func TestFormat_LargeTabSize_ClampedToTwo(t *testing.T) {
+func TestFormat_IndentSize(t *testing.T) {
 	input := `apiVersion: tekton.dev/v1
 kind: Task
 metadata:
@@ -113,9 +113,12 @@ spec:
     - name: build
       image: golang:1.25
 `
-	// Even when editor sends tabSize=4 or 8, YAML should stay at 2
 	result, err := Format(input, Options{IndentSize: 4})
 	require.NoError(t, err)
-	assert.Contains(t, result, "  name: test", "should use 2-space indent regardless of tabSize")
-	assert.NotContains(t, result, "    name:", "should not use 4-space indent")
+	assert.Contains(t, result, "\n    name: test", "should use the configured 4-space indent")
+
+	// Without an indent size, YAML stays at 2.
+	result, err = Format(input, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, input, result)
 }
@@ -1,50 +1,88 @@
 package hover
 
-// docs maps field keys to markdown documentation.
-var docs = map[string]string{
-	// Top-level
-	"apiVersion": "**apiVersion** — The API version for this resource.\n\nTekton resources use `tekton.dev/v1` or `tekton.dev/v1beta1`.",
-	"kind":       "**kind** — The type of Tekton resource.\n\nCommon kinds: `Pipeline`, `Task`, `PipelineRun`, `TaskRun`.",
-	"metadata":   "**metadata** — Standard Kubernetes metadata.\n\nIncludes `name`, `namespace`, `labels`, and `annotations`.",
+import (
+	"fmt"
+	"strings"
 
-	// Metadata
-	"name":        "**name** — The name of the resource (required).\n\nMust be unique within the namespace.",
-	"namespace":   "**namespace** — The Kubernetes namespace for this resource.",
-	"labels":      "**labels** — Key-value pairs for organizing resources.",
-	"annotations": "**annotations** — Key-value pairs for non-identifying metadata.",
+	"github.com/vdemeester/tekton-lsp-go/pkg/schema"
+)
 
-	// Pipeline spec
-	"tasks":       "**tasks** — The list of Tasks to execute in the Pipeline.\n\nEach task references a `Task` resource via `taskRef` or defines one inline via `taskSpec`.",
-	"finally":     "**finally** — Tasks that run after all other tasks complete.\n\nThese tasks run regardless of whether previous tasks succeeded or failed.",
-	"params":      "**params** — Parameters accepted by this resource.\n\nParameters can be referenced using `$(params.name)` syntax.",
-	"workspaces":  "**workspaces** — Workspace declarations for shared storage.\n\nWorkspaces provide a way to share data between tasks.",
-	"results":     "**results** — Results produced by this resource.\n\nResults can be referenced by subsequent tasks using `$(tasks.taskName.results.resultName)`.",
-	"description": "**description** — A human-readable description of this resource.",
+// FieldDocumentation returns the documentation shown when hovering the
+// field of a kind at path, or "" when the field is unknown. An empty key in
+// the path steps into the items of an array.
+//
+// The documentation is assembled from the schema, whose descriptions are
+// written by hand: the field's type, whether it is required, its description
+// and default, the API version that introduced it when later than its
+// resource's and a link to the upstream documentation.
+func FieldDocumentation(kind string, path ...string) string {
+	trail := schema.For(kind).Trail(path...)
+	if len(trail) != len(path)+1 {
+		return ""
+	}
+	f := trail[len(path)]
+	if f.Name == "" || len(path) == 0 {
+		return ""
+	}
 
-	// Pipeline task
-	"taskRef":  "**taskRef** — Reference to an existing Task resource.\n\nSpecify `name` (and optionally `kind` for ClusterTask).",
-	"taskSpec": "**taskSpec** — Inline Task specification.\n\nDefine a Task directly within the Pipeline instead of referencing an existing one.",
-	"runAfter": "**runAfter** — List of task names that must complete before this task runs.\n\nUsed to define execution order.",
-	"when":     "**when** — Conditional expressions that determine if this task runs.\n\nUse `input`, `operator`, and `values` fields.",
-	"matrix":   "**matrix** — Matrix parameters for fan-out execution.\n\nRuns the task multiple times with different parameter combinations.",
+	presence := "optional"
+	if f.Required {
+		presence = "required"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s** (%s, %s)\n\n%s field `%s`", f.Name, typeName(f), presence, kind, fieldPath(path))
+	if f.Description != "" {
+		fmt.Fprintf(&b, "\n\n%s", f.Description)
+	}
+	if f.Default != "" {
+		fmt.Fprintf(&b, "\n\nDefault: `%s`", f.Default)
+	}
+	if f.Deprecated != "" {
+		fmt.Fprintf(&b, "\n\n**Deprecated**: %s", f.Deprecated)
+	}
 
-	// Task spec
-	"steps":        "**steps** — The sequence of containers to run in this Task.\n\nEach step runs in order within a shared Pod.",
-	"volumes":      "**volumes** — Kubernetes volumes to make available to steps.",
-	"sidecars":     "**sidecars** — Sidecar containers that run alongside steps.\n\nUseful for services like databases needed during execution.",
-	"stepTemplate": "**stepTemplate** — Default values applied to all steps.\n\nSet common image, env vars, or resource limits.",
+	// The innermost field with an API version or a link is the most
+	// precise one. The version of the resource itself is left out: fields
+	// added to it later do not record theirs.
+	var since, docs string
+	for i, field := range trail {
+		if i > 0 && field.Since != "" {
+			since = field.Since
+		}
+		if field.Docs != "" {
+			docs = field.Docs
+		}
+	}
+	if since != "" {
+		fmt.Fprintf(&b, "\n\nSince `%s`", since)
+	}
+	if docs != "" {
+		fmt.Fprintf(&b, "\n\n[Tekton documentation](%s)", docs)
+	}
+	return b.String()
+}
 
-	// Step
-	"image":      "**image** — The container image to use for this step (required).\n\nExample: `golang:1.25`, `ubuntu:latest`.",
-	"script":     "**script** — A script to execute in the container.\n\nThe script is written to a file and executed. Supports shebangs.",
-	"command":    "**command** — The entrypoint for the container.\n\nOverrides the image's default entrypoint.",
-	"args":       "**args** — Arguments passed to the command.",
-	"env":        "**env** — Environment variables for the container.\n\nEach entry has `name` and `value` (or `valueFrom`).",
-	"workingDir": "**workingDir** — The working directory for the container.",
+// typeName describes the type of a field, with the type of the items of an
+// array.
+func typeName(f *schema.Field) string {
+	if f.Type == schema.Array && f.Items != nil {
+		return "array of " + f.Items.Type.String() + "s"
+	}
+	return f.Type.String()
 }
 
-// getDocumentation returns markdown documentation for a field key.
-func getDocumentation(key string) (string, bool) {
-	doc, ok := docs[key]
-	return doc, ok
+// fieldPath renders a schema path as in `spec.steps[].name`.
+func fieldPath(path []string) string {
+	var b strings.Builder
+	for _, key := range path {
+		if key == "" {
+			b.WriteString("[]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(key)
+	}
+	return b.String()
 }
@@ -1,8 +1,12 @@
 package hover
 
 import (
+	"path"
 	"strings"
 
+	"github.com/vdemeester/tekton-lsp-go/pkg/cache"
+	"github.com/vdemeester/tekton-lsp-go/pkg/definition"
+	"github.com/vdemeester/tekton-lsp-go/pkg/model"
 	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
 )
 
@@ -12,26 +16,53 @@ type HoverResult struct {
 	Range   *parser.Range
 }
 
+// Options configures hover beyond the document itself.
+type Options struct {
+	// Cache, when set, resolves the taskRef, pipelineRef and step ref
+	// references hovered to the resources they name, as go-to-definition
+	// does with Definition.
+	Cache      *cache.Cache
+	Definition definition.Options
+}
+
 // Hover returns documentation for the node at the given position.
 func Hover(doc *parser.Document, pos parser.Position) *HoverResult {
-	if !strings.Contains(doc.APIVersion, "tekton.dev") {
+	return HoverWithOptions(doc, pos, Options{})
+}
+
+// HoverWithOptions is Hover with the workspace described by opts.
+func HoverWithOptions(doc *parser.Document, pos parser.Position, opts Options) *HoverResult {
+	if !model.IsTekton(doc) {
 		return nil
 	}
 
-	// Find the node at the position.
-	node := doc.FindNodeAtPosition(pos)
+	// Find the node at the position, and the path leading to it.
+	path, node := nodePath(doc.Root, pos)
 	if node == nil {
 		return nil
 	}
 
-	// Try looking up documentation by the node's key.
-	if node.Key != "" {
-		if content, ok := getDocumentation(node.Key); ok {
+	// A reference, or its name, shows the interface of the resource.
+	if opts.Cache != nil {
+		if content := referenceDocumentation(doc, pos, node, opts); content != "" {
 			r := node.Range
 			return &HoverResult{Content: content, Range: &r}
 		}
 	}
 
+	// A $(...) reference shows the declaration of the variable.
+	if content, r, ok := variableDocumentation(doc, pos, node, opts); ok {
+		return &HoverResult{Content: content, Range: &r}
+	}
+
+	// Document the field the node is the value of.
+	if node.Key != "" {
+		if content := FieldDocumentation(doc.Kind, path...); content != "" {
+			r := node.Range
+			return &HoverResult{Content: withComments(content, node), Range: &r}
+		}
+	}
+
 	// For scalar values, try the parent key context.
 	if node.IsScalar() {
 		// The value itself might be interesting (e.g. "Pipeline" for kind).
@@ -48,3 +79,84 @@ func Hover(doc *parser.Document, pos parser.Position) *HoverResult {
 
 	return nil
 }
+
+// referenceDocumentation describes the resource the reference at pos
+// resolves to, when node is the reference or its name.
+func referenceDocumentation(doc *parser.Document, pos parser.Position, node *parser.Node, opts Options) string {
+	target := definition.ResolveReference(doc, pos, opts.Cache, opts.Definition)
+	if target == nil || node != target.Ref.Node && node != target.Ref.Name.Node {
+		return ""
+	}
+	if target.Doc == nil {
+		return "Defined in [" + path.Base(target.URI) + "](" + target.URI + ")"
+	}
+	return ResourceDocumentation(target.Doc.Root.Get("metadata").Get("name").AsScalar(), target.Doc.Kind, target.URI, target.Doc)
+}
+
+// withComments appends the comments written above a node to its hover content.
+// Suppression directives (# tekton-lsp: ...) are left out.
+func withComments(content string, node *parser.Node) string {
+	var lines []string
+	for _, c := range node.LeadingComments {
+		if strings.HasPrefix(c.Text, "tekton-lsp:") {
+			continue
+		}
+		lines = append(lines, c.Text)
+	}
+	if len(lines) == 0 {
+		return content
+	}
+	return content + "\n\n---\n\n" + strings.Join(lines, "\n")
+}
+
+// nodePath returns the most specific node at pos, like
+// parser.Document.FindNodeAtPosition, and the schema path leading to it:
+// the keys of the mappings it is in, and "" for sequence items.
+func nodePath(root *parser.Node, pos parser.Position) ([]string, *parser.Node) {
+	if root == nil || !within(pos, root.Range) {
+		return nil, nil
+	}
+	var path []string
+	node := root
+	for {
+		var next *parser.Node
+		switch node.Kind {
+		case parser.NodeKindMapping:
+			for _, merge := range node.Merges {
+				if within(pos, merge.Range) {
+					return path, merge
+				}
+			}
+			for _, child := range node.MappingChildren {
+				if within(pos, child.Range) {
+					next = child
+					path = append(path, child.Key)
+					break
+				}
+			}
+		case parser.NodeKindSequence:
+			for _, child := range node.SequenceChildren {
+				if within(pos, child.Range) {
+					next = child
+					path = append(path, "")
+					break
+				}
+			}
+		}
+		if next == nil {
+			return path, node
+		}
+		node = next
+	}
+}
+
+// within reports whether pos is in r, ends included.
+func within(pos parser.Position, r parser.Range) bool {
+	if pos.Line < r.Start.Line || pos.Line > r.End.Line {
+		return false
+	}
+	if pos.Line == r.Start.Line && pos.Character < r.Start.Character {
+		return false
+	}
+	return pos.Line != r.End.Line || pos.Character <= r.End.Character
+}
@@ -108,3 +108,78 @@ metadata:
 	result := Hover(doc, parser.Position{Line: 1, Character: 3})
 	assert.Nil(t, result, "should return nil for non-Tekton resources")
 }
+
+func TestHover_ShowsLeadingComments(t *testing.T) {
+	doc := parse(t, `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: test
+spec:
+  # Runs the build.
+  # tekton-lsp: ignore=unknown-field
+  steps:
+    - name: build
+      image: golang:1.25
+`)
+	result := Hover(doc, parser.Position{Line: 7, Character: 4})
+	require.NotNil(t, result)
+	assert.Contains(t, result.Content, "steps")
+	assert.Contains(t, result.Content, "Runs the build.")
+	assert.NotContains(t, result.Content, "tekton-lsp:")
+}
+
+func TestFieldDocumentation(t *testing.T) {
+	steps := FieldDocumentation("Task", "spec", "steps")
+	assert.Contains(t, steps, "**steps** (array of objects, required)")
+	assert.Contains(t, steps, "Task field `spec.steps`")
+	assert.Contains(t, steps, "The containers run in order")
+	assert.NotContains(t, steps, "Since", "the resource's version is not the field's")
+	assert.Contains(t, steps, "[Tekton documentation](https://tekton.dev/docs/pipelines/tasks/)")
+
+	onError := FieldDocumentation("Task", "spec", "steps", "", "onError")
+	assert.Contains(t, onError, "(string, optional)")
+	assert.Contains(t, onError, "`spec.steps[].onError`")
+	assert.Contains(t, onError, "Default: `stopAndFail`")
+
+	assert.Contains(t, FieldDocumentation("Task", "spec", "steps", "", "computeResources"), "Since `tekton.dev/v1`", "the field's own version")
+	assert.Contains(t, FieldDocumentation("PipelineRun", "spec", "taskRunTemplate", "serviceAccountName"), "Since `tekton.dev/v1`", "its parent's version")
+	assert.NotContains(t, FieldDocumentation("Pipeline", "spec", "displayName"), "Since")
+	assert.Contains(t, FieldDocumentation("Pipeline", "spec", "tasks", "", "when"), "#guard-task-execution-using-when-expressions", "the field's own link")
+	assert.Contains(t, FieldDocumentation("PipelineRun", "spec", "timeout"), "**Deprecated**")
+	assert.Empty(t, FieldDocumentation("Task", "spec", "unknown"))
+	assert.Empty(t, FieldDocumentation("Task", "spec", "steps", ""), "items have no key")
+	assert.Empty(t, FieldDocumentation("ConfigMap", "data"))
+}
+
+func TestHover_ByPath(t *testing.T) {
+	doc := parse(t, `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: test
+spec:
+  params:
+    - name: revision
+  steps:
+    - name: build
+      image: golang:1.25
+      onError: continue
+      workingDir: /src
+      unknown: x
+`)
+	tests := []struct {
+		line     uint32
+		contains string
+	}{
+		{3, "The name of the resource"},
+		{6, "The name of the param."},
+		{8, "The name of the step"},
+		{10, "Default: `stopAndFail`"},
+		{11, "`spec.steps[].workingDir`"},
+	}
+	for _, tt := range tests {
+		result := Hover(doc, parser.Position{Line: tt.line, Character: 8})
+		require.NotNil(t, result, "line %d", tt.line)
+		assert.Contains(t, result.Content, tt.contains, "line %d", tt.line)
+	}
+	assert.Nil(t, Hover(doc, parser.Position{Line: 12, Character: 8}), "unknown fields are not documented")
+}
@@ -0,0 +1,92 @@
+package hover
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/model"
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+	"github.com/vdemeester/tekton-lsp-go/pkg/variables"
+)
+
+// ResourceDocumentation describes the interface of a resource others refer
+// to: its description, the params it takes, the workspaces it needs, the
+// results it emits and the file defining it. doc may be nil when the
+// resource's document is not held.
+func ResourceDocumentation(name, kind, uri string, doc *parser.Document) string {
+	var description string
+	var params []*model.ParamSpec
+	var workspaces []*model.WorkspaceDeclaration
+	var results []result
+	switch obj := model.FromDocument(doc).(type) {
+	case *model.Task:
+		description, params, workspaces = obj.Description.Value, obj.Params, obj.Workspaces
+		for _, r := range obj.Results {
+			results = append(results, result{r.Name.Value, r.Type.Value, r.Description.Value})
+		}
+	case *model.Pipeline:
+		description, params, workspaces = obj.Description.Value, obj.Params, obj.Workspaces
+		for _, r := range obj.Results {
+			results = append(results, result{r.Name.Value, r.Type.Value, r.Description.Value})
+		}
+	case *model.StepAction:
+		description, params = obj.Description.Value, obj.Params
+		for _, r := range obj.Results {
+			results = append(results, result{r.Name.Value, r.Type.Value, r.Description.Value})
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s** (%s)\n", name, kind)
+	if description != "" {
+		fmt.Fprintf(&b, "\n%s\n", strings.TrimSpace(description))
+	}
+	if len(params) > 0 {
+		b.WriteString("\n**Params**\n")
+		for _, p := range params {
+			fmt.Fprintf(&b, "- `%s` (%s", p.Name.Value, p.ParamType())
+			if p.Default != nil {
+				fmt.Fprintf(&b, ", default `%s`", variables.Value(p.Default))
+			}
+			b.WriteString(")")
+			describe(&b, p.Description.Value)
+		}
+	}
+	if len(workspaces) > 0 {
+		b.WriteString("\n**Workspaces**\n")
+		for _, w := range workspaces {
+			fmt.Fprintf(&b, "- `%s`", w.Name.Value)
+			if w.Optional.Value == "true" {
+				b.WriteString(" (optional)")
+			}
+			describe(&b, w.Description.Value)
+		}
+	}
+	if len(results) > 0 {
+		b.WriteString("\n**Results**\n")
+		for _, r := range results {
+			typ := r.typ
+			if typ == "" {
+				typ = "string"
+			}
+			fmt.Fprintf(&b, "- `%s` (%s)", r.name, typ)
+			describe(&b, r.description)
+		}
+	}
+	fmt.Fprintf(&b, "\nDefined in [%s](%s)", path.Base(uri), uri)
+	return b.String()
+}
+
+// result is a result of a Task, a Pipeline or a StepAction.
+type result struct {
+	name, typ, description string
+}
+
+// describe ends a list item with the description of its entry, if any.
+func describe(b *strings.Builder, description string) {
+	if d := strings.TrimSpace(description); d != "" {
+		fmt.Fprintf(b, ": %s", d)
+	}
+	b.WriteString("\n")
+}
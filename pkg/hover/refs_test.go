@@ -0,0 +1,138 @@
+package hover
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/cache"
+	"github.com/vdemeester/tekton-lsp-go/pkg/config"
+	"github.com/vdemeester/tekton-lsp-go/pkg/definition"
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+func referenceCache(t *testing.T) *cache.Cache {
+	t.Helper()
+	c := cache.New()
+	c.Insert("file:///ws/tasks/build.yaml", "yaml", 1, `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  description: Builds the sources.
+  params:
+    - name: revision
+      description: Revision to build
+      default: main
+    - name: flags
+      type: array
+  workspaces:
+    - name: source
+      description: The sources.
+    - name: cache
+      optional: true
+  results:
+    - name: digest
+      description: The image digest.
+    - name: tags
+      type: array
+    - name: image
+      type: object
+      properties:
+        url: {type: string}
+  steps:
+    - name: build
+      image: golang
+`)
+	c.Insert("file:///ws/pipeline.yaml", "yaml", 1, `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: ci
+spec:
+  description: Builds and tests.
+  params:
+    - name: revision
+  results:
+    - name: image
+      value: $(tasks.build.results.digest)
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+      params:
+        - name: revision
+          value: $(params.revision)
+    - name: clone
+      taskRef:
+        resolver: hub
+        params:
+          - name: name
+            value: git-clone
+`)
+	c.Insert("file:///ws/run.yaml", "yaml", 1, `apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  generateName: ci-
+spec:
+  pipelineRef:
+    name: ci
+`)
+	return c
+}
+
+func TestHover_TaskRef(t *testing.T) {
+	c := referenceCache(t)
+	pipeline, _ := c.GetParsed("file:///ws/pipeline.yaml")
+	opts := Options{Cache: c}
+
+	for _, pos := range []parser.Position{{Line: 13, Character: 8}, {Line: 14, Character: 16}} {
+		result := HoverWithOptions(pipeline, pos, opts)
+		require.NotNil(t, result, "%v", pos)
+		assert.Contains(t, result.Content, "**build** (Task)")
+		assert.Contains(t, result.Content, "Builds the sources.")
+		assert.Contains(t, result.Content, "- `revision` (string, default `main`): Revision to build")
+		assert.Contains(t, result.Content, "- `flags` (array)")
+		assert.Contains(t, result.Content, "**Workspaces**\n- `source`: The sources.\n- `cache` (optional)")
+		assert.Contains(t, result.Content, "**Results**\n- `digest` (string): The image digest.")
+		assert.Contains(t, result.Content, "Defined in [build.yaml](file:///ws/tasks/build.yaml)")
+	}
+
+	result := HoverWithOptions(pipeline, parser.Position{Line: 16, Character: 12}, opts)
+	require.NotNil(t, result)
+	assert.NotContains(t, result.Content, "Builds the sources.", "params of the task document the field")
+
+	result = Hover(pipeline, parser.Position{Line: 14, Character: 16})
+	require.NotNil(t, result)
+	assert.NotContains(t, result.Content, "(Task)", "references are only resolved with a cache")
+}
+
+func TestHover_PipelineRef(t *testing.T) {
+	c := referenceCache(t)
+	run, _ := c.GetParsed("file:///ws/run.yaml")
+
+	result := HoverWithOptions(run, parser.Position{Line: 6, Character: 10}, Options{Cache: c})
+	require.NotNil(t, result)
+	assert.Contains(t, result.Content, "**ci** (Pipeline)")
+	assert.Contains(t, result.Content, "Builds and tests.")
+	assert.Contains(t, result.Content, "- `revision` (string)")
+	assert.Contains(t, result.Content, "- `image` (string)")
+	assert.Contains(t, result.Content, "[pipeline.yaml](file:///ws/pipeline.yaml)")
+}
+
+func TestHover_ResolverRef(t *testing.T) {
+	c := referenceCache(t)
+	pipeline, _ := c.GetParsed("file:///ws/pipeline.yaml")
+	pos := parser.Position{Line: 19, Character: 8}
+
+	result := HoverWithOptions(pipeline, pos, Options{Cache: c})
+	require.NotNil(t, result)
+	assert.NotContains(t, result.Content, "Defined in", "unmapped resolver references are not resolved")
+
+	opts := Options{Cache: c, Definition: definition.Options{Resolvers: []config.ResolverMapping{
+		{Resolver: "hub", Params: map[string]string{"name": "git-clone"}, Path: "/ws/catalog/git-clone.yaml"},
+	}}}
+	result = HoverWithOptions(pipeline, pos, opts)
+	require.NotNil(t, result)
+	assert.Equal(t, "Defined in [git-clone.yaml](file:///ws/catalog/git-clone.yaml)", result.Content, "the file is not indexed")
+}
@@ -0,0 +1,72 @@
+package hover
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+	"github.com/vdemeester/tekton-lsp-go/pkg/variables"
+)
+
+// expressionRe matches $(...) variable references.
+var expressionRe = regexp.MustCompile(`\$\(([^()\s]+)\)`)
+
+// bracketRe matches the bracket form of a key, e.g. params["revision"].
+var bracketRe = regexp.MustCompile(`\[["']([^"']+)["']\]`)
+
+// indexRe matches the index of an array param or result, e.g. [*] or [0].
+var indexRe = regexp.MustCompile(`\[(\*|\d+)\]$`)
+
+// variableDocumentation describes the $(...) variable at pos in a scalar
+// node, as declared where the node is: the param with its origin, the task
+// producing a result, or what a context variable expands to. It also
+// returns the range of the reference.
+func variableDocumentation(doc *parser.Document, pos parser.Position, node *parser.Node, opts Options) (string, parser.Range, bool) {
+	if !node.IsScalar() {
+		return "", parser.Range{}, false
+	}
+	value := node.AsScalar()
+	for _, m := range expressionRe.FindAllStringSubmatchIndex(value, -1) {
+		r := node.ValueRange(m[0], m[1])
+		if !within(pos, r) {
+			continue
+		}
+		name := bracketRe.ReplaceAllString(value[m[2]:m[3]], ".$1")
+		if v, ok := lookupVariable(doc, pos, name, opts); ok {
+			return v.Documentation, r, true
+		}
+		return "", parser.Range{}, false
+	}
+	return "", parser.Range{}, false
+}
+
+// lookupVariable finds the variable name refers to among those valid at
+// pos. Indexed arrays are found by their [*] form, and keys an object does
+// not declare by the object.
+func lookupVariable(doc *parser.Document, pos parser.Position, name string, opts Options) (variables.Variable, bool) {
+	in := func(n *parser.Node) bool { return n != nil && within(pos, n.Range) }
+	vopts := variables.Options{Scope: opts.Definition.Scope}
+	if opts.Cache != nil {
+		vopts.Index = opts.Cache.Index()
+	}
+	vars := make(map[string]variables.Variable)
+	for _, v := range variables.In(doc, in, vopts) {
+		vars[v.Name] = v
+	}
+
+	candidates := []string{name}
+	if base := indexRe.ReplaceAllString(name, ""); base != name {
+		candidates = append(candidates, base)
+	}
+	if i := strings.LastIndex(name, "."); i > 0 {
+		candidates = append(candidates, name[:i])
+	}
+	for _, c := range candidates {
+		for _, n := range []string{c, c + "[*]"} {
+			if v, ok := vars[n]; ok {
+				return v, true
+			}
+		}
+	}
+	return variables.Variable{}, false
+}
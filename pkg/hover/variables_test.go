@@ -0,0 +1,146 @@
+package hover
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+// variablesPipeline is the Pipeline of referenceCache, with inline specs.
+const variablesPipeline = `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: release
+spec:
+  params:
+    - name: revision
+      description: The revision to release.
+      default: main
+    - name: platforms
+      type: array
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+      params:
+        - name: revision
+          value: $(params.revision)
+        - name: flags
+          value: ["$(params.platforms[0])"]
+    - name: publish
+      params:
+        - name: digest
+          value: $(tasks.build.results.digest)
+        - name: tags
+          value: ["$(tasks.build.results.tags[1])", "$(tasks.build.results.image.url)", "$(tasks.build.results.image.tag)"]
+      taskSpec:
+        params:
+          - name: digest
+            description: The digest to publish.
+        steps:
+          - name: push
+            image: alpine
+            script: |
+              echo $(params.digest) $(params.revision)
+              echo $(context.pipelineRun.name) $(params.unknown)
+`
+
+// hoverAt hovers the first occurrence of text in the Pipeline, offset
+// characters in.
+func hoverAt(t *testing.T, text string, offset int) *HoverResult {
+	t.Helper()
+	c := referenceCache(t)
+	c.Insert("file:///ws/release.yaml", "yaml", 1, variablesPipeline)
+	doc, _ := c.GetParsed("file:///ws/release.yaml")
+	for i, line := range strings.Split(variablesPipeline, "\n") {
+		if col := strings.Index(line, text); col >= 0 {
+			return HoverWithOptions(doc, parser.Position{Line: uint32(i), Character: uint32(col + offset)}, Options{Cache: c})
+		}
+	}
+	t.Fatalf("%q not found", text)
+	return nil
+}
+
+func TestHover_Variable(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		contains []string
+	}{
+		{
+			name:     "pipeline param",
+			text:     "value: $(params.revision)",
+			contains: []string{"**revision** (string param)", "The revision to release.", "Default: `main`", "Declared by the Pipeline."},
+		},
+		{
+			name:     "indexed array param",
+			text:     "$(params.platforms[0])",
+			contains: []string{"**platforms** (array param)"},
+		},
+		{
+			name:     "task result",
+			text:     "$(tasks.build.results.digest)",
+			contains: []string{"The image digest.", "Produced by pipeline task `build` (Task `build`)."},
+		},
+		{
+			name:     "indexed array result",
+			text:     "$(tasks.build.results.tags[1])",
+			contains: []string{"`$(tasks.build.results.tags[*])` (array)"},
+		},
+		{
+			name:     "object result key",
+			text:     "$(tasks.build.results.image.url)",
+			contains: []string{"`$(tasks.build.results.image.url)` (object)"},
+		},
+		{
+			name:     "undeclared object result key",
+			text:     "$(tasks.build.results.image.tag)",
+			contains: []string{"`$(tasks.build.results.image[*])` (object)"},
+		},
+		{
+			name:     "task param",
+			text:     "$(params.digest)",
+			contains: []string{"**digest** (string param)", "The digest to publish.", "Declared by the Task."},
+		},
+		{
+			name:     "propagated param",
+			text:     "$(params.digest) $(params.revision)",
+			contains: []string{"**revision**", "Propagated from the Pipeline."},
+		},
+		{
+			name:     "context",
+			text:     "$(context.pipelineRun.name)",
+			contains: []string{"The name of the PipelineRun running the Pipeline."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// On the last reference of the text.
+			result := hoverAt(t, tt.text, len(tt.text)-3)
+			require.NotNil(t, result)
+			for _, s := range tt.contains {
+				assert.Contains(t, result.Content, s)
+			}
+		})
+	}
+}
+
+func TestHover_VariableRange(t *testing.T) {
+	result := hoverAt(t, "$(params.digest)", 4)
+	require.NotNil(t, result)
+	require.NotNil(t, result.Range)
+	assert.Equal(t, parser.Position{Line: 34, Character: 19}, result.Range.Start)
+	assert.Equal(t, parser.Position{Line: 34, Character: 35}, result.Range.End)
+
+	result = hoverAt(t, "$(params.unknown)", 4)
+	require.NotNil(t, result)
+	assert.Contains(t, result.Content, "**script**", "unknown variables leave the field documentation")
+
+	result = hoverAt(t, "echo $(context", 2)
+	require.NotNil(t, result)
+	assert.Contains(t, result.Content, "**script**", "outside of a reference")
+}
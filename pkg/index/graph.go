@@ -0,0 +1,46 @@
+package index
+
+import "sort"
+
+// Dependents returns the files that depend on the resources defined in uris:
+// files referencing them or defining them too, then the dependents of those
+// files, and so on. The result is sorted and never contains uris themselves.
+func (idx *Index) Dependents(uris ...string) []string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	seen := make(map[string]bool, len(uris))
+	for _, uri := range uris {
+		seen[uri] = true
+	}
+	queue := append([]string(nil), uris...)
+	var dependents []string
+	for len(queue) > 0 {
+		uri := queue[0]
+		queue = queue[1:]
+		f, ok := idx.files[uri]
+		if !ok {
+			continue
+		}
+		add := func(dependent string) {
+			if !seen[dependent] {
+				seen[dependent] = true
+				dependents = append(dependents, dependent)
+				queue = append(queue, dependent)
+			}
+		}
+		for _, r := range f.resources {
+			for _, ref := range idx.refsTo[r.nameKey()] {
+				if namespaceMatches(r.Key, ref) {
+					add(ref.URI)
+				}
+			}
+			// Duplicate definitions are reported on each copy.
+			for _, other := range idx.resources[r.Key] {
+				add(other.URI)
+			}
+		}
+	}
+	sort.Strings(dependents)
+	return dependents
+}
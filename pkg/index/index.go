@@ -0,0 +1,331 @@
+// Package index maintains a workspace-wide index of Kubernetes resources and
+// of the references between them, so lookups by name do not need to scan
+// every parsed document.
+package index
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+// Key identifies a resource: API group, kind, namespace and name.
+// Namespace is empty for resources that do not set one.
+type Key struct {
+	Group     string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// nameKey is a Key without the namespace, used to find resources and
+// references regardless of the namespace they live in.
+type nameKey struct {
+	Group string
+	Kind  string
+	Name  string
+}
+
+func (k Key) nameKey() nameKey {
+	return nameKey{Group: k.Group, Kind: k.Kind, Name: k.Name}
+}
+
+// Resource is an indexed resource definition.
+type Resource struct {
+	Key
+	// URI is the file the resource is defined in.
+	URI string
+	// Doc is the parsed document defining the resource.
+	Doc *parser.Document
+}
+
+// Reference is a reference from one resource to another, e.g. a taskRef.
+type Reference struct {
+	// From is the referencing resource. Its name is empty for documents
+	// without a metadata.name, such as runs using generateName.
+	From Key
+	// To is the referenced resource. Its namespace is the referencing
+	// resource's namespace, as Tekton resolves references locally.
+	To Key
+	// URI is the file containing the reference.
+	URI string
+	// Range is the range of the referenced name.
+	Range parser.Range
+}
+
+// Index is a thread-safe index of resources and references, fed one file
+// at a time.
+type Index struct {
+	mu        sync.RWMutex
+	resources map[Key][]*Resource
+	byName    map[nameKey][]*Resource
+	refsTo    map[nameKey][]Reference
+	files     map[string]*file
+}
+
+// file records what a URI contributed, so it can be removed on update.
+type file struct {
+	resources []*Resource
+	refs      []Reference
+}
+
+// New creates an empty index.
+func New() *Index {
+	return &Index{
+		resources: make(map[Key][]*Resource),
+		byName:    make(map[nameKey][]*Resource),
+		refsTo:    make(map[nameKey][]Reference),
+		files:     make(map[string]*file),
+	}
+}
+
+// Update replaces everything indexed for uri with the given documents.
+func (idx *Index) Update(uri string, docs []*parser.Document) {
+	f := &file{}
+	for _, doc := range docs {
+		key, ok := keyOf(doc)
+		if !ok {
+			continue
+		}
+		// Unnamed documents cannot be referenced, but their references
+		// still count.
+		if key.Name != "" {
+			f.resources = append(f.resources, &Resource{Key: key, URI: uri, Doc: doc})
+		}
+		f.refs = append(f.refs, referencesOf(uri, key, doc)...)
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(uri)
+	if len(f.resources) == 0 && len(f.refs) == 0 {
+		return
+	}
+	idx.files[uri] = f
+	for _, r := range f.resources {
+		idx.resources[r.Key] = append(idx.resources[r.Key], r)
+		idx.byName[r.nameKey()] = append(idx.byName[r.nameKey()], r)
+	}
+	for _, ref := range f.refs {
+		idx.refsTo[ref.To.nameKey()] = append(idx.refsTo[ref.To.nameKey()], ref)
+	}
+}
+
+// Remove drops everything indexed for uri.
+func (idx *Index) Remove(uri string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(uri)
+}
+
+func (idx *Index) remove(uri string) {
+	f, ok := idx.files[uri]
+	if !ok {
+		return
+	}
+	delete(idx.files, uri)
+	for _, r := range f.resources {
+		idx.resources[r.Key] = withoutURI(idx.resources[r.Key], uri)
+		if len(idx.resources[r.Key]) == 0 {
+			delete(idx.resources, r.Key)
+		}
+		nk := r.nameKey()
+		idx.byName[nk] = withoutURI(idx.byName[nk], uri)
+		if len(idx.byName[nk]) == 0 {
+			delete(idx.byName, nk)
+		}
+	}
+	for _, ref := range f.refs {
+		nk := ref.To.nameKey()
+		refs := idx.refsTo[nk][:0]
+		for _, r := range idx.refsTo[nk] {
+			if r.URI != uri {
+				refs = append(refs, r)
+			}
+		}
+		if len(refs) == 0 {
+			delete(idx.refsTo, nk)
+		} else {
+			idx.refsTo[nk] = refs
+		}
+	}
+}
+
+func withoutURI(resources []*Resource, uri string) []*Resource {
+	result := resources[:0]
+	for _, r := range resources {
+		if r.URI != uri {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+// Lookup returns the resources defined with exactly the given key. More than
+// one result means the resource is defined in several files.
+func (idx *Index) Lookup(key Key) []*Resource {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return append([]*Resource(nil), idx.resources[key]...)
+}
+
+// Resolve finds the resource a reference points to. An exact namespace match
+// wins; otherwise a resource with the same group, kind and name in any
+// namespace is returned, since workspace files often omit the namespace.
+func (idx *Index) Resolve(key Key) *Resource {
+	return idx.ResolveFrom(key, "", nil)
+}
+
+// ResolveIn is Resolve restricted to resources whose file is in scope. A nil
+// scope accepts every file.
+func (idx *Index) ResolveIn(key Key, scope func(uri string) bool) *Resource {
+	return idx.ResolveFrom(key, "", scope)
+}
+
+// ResolveFrom is ResolveIn for a reference made in the file from. When the
+// resource is defined several times, the definition in from wins, then the
+// first by URI, so the result does not depend on the order files were
+// indexed in.
+func (idx *Index) ResolveFrom(key Key, from string, scope func(uri string) bool) *Resource {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	inScope := func(rs []*Resource) []*Resource {
+		if scope == nil {
+			return rs
+		}
+		var result []*Resource
+		for _, r := range rs {
+			if scope(r.URI) {
+				result = append(result, r)
+			}
+		}
+		return result
+	}
+	if rs := inScope(idx.resources[key]); len(rs) > 0 {
+		return preferred(rs, from)
+	}
+	candidates := inScope(idx.byName[key.nameKey()])
+	if len(candidates) == 0 {
+		return nil
+	}
+	return preferred(candidates, from)
+}
+
+// preferred picks the definition a reference from the file from resolves to
+// among candidates: one in from, then one without a namespace, then the first
+// by URI.
+func preferred(candidates []*Resource, from string) *Resource {
+	best := candidates[0]
+	for _, r := range candidates[1:] {
+		switch {
+		case (r.URI == from) != (best.URI == from):
+			if r.URI == from {
+				best = r
+			}
+		case (r.Namespace == "") != (best.Namespace == ""):
+			if r.Namespace == "" {
+				best = r
+			}
+		case r.URI < best.URI:
+			best = r
+		}
+	}
+	return best
+}
+
+// ReferencesTo returns the references to the resource with the given key.
+// References without a namespace, or to a resource without one, match any
+// namespace.
+func (idx *Index) ReferencesTo(key Key) []Reference {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	var refs []Reference
+	for _, ref := range idx.refsTo[key.nameKey()] {
+		if namespaceMatches(key, ref) {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// namespaceMatches reports whether ref may point to the resource with the
+// given key, which ReferencesTo already matched by name.
+func namespaceMatches(key Key, ref Reference) bool {
+	return key.Namespace == "" || ref.To.Namespace == "" || key.Namespace == ref.To.Namespace
+}
+
+// ReferencesFrom returns the references made by the resources in uri.
+func (idx *Index) ReferencesFrom(uri string) []Reference {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	f, ok := idx.files[uri]
+	if !ok {
+		return nil
+	}
+	return append([]Reference(nil), f.refs...)
+}
+
+// ResourcesIn returns the resources defined in uri.
+func (idx *Index) ResourcesIn(uri string) []*Resource {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	f, ok := idx.files[uri]
+	if !ok {
+		return nil
+	}
+	return append([]*Resource(nil), f.resources...)
+}
+
+// All returns every indexed resource of the given group and kind, sorted by
+// name. An empty kind matches every kind of the group.
+func (idx *Index) All(group, kind string) []*Resource {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	var result []*Resource
+	for key, rs := range idx.resources {
+		if key.Group == group && (kind == "" || key.Kind == kind) {
+			result = append(result, rs...)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].URI < result[j].URI
+	})
+	return result
+}
+
+// KeyOf returns the index key of a document. Documents without a kind or a
+// metadata.name cannot be referenced and are not indexed.
+func KeyOf(doc *parser.Document) (Key, bool) {
+	key, ok := keyOf(doc)
+	return key, ok && key.Name != ""
+}
+
+// keyOf is KeyOf accepting documents without a metadata.name, whose key has
+// an empty name.
+func keyOf(doc *parser.Document) (Key, bool) {
+	if doc == nil || doc.Root == nil || doc.Kind == "" {
+		return Key{}, false
+	}
+	metadata := doc.Root.Get("metadata")
+	return Key{
+		Group:     Group(doc.APIVersion),
+		Kind:      doc.Kind,
+		Namespace: metadata.Get("namespace").AsScalar(),
+		Name:      metadata.Get("name").AsScalar(),
+	}, true
+}
+
+// Group returns the API group of an apiVersion ("tekton.dev/v1" →
+// "tekton.dev", "v1" → "").
+func Group(apiVersion string) string {
+	group, _, found := strings.Cut(apiVersion, "/")
+	if !found {
+		return ""
+	}
+	return group
+}
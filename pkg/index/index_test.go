@@ -0,0 +1,260 @@
+package index
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/model"
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+func parseAll(t *testing.T, uri, yaml string) []*parser.Document {
+	t.Helper()
+	docs, err := parser.ParseAllYAML(uri, yaml)
+	require.NoError(t, err)
+	return docs
+}
+
+const buildTask = `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  steps:
+    - name: build
+      ref:
+        name: go-build
+`
+
+const pipeline = `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: main
+  namespace: ci
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+    - name: remote
+      taskRef:
+        resolver: git
+        params:
+          - name: url
+            value: https://example.com/repo
+  finally:
+    - name: cleanup
+      taskRef:
+        name: cleanup
+        kind: ClusterTask
+---
+apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  generateName: main-run-
+spec:
+  pipelineRef:
+    name: main
+`
+
+func TestIndex_LookupAndResolve(t *testing.T) {
+	idx := New()
+	idx.Update("file:///task.yaml", parseAll(t, "file:///task.yaml", buildTask))
+
+	key := Key{Group: "tekton.dev", Kind: "Task", Name: "build"}
+	rs := idx.Lookup(key)
+	require.Len(t, rs, 1)
+	assert.Equal(t, "file:///task.yaml", rs[0].URI)
+
+	// A namespaced reference still resolves to the unnamespaced definition.
+	r := idx.Resolve(Key{Group: "tekton.dev", Kind: "Task", Namespace: "ci", Name: "build"})
+	require.NotNil(t, r)
+	assert.Equal(t, "file:///task.yaml", r.URI)
+
+	assert.Nil(t, idx.Resolve(Key{Group: "tekton.dev", Kind: "Pipeline", Name: "build"}))
+}
+
+func TestIndex_ResolveIn(t *testing.T) {
+	idx := New()
+	idx.Update("file:///a/task.yaml", parseAll(t, "file:///a/task.yaml", buildTask))
+	idx.Update("file:///b/task.yaml", parseAll(t, "file:///b/task.yaml", buildTask))
+	key := Key{Group: "tekton.dev", Kind: "Task", Name: "build"}
+	under := func(prefix string) func(string) bool {
+		return func(uri string) bool { return strings.HasPrefix(uri, prefix) }
+	}
+
+	assert.Equal(t, "file:///a/task.yaml", idx.ResolveIn(key, nil).URI)
+	assert.Equal(t, "file:///b/task.yaml", idx.ResolveIn(key, under("file:///b/")).URI)
+	assert.Equal(t, "file:///b/task.yaml", idx.ResolveIn(Key{Group: "tekton.dev", Kind: "Task", Namespace: "ci", Name: "build"}, under("file:///b/")).URI)
+	assert.Nil(t, idx.ResolveIn(key, under("file:///c/")))
+}
+
+func TestIndex_ResolveDuplicates(t *testing.T) {
+	task := func(name string) string {
+		return "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: " + name + "\n"
+	}
+	key := Key{Group: "tekton.dev", Kind: "Task", Name: "build"}
+	for _, order := range [][]string{
+		{"file:///b.yaml", "file:///a.yaml", "file:///c.yaml"},
+		{"file:///c.yaml", "file:///a.yaml", "file:///b.yaml"},
+	} {
+		idx := New()
+		for _, uri := range order {
+			idx.Update(uri, parseAll(t, uri, task("build")))
+		}
+		assert.Equal(t, "file:///a.yaml", idx.Resolve(key).URI, "the first by URI, whatever the indexing order")
+		assert.Equal(t, "file:///c.yaml", idx.ResolveFrom(key, "file:///c.yaml", nil).URI, "the definition in the referring file")
+		assert.Equal(t, "file:///b.yaml", idx.ResolveFrom(key, "file:///c.yaml", func(uri string) bool { return uri != "file:///a.yaml" && uri != "file:///c.yaml" }).URI)
+	}
+}
+
+func TestIndex_TaskSpec(t *testing.T) {
+	idx := New()
+	idx.Update("file:///task.yaml", parseAll(t, "file:///task.yaml", buildTask))
+	doc := parseAll(t, "file:///pipeline.yaml", pipeline)[0]
+	p, ok := model.FromDocument(doc).(*model.Pipeline)
+	require.True(t, ok)
+
+	build := p.Task("build")
+	spec := idx.TaskSpec(build, doc, nil)
+	require.NotNil(t, spec)
+	assert.Equal(t, "build", spec.Steps[0].Name.Value)
+	assert.Nil(t, idx.TaskSpec(build, doc, func(string) bool { return false }), "out of scope")
+	assert.Nil(t, idx.TaskSpec(p.Task("remote"), doc, nil), "resolver references are not resolved")
+
+	var none *Index
+	assert.Nil(t, none.TaskSpec(build, doc, nil))
+	inline := &model.PipelineTask{TaskSpec: &model.TaskSpec{}}
+	assert.Same(t, inline.TaskSpec, none.TaskSpec(inline, doc, nil), "inline specs need no index")
+}
+
+func TestIndex_References(t *testing.T) {
+	idx := New()
+	idx.Update("file:///task.yaml", parseAll(t, "file:///task.yaml", buildTask))
+	idx.Update("file:///pipeline.yaml", parseAll(t, "file:///pipeline.yaml", pipeline))
+
+	// The PipelineRun has no name and is not indexed as a resource.
+	assert.Len(t, idx.ResourcesIn("file:///pipeline.yaml"), 1)
+
+	refs := idx.ReferencesTo(Key{Group: "tekton.dev", Kind: "Task", Name: "build"})
+	require.Len(t, refs, 1)
+	assert.Equal(t, "file:///pipeline.yaml", refs[0].URI)
+	assert.Equal(t, "main", refs[0].From.Name)
+	assert.Equal(t, "ci", refs[0].To.Namespace)
+	assert.Equal(t, uint32(9), refs[0].Range.Start.Line)
+
+	// ClusterTask references are cluster-scoped.
+	refs = idx.ReferencesTo(Key{Group: "tekton.dev", Kind: "ClusterTask", Name: "cleanup"})
+	require.Len(t, refs, 1)
+	assert.Empty(t, refs[0].To.Namespace)
+
+	// Step refs point at StepActions.
+	refs = idx.ReferencesTo(Key{Group: "tekton.dev", Kind: "StepAction", Name: "go-build"})
+	require.Len(t, refs, 1)
+	assert.Equal(t, "file:///task.yaml", refs[0].URI)
+
+	// The unnamed PipelineRun still references main.
+	refs = idx.ReferencesTo(Key{Group: "tekton.dev", Kind: "Pipeline", Name: "main"})
+	require.Len(t, refs, 1)
+	assert.Equal(t, "file:///pipeline.yaml", refs[0].URI)
+	assert.Equal(t, "PipelineRun", refs[0].From.Kind)
+	assert.Empty(t, refs[0].From.Name)
+
+	// Resolver references are not indexed.
+	from := idx.ReferencesFrom("file:///pipeline.yaml")
+	assert.Len(t, from, 3, "taskRef build, ClusterTask cleanup and the PipelineRun's pipelineRef main")
+}
+
+func TestIndex_UpdateAndRemove(t *testing.T) {
+	idx := New()
+	idx.Update("file:///task.yaml", parseAll(t, "file:///task.yaml", buildTask))
+	idx.Update("file:///pipeline.yaml", parseAll(t, "file:///pipeline.yaml", pipeline))
+
+	// Renaming the task replaces the old entry.
+	renamed := parseAll(t, "file:///task.yaml", `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: compile
+`)
+	idx.Update("file:///task.yaml", renamed)
+	assert.Empty(t, idx.Lookup(Key{Group: "tekton.dev", Kind: "Task", Name: "build"}))
+	assert.Len(t, idx.Lookup(Key{Group: "tekton.dev", Kind: "Task", Name: "compile"}), 1)
+	assert.Empty(t, idx.ReferencesTo(Key{Group: "tekton.dev", Kind: "StepAction", Name: "go-build"}))
+
+	idx.Remove("file:///pipeline.yaml")
+	assert.Empty(t, idx.ReferencesTo(Key{Group: "tekton.dev", Kind: "Task", Name: "build"}))
+	assert.Empty(t, idx.ResourcesIn("file:///pipeline.yaml"))
+}
+
+func TestIndex_All(t *testing.T) {
+	idx := New()
+	idx.Update("file:///task.yaml", parseAll(t, "file:///task.yaml", buildTask))
+	idx.Update("file:///sa.yaml", parseAll(t, "file:///sa.yaml", `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: builder
+`))
+
+	tasks := idx.All("tekton.dev", "Task")
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "build", tasks[0].Name)
+
+	sas := idx.All("", "ServiceAccount")
+	require.Len(t, sas, 1)
+	assert.Equal(t, "builder", sas[0].Name)
+}
+
+func TestGroup(t *testing.T) {
+	assert.Equal(t, "tekton.dev", Group("tekton.dev/v1"))
+	assert.Equal(t, "triggers.tekton.dev", Group("triggers.tekton.dev/v1beta1"))
+	assert.Equal(t, "", Group("v1"))
+}
+
+func TestIndex_Dependents(t *testing.T) {
+	idx := New()
+	idx.Update("file:///task.yaml", parseAll(t, "file:///task.yaml", buildTask))
+	idx.Update("file:///pipeline.yaml", parseAll(t, "file:///pipeline.yaml", pipeline))
+	idx.Update("file:///run.yaml", parseAll(t, "file:///run.yaml", `apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: nightly
+spec:
+  pipelineRef:
+    name: main
+`))
+	idx.Update("file:///step.yaml", parseAll(t, "file:///step.yaml", `apiVersion: tekton.dev/v1beta1
+kind: StepAction
+metadata:
+  name: go-build
+`))
+	idx.Update("file:///taskrun.yaml", parseAll(t, "file:///taskrun.yaml", `apiVersion: tekton.dev/v1
+kind: TaskRun
+metadata:
+  generateName: build-
+spec:
+  taskRef:
+    name: build
+`))
+
+	// step → task → pipeline → run, transitively.
+	assert.Equal(t, []string{"file:///pipeline.yaml", "file:///run.yaml", "file:///task.yaml", "file:///taskrun.yaml"}, idx.Dependents("file:///step.yaml"))
+	assert.Equal(t, []string{"file:///run.yaml"}, idx.Dependents("file:///pipeline.yaml"))
+	assert.Empty(t, idx.Dependents("file:///run.yaml"))
+
+	// Files with unnamed runs only depend on what they reference.
+	assert.Equal(t, []string{"file:///pipeline.yaml", "file:///run.yaml", "file:///taskrun.yaml"}, idx.Dependents("file:///task.yaml"))
+	assert.Empty(t, idx.Dependents("file:///taskrun.yaml"))
+	assert.Empty(t, idx.Dependents("file:///missing.yaml"))
+
+	// Files defining the same resource depend on each other.
+	idx.Update("file:///copy.yaml", parseAll(t, "file:///copy.yaml", `apiVersion: tekton.dev/v1beta1
+kind: StepAction
+metadata:
+  name: go-build
+`))
+	assert.Contains(t, idx.Dependents("file:///copy.yaml"), "file:///step.yaml")
+	assert.Contains(t, idx.Dependents("file:///step.yaml"), "file:///copy.yaml")
+}
@@ -0,0 +1,100 @@
+package index
+
+import (
+	"github.com/vdemeester/tekton-lsp-go/pkg/model"
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+// tektonGroup is the API group of Tekton Pipelines resources.
+const tektonGroup = "tekton.dev"
+
+// referencesOf extracts the taskRef, pipelineRef and step ref references
+// made by a document. Remote resolver references are skipped since they do
+// not point into the workspace.
+func referencesOf(uri string, from Key, doc *parser.Document) []Reference {
+	var refs []Reference
+	add := func(ref *model.Ref, defaultKind string) {
+		if r, ok := RefKey(ref, defaultKind, from.Namespace); ok {
+			refs = append(refs, Reference{From: from, To: r, URI: uri, Range: ref.Name.Node.Range})
+		}
+	}
+	addTaskSpec := func(spec *model.TaskSpec) {
+		if spec == nil {
+			return
+		}
+		for _, step := range spec.Steps {
+			add(step.Ref, "StepAction")
+		}
+	}
+	addPipelineSpec := func(spec *model.PipelineSpec) {
+		if spec == nil {
+			return
+		}
+		for _, t := range spec.AllTasks() {
+			add(t.TaskRef, "Task")
+			add(t.PipelineRef, "Pipeline")
+			addTaskSpec(t.TaskSpec)
+		}
+	}
+
+	switch obj := model.FromDocument(doc).(type) {
+	case *model.Pipeline:
+		addPipelineSpec(&obj.PipelineSpec)
+	case *model.Task:
+		addTaskSpec(&obj.TaskSpec)
+	case *model.PipelineRun:
+		add(obj.PipelineRef, "Pipeline")
+		addPipelineSpec(obj.PipelineSpec)
+	case *model.TaskRun:
+		add(obj.TaskRef, "Task")
+		addTaskSpec(obj.TaskSpec)
+	}
+	return refs
+}
+
+// RefKey returns the key of the resource a taskRef, pipelineRef or step ref
+// points to, resolved in namespace. defaultKind applies when the ref has no
+// kind. It returns false for resolver references and refs without a name.
+func RefKey(ref *model.Ref, defaultKind, namespace string) (Key, bool) {
+	if ref == nil || ref.Name.Value == "" || ref.Resolver.IsSet() {
+		return Key{}, false
+	}
+	kind := defaultKind
+	if ref.Kind.Value != "" {
+		kind = ref.Kind.Value
+	}
+	group := tektonGroup
+	if ref.APIVersion.Value != "" {
+		group = Group(ref.APIVersion.Value)
+	}
+	if kind == "ClusterTask" {
+		// Cluster-scoped.
+		namespace = ""
+	}
+	return Key{Group: group, Kind: kind, Namespace: namespace, Name: ref.Name.Value}, true
+}
+
+// TaskSpec returns the Task spec a pipeline task of doc runs: its inline
+// taskSpec, or the spec of the Task its taskRef resolves to among the files
+// in scope. It returns nil when neither is known, and is safe to call on a
+// nil index.
+func (idx *Index) TaskSpec(t *model.PipelineTask, doc *parser.Document, scope func(uri string) bool) *model.TaskSpec {
+	if t.TaskSpec != nil {
+		return t.TaskSpec
+	}
+	if idx == nil {
+		return nil
+	}
+	key, ok := RefKey(t.TaskRef, "Task", doc.Root.Get("metadata").Get("namespace").AsScalar())
+	if !ok {
+		return nil
+	}
+	r := idx.ResolveFrom(key, doc.Filename, scope)
+	if r == nil {
+		return nil
+	}
+	if task, ok := model.FromDocument(r.Doc).(*model.Task); ok {
+		return &task.TaskSpec
+	}
+	return nil
+}
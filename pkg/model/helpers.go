@@ -0,0 +1,56 @@
+package model
+
+import (
+	"sort"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+// str reads a scalar field of a mapping.
+func str(n *parser.Node, key string) String {
+	child := n.Get(key)
+	if child == nil {
+		return String{}
+	}
+	return String{Value: child.AsScalar(), Node: child}
+}
+
+// strs reads a sequence of scalars.
+func strs(n *parser.Node) []String {
+	var values []String
+	for _, item := range n.AsSequence() {
+		values = append(values, String{Value: item.AsScalar(), Node: item})
+	}
+	return values
+}
+
+// mapping returns n if it is a mapping, nil otherwise.
+func mapping(n *parser.Node) *parser.Node {
+	if !n.IsMapping() {
+		return nil
+	}
+	return n
+}
+
+// mappings returns the mapping items of a sequence.
+func mappings(n *parser.Node) []*parser.Node {
+	var items []*parser.Node
+	for _, item := range n.AsSequence() {
+		if item.IsMapping() {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// sortByPosition orders values read from a mapping by source position, since
+// mapping children have no defined order.
+func sortByPosition[T any](values []T, node func(T) *parser.Node) {
+	sort.SliceStable(values, func(i, j int) bool {
+		a, b := node(values[i]).Range.Start, node(values[j]).Range.Start
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Character < b.Character
+	})
+}
@@ -0,0 +1,346 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+// IsTekton reports whether a document is a Tekton resource (tekton.dev or
+// triggers.tekton.dev API group).
+func IsTekton(doc *parser.Document) bool {
+	return doc != nil && strings.Contains(doc.APIVersion, "tekton.dev")
+}
+
+// FromDocument builds the typed model for a Tekton document. It returns nil
+// for documents that are not Tekton resources. Missing or malformed fields
+// are left empty rather than reported; validation is the validator's job.
+func FromDocument(doc *parser.Document) Object {
+	if !IsTekton(doc) || doc.Root == nil {
+		return nil
+	}
+	switch doc.Kind {
+	case "Pipeline":
+		return NewPipeline(doc)
+	case "Task", "ClusterTask":
+		return NewTask(doc)
+	case "PipelineRun":
+		return NewPipelineRun(doc)
+	case "TaskRun":
+		return NewTaskRun(doc)
+	case "StepAction":
+		return NewStepAction(doc)
+	default:
+		return &Generic{Resource: newResource(doc)}
+	}
+}
+
+// NewPipeline builds a Pipeline from a document.
+func NewPipeline(doc *parser.Document) *Pipeline {
+	p := &Pipeline{Resource: newResource(doc)}
+	if spec := newPipelineSpec(p.Spec); spec != nil {
+		p.PipelineSpec = *spec
+	}
+	return p
+}
+
+// NewTask builds a Task (or ClusterTask) from a document.
+func NewTask(doc *parser.Document) *Task {
+	t := &Task{Resource: newResource(doc)}
+	if spec := newTaskSpec(t.Spec); spec != nil {
+		t.TaskSpec = *spec
+	}
+	return t
+}
+
+// NewPipelineRun builds a PipelineRun from a document.
+func NewPipelineRun(doc *parser.Document) *PipelineRun {
+	pr := &PipelineRun{Resource: newResource(doc)}
+	spec := pr.Spec
+	pr.PipelineRef = NewRef(spec.Get("pipelineRef"))
+	pr.PipelineSpec = newPipelineSpec(spec.Get("pipelineSpec"))
+	pr.Params = newParams(spec.Get("params"))
+	pr.Workspaces = newWorkspaceBindings(spec.Get("workspaces"))
+	pr.Timeouts = spec.Get("timeouts")
+	pr.ServiceAccountName = str(spec.Get("taskRunTemplate"), "serviceAccountName")
+	if !pr.ServiceAccountName.IsSet() {
+		// v1beta1 kept it at the top of the spec.
+		pr.ServiceAccountName = str(spec, "serviceAccountName")
+	}
+	return pr
+}
+
+// NewTaskRun builds a TaskRun from a document.
+func NewTaskRun(doc *parser.Document) *TaskRun {
+	tr := &TaskRun{Resource: newResource(doc)}
+	spec := tr.Spec
+	tr.TaskRef = NewRef(spec.Get("taskRef"))
+	tr.TaskSpec = newTaskSpec(spec.Get("taskSpec"))
+	tr.Params = newParams(spec.Get("params"))
+	tr.Workspaces = newWorkspaceBindings(spec.Get("workspaces"))
+	tr.ServiceAccountName = str(spec, "serviceAccountName")
+	tr.Timeout = str(spec, "timeout")
+	return tr
+}
+
+// NewStepAction builds a StepAction from a document.
+func NewStepAction(doc *parser.Document) *StepAction {
+	sa := &StepAction{Resource: newResource(doc)}
+	spec := sa.Spec
+	sa.Description = str(spec, "description")
+	sa.Image = str(spec, "image")
+	sa.Script = str(spec, "script")
+	sa.Command = strs(spec.Get("command"))
+	sa.Args = strs(spec.Get("args"))
+	sa.Params = newParamSpecs(spec.Get("params"))
+	sa.Results = newResults(spec.Get("results"))
+	return sa
+}
+
+func newResource(doc *parser.Document) Resource {
+	root := doc.Root
+	metadata := mapping(root.Get("metadata"))
+	return Resource{
+		Doc:          doc,
+		APIVersion:   str(root, "apiVersion"),
+		Kind:         str(root, "kind"),
+		Name:         str(metadata, "name"),
+		GenerateName: str(metadata, "generateName"),
+		Namespace:    str(metadata, "namespace"),
+		Metadata:     metadata,
+		Spec:         mapping(root.Get("spec")),
+	}
+}
+
+func newPipelineSpec(n *parser.Node) *PipelineSpec {
+	if n = mapping(n); n == nil {
+		return nil
+	}
+	return &PipelineSpec{
+		Node:        n,
+		Description: str(n, "description"),
+		Params:      newParamSpecs(n.Get("params")),
+		Workspaces:  newWorkspaceDeclarations(n.Get("workspaces")),
+		Results:     newPipelineResults(n.Get("results")),
+		Tasks:       newPipelineTasks(n.Get("tasks"), false),
+		Finally:     newPipelineTasks(n.Get("finally"), true),
+	}
+}
+
+func newPipelineTasks(n *parser.Node, finally bool) []*PipelineTask {
+	var tasks []*PipelineTask
+	for _, item := range mappings(n) {
+		t := &PipelineTask{
+			Node:        item,
+			Name:        str(item, "name"),
+			DisplayName: str(item, "displayName"),
+			Description: str(item, "description"),
+			TaskRef:     NewRef(item.Get("taskRef")),
+			TaskSpec:    newTaskSpec(item.Get("taskSpec")),
+			PipelineRef: NewRef(item.Get("pipelineRef")),
+			RunAfter:    strs(item.Get("runAfter")),
+			Params:      newParams(item.Get("params")),
+			Workspaces:  newWorkspaceBindings(item.Get("workspaces")),
+			When:        newWhenExpressions(item.Get("when")),
+			Timeout:     str(item, "timeout"),
+			Retries:     str(item, "retries"),
+			OnError:     str(item, "onError"),
+			Finally:     finally,
+		}
+		if m := mapping(item.Get("matrix")); m != nil {
+			t.Matrix = &Matrix{Node: m, Params: newParams(m.Get("params"))}
+			for _, inc := range mappings(m.Get("include")) {
+				t.Matrix.Include = append(t.Matrix.Include, &MatrixInclude{
+					Node:   inc,
+					Name:   str(inc, "name"),
+					Params: newParams(inc.Get("params")),
+				})
+			}
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks
+}
+
+func newWhenExpressions(n *parser.Node) []*WhenExpression {
+	var whens []*WhenExpression
+	for _, item := range mappings(n) {
+		whens = append(whens, &WhenExpression{
+			Node:     item,
+			Input:    str(item, "input"),
+			Operator: str(item, "operator"),
+			Values:   strs(item.Get("values")),
+			CEL:      str(item, "cel"),
+		})
+	}
+	return whens
+}
+
+// NewRef builds a Ref from a taskRef, pipelineRef or step ref mapping. It
+// returns nil if n is not a mapping.
+func NewRef(n *parser.Node) *Ref {
+	if n = mapping(n); n == nil {
+		return nil
+	}
+	return &Ref{
+		Node:       n,
+		Name:       str(n, "name"),
+		Kind:       str(n, "kind"),
+		APIVersion: str(n, "apiVersion"),
+		Resolver:   str(n, "resolver"),
+		Params:     newParams(n.Get("params")),
+	}
+}
+
+func newTaskSpec(n *parser.Node) *TaskSpec {
+	if n = mapping(n); n == nil {
+		return nil
+	}
+	spec := &TaskSpec{
+		Node:        n,
+		Description: str(n, "description"),
+		Params:      newParamSpecs(n.Get("params")),
+		Workspaces:  newWorkspaceDeclarations(n.Get("workspaces")),
+		Results:     newResults(n.Get("results")),
+		Steps:       newSteps(n.Get("steps")),
+		Sidecars:    newSteps(n.Get("sidecars")),
+	}
+	if tmpl := mapping(n.Get("stepTemplate")); tmpl != nil {
+		spec.StepTemplate = newStep(tmpl)
+	}
+	return spec
+}
+
+func newSteps(n *parser.Node) []*Step {
+	var steps []*Step
+	for _, item := range mappings(n) {
+		steps = append(steps, newStep(item))
+	}
+	return steps
+}
+
+func newStep(n *parser.Node) *Step {
+	s := &Step{
+		Node:       n,
+		Name:       str(n, "name"),
+		Image:      str(n, "image"),
+		Script:     str(n, "script"),
+		Command:    strs(n.Get("command")),
+		Args:       strs(n.Get("args")),
+		WorkingDir: str(n, "workingDir"),
+		OnError:    str(n, "onError"),
+		Timeout:    str(n, "timeout"),
+		Ref:        NewRef(n.Get("ref")),
+		Params:     newParams(n.Get("params")),
+		Results:    newResults(n.Get("results")),
+	}
+	for _, item := range mappings(n.Get("env")) {
+		s.Env = append(s.Env, &EnvVar{
+			Node:  item,
+			Name:  str(item, "name"),
+			Value: str(item, "value"),
+		})
+	}
+	return s
+}
+
+func newParamSpecs(n *parser.Node) []*ParamSpec {
+	var params []*ParamSpec
+	for _, item := range mappings(n) {
+		params = append(params, &ParamSpec{
+			Node:        item,
+			Name:        str(item, "name"),
+			Type:        str(item, "type"),
+			Description: str(item, "description"),
+			Default:     item.Get("default"),
+			Properties:  newProperties(item.Get("properties")),
+			Enum:        strs(item.Get("enum")),
+		})
+	}
+	return params
+}
+
+func newProperties(n *parser.Node) []*PropertySpec {
+	n = mapping(n)
+	if n == nil {
+		return nil
+	}
+	var props []*PropertySpec
+	for key, child := range n.MappingChildren {
+		props = append(props, &PropertySpec{
+			Node: child,
+			Name: String{Value: key, Node: child},
+			Type: str(child, "type"),
+		})
+	}
+	sortByPosition(props, func(p *PropertySpec) *parser.Node { return p.Node })
+	return props
+}
+
+func newParams(n *parser.Node) []*Param {
+	var params []*Param
+	for _, item := range mappings(n) {
+		params = append(params, &Param{
+			Node:  item,
+			Name:  str(item, "name"),
+			Value: item.Get("value"),
+		})
+	}
+	return params
+}
+
+func newWorkspaceDeclarations(n *parser.Node) []*WorkspaceDeclaration {
+	var workspaces []*WorkspaceDeclaration
+	for _, item := range mappings(n) {
+		workspaces = append(workspaces, &WorkspaceDeclaration{
+			Node:        item,
+			Name:        str(item, "name"),
+			Description: str(item, "description"),
+			MountPath:   str(item, "mountPath"),
+			ReadOnly:    str(item, "readOnly"),
+			Optional:    str(item, "optional"),
+		})
+	}
+	return workspaces
+}
+
+func newWorkspaceBindings(n *parser.Node) []*WorkspaceBinding {
+	var bindings []*WorkspaceBinding
+	for _, item := range mappings(n) {
+		bindings = append(bindings, &WorkspaceBinding{
+			Node:      item,
+			Name:      str(item, "name"),
+			Workspace: str(item, "workspace"),
+			SubPath:   str(item, "subPath"),
+		})
+	}
+	return bindings
+}
+
+func newResults(n *parser.Node) []*Result {
+	var results []*Result
+	for _, item := range mappings(n) {
+		results = append(results, &Result{
+			Node:        item,
+			Name:        str(item, "name"),
+			Type:        str(item, "type"),
+			Description: str(item, "description"),
+			Properties:  newProperties(item.Get("properties")),
+		})
+	}
+	return results
+}
+
+func newPipelineResults(n *parser.Node) []*PipelineResult {
+	var results []*PipelineResult
+	for _, item := range mappings(n) {
+		results = append(results, &PipelineResult{
+			Node:        item,
+			Name:        str(item, "name"),
+			Type:        str(item, "type"),
+			Description: str(item, "description"),
+			Value:       item.Get("value"),
+		})
+	}
+	return results
+}
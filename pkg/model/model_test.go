@@ -0,0 +1,244 @@
+package model
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+func parse(t *testing.T, yaml string) *parser.Document {
+	t.Helper()
+	doc, err := parser.ParseYAML("test.yaml", yaml)
+	require.NoError(t, err)
+	return doc
+}
+
+func TestFromDocument_Pipeline(t *testing.T) {
+	doc := parse(t, `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: build-pipeline
+  namespace: ci
+spec:
+  params:
+    - name: revision
+      type: string
+      default: main
+  workspaces:
+    - name: source
+  tasks:
+    - name: clone
+      taskRef:
+        name: git-clone
+        kind: Task
+      params:
+        - name: revision
+          value: $(params.revision)
+      workspaces:
+        - name: output
+          workspace: source
+    - name: build
+      runAfter: [clone]
+      when:
+        - input: $(params.revision)
+          operator: in
+          values: ["main"]
+      taskSpec:
+        steps:
+          - name: compile
+            image: golang:1.25
+  finally:
+    - name: notify
+      taskRef:
+        resolver: hub
+        params:
+          - name: name
+            value: notify
+`)
+	obj := FromDocument(doc)
+	require.NotNil(t, obj)
+	p, ok := obj.(*Pipeline)
+	require.True(t, ok, "should build a Pipeline")
+
+	assert.Equal(t, "build-pipeline", p.Name.Value)
+	assert.Equal(t, "ci", p.Namespace.Value)
+	assert.Equal(t, uint32(3), p.Name.Node.Range.Start.Line)
+
+	require.Len(t, p.Params, 1)
+	assert.Equal(t, "revision", p.Params[0].Name.Value)
+	assert.Equal(t, "string", p.Params[0].ParamType())
+	assert.Equal(t, "main", p.Params[0].Default.AsScalar())
+	assert.Same(t, p.Params[0], p.Param("revision"))
+
+	require.Len(t, p.Workspaces, 1)
+	require.Len(t, p.Tasks, 2)
+	require.Len(t, p.Finally, 1)
+
+	clone := p.Tasks[0]
+	require.NotNil(t, clone.TaskRef)
+	assert.Equal(t, "git-clone", clone.TaskRef.Name.Value)
+	assert.Equal(t, "Task", clone.TaskRef.Kind.Value)
+	require.NotNil(t, clone.Param("revision"))
+	assert.Equal(t, "$(params.revision)", clone.Param("revision").Value.AsScalar())
+	require.Len(t, clone.Workspaces, 1)
+	assert.Equal(t, "source", clone.Workspaces[0].Workspace.Value)
+
+	build := p.Task("build")
+	require.NotNil(t, build)
+	require.Len(t, build.RunAfter, 1)
+	assert.Equal(t, "clone", build.RunAfter[0].Value)
+	require.Len(t, build.When, 1)
+	assert.Equal(t, "in", build.When[0].Operator.Value)
+	require.NotNil(t, build.TaskSpec)
+	require.Len(t, build.TaskSpec.Steps, 1)
+	assert.Equal(t, "golang:1.25", build.TaskSpec.Steps[0].Image.Value)
+
+	notify := p.Task("notify")
+	require.NotNil(t, notify)
+	assert.True(t, notify.Finally)
+	assert.Equal(t, "hub", notify.TaskRef.Resolver.Value)
+	assert.Len(t, p.AllTasks(), 3)
+}
+
+func TestFromDocument_Task(t *testing.T) {
+	doc := parse(t, `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  generateName: build-
+spec:
+  params:
+    - name: config
+      properties:
+        url: {}
+        branch:
+          type: string
+  results:
+    - name: digest
+      description: Image digest
+  stepTemplate:
+    image: alpine
+  steps:
+    - name: build
+      image: golang:1.25
+      script: |
+        go build ./...
+      env:
+        - name: GOFLAGS
+          value: -mod=vendor
+`)
+	task, ok := FromDocument(doc).(*Task)
+	require.True(t, ok)
+
+	assert.Equal(t, "build-", task.DisplayName())
+	assert.False(t, task.Name.IsSet())
+
+	require.Len(t, task.Params, 1)
+	assert.Equal(t, "object", task.Params[0].ParamType())
+	require.Len(t, task.Params[0].Properties, 2)
+	assert.Equal(t, "url", task.Params[0].Properties[0].Name.Value)
+	assert.Equal(t, "branch", task.Params[0].Properties[1].Name.Value)
+
+	require.NotNil(t, task.Result("digest"))
+	assert.Equal(t, "Image digest", task.Result("digest").Description.Value)
+
+	require.NotNil(t, task.StepTemplate)
+	assert.Equal(t, "alpine", task.StepTemplate.Image.Value)
+
+	require.Len(t, task.Steps, 1)
+	assert.Equal(t, "go build ./...\n", task.Steps[0].Script.Value)
+	require.Len(t, task.Steps[0].Env, 1)
+	assert.Equal(t, "GOFLAGS", task.Steps[0].Env[0].Name.Value)
+}
+
+func TestFromDocument_Runs(t *testing.T) {
+	docs, err := parser.ParseAllYAML("runs.yaml", `apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: run
+spec:
+  pipelineRef:
+    name: build-pipeline
+  params:
+    - name: revision
+      value: main
+  taskRunTemplate:
+    serviceAccountName: builder
+---
+apiVersion: tekton.dev/v1
+kind: TaskRun
+metadata:
+  name: run
+spec:
+  serviceAccountName: runner
+  taskSpec:
+    steps:
+      - name: hello
+        image: alpine
+---
+apiVersion: tekton.dev/v1beta1
+kind: StepAction
+metadata:
+  name: action
+spec:
+  image: alpine
+  params:
+    - name: message
+`)
+	require.NoError(t, err)
+	require.Len(t, docs, 3)
+
+	pr, ok := FromDocument(docs[0]).(*PipelineRun)
+	require.True(t, ok)
+	require.NotNil(t, pr.PipelineRef)
+	assert.Equal(t, "build-pipeline", pr.PipelineRef.Name.Value)
+	assert.Equal(t, "builder", pr.ServiceAccountName.Value)
+	require.Len(t, pr.Params, 1)
+
+	tr, ok := FromDocument(docs[1]).(*TaskRun)
+	require.True(t, ok)
+	assert.Nil(t, tr.TaskRef)
+	require.NotNil(t, tr.TaskSpec)
+	assert.Len(t, tr.TaskSpec.Steps, 1)
+	assert.Equal(t, "runner", tr.ServiceAccountName.Value)
+
+	sa, ok := FromDocument(docs[2]).(*StepAction)
+	require.True(t, ok)
+	assert.Equal(t, "alpine", sa.Image.Value)
+	require.Len(t, sa.Params, 1)
+}
+
+func TestFromDocument_NonTekton(t *testing.T) {
+	doc := parse(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+`)
+	assert.Nil(t, FromDocument(doc))
+}
+
+func TestFromDocument_Generic(t *testing.T) {
+	doc := parse(t, `apiVersion: triggers.tekton.dev/v1beta1
+kind: EventListener
+metadata:
+  name: listener
+`)
+	obj := FromDocument(doc)
+	require.NotNil(t, obj)
+	assert.Equal(t, "listener", obj.Meta().Name.Value)
+	assert.Equal(t, "EventListener", obj.Meta().Kind.Value)
+}
+
+func TestFromDocument_MissingSpec(t *testing.T) {
+	doc := parse(t, `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: empty
+`)
+	p, ok := FromDocument(doc).(*Pipeline)
+	require.True(t, ok)
+	assert.Nil(t, p.Spec)
+	assert.Empty(t, p.Tasks)
+}
@@ -0,0 +1,336 @@
+// Package model provides a typed, position-aware view of Tekton resources
+// layered on top of the parser AST. Every field keeps the node it was read
+// from so analyzers can report precise locations.
+package model
+
+import "github.com/vdemeester/tekton-lsp-go/pkg/parser"
+
+// String is a scalar field together with the node it was read from.
+// Node is nil when the field is absent.
+type String struct {
+	Value string
+	Node  *parser.Node
+}
+
+// IsSet reports whether the field is present in the source.
+func (s String) IsSet() bool {
+	return s.Node != nil
+}
+
+// Object is a typed Tekton resource.
+type Object interface {
+	// Meta returns the fields shared by every resource.
+	Meta() *Resource
+}
+
+// Resource holds the fields shared by every Tekton resource.
+type Resource struct {
+	Doc          *parser.Document
+	APIVersion   String
+	Kind         String
+	Name         String
+	GenerateName String
+	Namespace    String
+	// Metadata is the metadata mapping, nil when missing.
+	Metadata *parser.Node
+	// Spec is the spec mapping, nil when missing.
+	Spec *parser.Node
+}
+
+// Meta implements Object.
+func (r *Resource) Meta() *Resource {
+	return r
+}
+
+// DisplayName returns the resource name, falling back to generateName.
+func (r *Resource) DisplayName() string {
+	if r.Name.Value != "" {
+		return r.Name.Value
+	}
+	return r.GenerateName.Value
+}
+
+// Pipeline is a tekton.dev Pipeline.
+type Pipeline struct {
+	Resource
+	PipelineSpec
+}
+
+// PipelineSpec is the spec of a Pipeline, also embedded in PipelineRuns.
+type PipelineSpec struct {
+	Node        *parser.Node
+	Description String
+	Params      []*ParamSpec
+	Workspaces  []*WorkspaceDeclaration
+	Results     []*PipelineResult
+	Tasks       []*PipelineTask
+	Finally     []*PipelineTask
+}
+
+// AllTasks returns the tasks followed by the finally tasks.
+func (s *PipelineSpec) AllTasks() []*PipelineTask {
+	all := make([]*PipelineTask, 0, len(s.Tasks)+len(s.Finally))
+	all = append(all, s.Tasks...)
+	return append(all, s.Finally...)
+}
+
+// Task returns the pipeline task (or finally task) with the given name.
+func (s *PipelineSpec) Task(name string) *PipelineTask {
+	for _, t := range s.AllTasks() {
+		if t.Name.Value == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Param returns the declared param with the given name.
+func (s *PipelineSpec) Param(name string) *ParamSpec {
+	return findParamSpec(s.Params, name)
+}
+
+// PipelineTask is an entry of a Pipeline's tasks or finally list.
+type PipelineTask struct {
+	Node        *parser.Node
+	Name        String
+	DisplayName String
+	Description String
+	TaskRef     *Ref
+	TaskSpec    *TaskSpec
+	PipelineRef *Ref
+	RunAfter    []String
+	Params      []*Param
+	Matrix      *Matrix
+	Workspaces  []*WorkspaceBinding
+	When        []*WhenExpression
+	Timeout     String
+	Retries     String
+	OnError     String
+	// Finally is true for entries of the finally list.
+	Finally bool
+}
+
+// Param returns the param passed with the given name.
+func (t *PipelineTask) Param(name string) *Param {
+	for _, p := range t.Params {
+		if p.Name.Value == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Matrix is the fan-out configuration of a pipeline task.
+type Matrix struct {
+	Node    *parser.Node
+	Params  []*Param
+	Include []*MatrixInclude
+}
+
+// MatrixInclude is an entry of matrix.include.
+type MatrixInclude struct {
+	Node   *parser.Node
+	Name   String
+	Params []*Param
+}
+
+// WhenExpression guards the execution of a pipeline task.
+type WhenExpression struct {
+	Node     *parser.Node
+	Input    String
+	Operator String
+	Values   []String
+	CEL      String
+}
+
+// Ref references another resource (taskRef, pipelineRef, step ref), either
+// by name in the cluster or through a remote resolver.
+type Ref struct {
+	Node       *parser.Node
+	Name       String
+	Kind       String
+	APIVersion String
+	Resolver   String
+	Params     []*Param
+}
+
+// Task is a tekton.dev Task or ClusterTask.
+type Task struct {
+	Resource
+	TaskSpec
+}
+
+// TaskSpec is the spec of a Task, also embedded in TaskRuns and pipeline tasks.
+type TaskSpec struct {
+	Node         *parser.Node
+	Description  String
+	Params       []*ParamSpec
+	Workspaces   []*WorkspaceDeclaration
+	Results      []*Result
+	Steps        []*Step
+	Sidecars     []*Step
+	StepTemplate *Step
+}
+
+// Param returns the declared param with the given name.
+func (s *TaskSpec) Param(name string) *ParamSpec {
+	return findParamSpec(s.Params, name)
+}
+
+// Result returns the declared result with the given name.
+func (s *TaskSpec) Result(name string) *Result {
+	for _, r := range s.Results {
+		if r.Name.Value == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// Step is a container in a Task's steps (also used for sidecars and the
+// step template).
+type Step struct {
+	Node       *parser.Node
+	Name       String
+	Image      String
+	Script     String
+	Command    []String
+	Args       []String
+	WorkingDir String
+	OnError    String
+	Timeout    String
+	Env        []*EnvVar
+	Ref        *Ref
+	Params     []*Param
+	Results    []*Result
+}
+
+// EnvVar is an environment variable of a step.
+type EnvVar struct {
+	Node  *parser.Node
+	Name  String
+	Value String
+}
+
+// StepAction is a tekton.dev StepAction.
+type StepAction struct {
+	Resource
+	Description String
+	Image       String
+	Script      String
+	Command     []String
+	Args        []String
+	Params      []*ParamSpec
+	Results     []*Result
+}
+
+// ParamSpec declares a parameter of a Task, Pipeline or StepAction.
+type ParamSpec struct {
+	Node        *parser.Node
+	Name        String
+	Type        String
+	Description String
+	// Default is the default value node, nil when there is none.
+	Default    *parser.Node
+	Properties []*PropertySpec
+	Enum       []String
+}
+
+// ParamType returns the declared type, defaulting to "string".
+func (p *ParamSpec) ParamType() string {
+	if p.Type.Value != "" {
+		return p.Type.Value
+	}
+	if len(p.Properties) > 0 {
+		return "object"
+	}
+	return "string"
+}
+
+// PropertySpec is a key of an object param or result.
+type PropertySpec struct {
+	Node *parser.Node
+	Name String
+	Type String
+}
+
+// Param passes a value to a declared parameter.
+type Param struct {
+	Node *parser.Node
+	Name String
+	// Value is the value node (scalar, sequence or mapping).
+	Value *parser.Node
+}
+
+// WorkspaceDeclaration declares a workspace of a Task or Pipeline.
+type WorkspaceDeclaration struct {
+	Node        *parser.Node
+	Name        String
+	Description String
+	MountPath   String
+	ReadOnly    String
+	Optional    String
+}
+
+// WorkspaceBinding binds a workspace in a pipeline task or a run.
+type WorkspaceBinding struct {
+	Node      *parser.Node
+	Name      String
+	Workspace String
+	SubPath   String
+}
+
+// Result declares a result of a Task or step.
+type Result struct {
+	Node        *parser.Node
+	Name        String
+	Type        String
+	Description String
+	Properties  []*PropertySpec
+}
+
+// PipelineResult declares a result of a Pipeline, computed from task results.
+type PipelineResult struct {
+	Node        *parser.Node
+	Name        String
+	Type        String
+	Description String
+	Value       *parser.Node
+}
+
+// PipelineRun is a tekton.dev PipelineRun.
+type PipelineRun struct {
+	Resource
+	PipelineRef        *Ref
+	PipelineSpec       *PipelineSpec
+	Params             []*Param
+	Workspaces         []*WorkspaceBinding
+	ServiceAccountName String
+	Timeouts           *parser.Node
+}
+
+// TaskRun is a tekton.dev TaskRun.
+type TaskRun struct {
+	Resource
+	TaskRef            *Ref
+	TaskSpec           *TaskSpec
+	Params             []*Param
+	Workspaces         []*WorkspaceBinding
+	ServiceAccountName String
+	Timeout            String
+}
+
+// Generic is a Tekton resource of a kind without a dedicated type
+// (e.g. TriggerTemplate, EventListener).
+type Generic struct {
+	Resource
+}
+
+func findParamSpec(params []*ParamSpec, name string) *ParamSpec {
+	for _, p := range params {
+		if p.Name.Value == name {
+			return p
+		}
+	}
+	return nil
+}
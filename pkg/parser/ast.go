@@ -1,5 +1,7 @@
 package parser
 
+import "sort"
+
 // Position represents a position in a text document (0-indexed).
 type Position struct {
 	Line      uint32
@@ -32,19 +34,47 @@ type Node struct {
 	Key string
 	// Kind is the type of this node.
 	Kind NodeKind
-	// ScalarValue holds the value for scalar nodes.
+	// ScalarValue holds the decoded value for scalar nodes: quotes removed,
+	// escapes resolved and block scalars folded and chomped.
 	ScalarValue string
+	// Raw holds the scalar's source text, including quotes, escape sequences
+	// and block scalar headers.
+	Raw string
+	// Style is how the scalar is written in the source.
+	Style ScalarStyle
 	// MappingChildren holds key→node pairs for mapping nodes.
 	MappingChildren map[string]*Node
 	// SequenceChildren holds ordered items for sequence nodes.
 	SequenceChildren []*Node
 	// Range is the range in the document where this node appears.
 	Range Range
+	// Anchor is the name of the anchor declared on this node (&name), if any.
+	Anchor string
+	// AnchorRange is the range of the &name token declaring Anchor.
+	AnchorRange Range
+	// Alias is the anchor name this node was resolved from (*name), if any.
+	Alias string
+	// AliasRange is the range of the *name token that produced this node.
+	AliasRange Range
+	// Origin is the anchored node an alias resolved to. It is nil for nodes
+	// that are not aliases and for aliases to unknown anchors.
+	Origin *Node
+	// Merges holds the aliases applied to this mapping through "<<" merge keys.
+	// Their entries are already part of MappingChildren.
+	Merges []*Node
+	// LeadingComments are the comments on the lines directly above this node.
+	LeadingComments []*Comment
+	// TrailingComment is the comment at the end of this node's first line.
+	TrailingComment *Comment
+
+	// offsets maps bytes of ScalarValue back to document positions.
+	offsets []segment
 }
 
 // Get returns a child node by key (for mappings). Returns nil if not found.
+// It is safe to call on a nil node, so lookups can be chained.
 func (n *Node) Get(key string) *Node {
-	if n.Kind != NodeKindMapping {
+	if n == nil || n.Kind != NodeKindMapping {
 		return nil
 	}
 	return n.MappingChildren[key]
@@ -52,15 +82,51 @@ func (n *Node) Get(key string) *Node {
 
 // AsScalar returns the scalar value as a string. Returns "" if not a scalar.
 func (n *Node) AsScalar() string {
-	if n.Kind != NodeKindScalar {
+	if n == nil || n.Kind != NodeKindScalar {
 		return ""
 	}
 	return n.ScalarValue
 }
 
+// ValuePosition returns the document position of byte offset i of ScalarValue.
+// Offsets past the end map to just after the last character. Nodes without an
+// offset map (non-scalars) return the start of their range.
+func (n *Node) ValuePosition(i int) Position {
+	if len(n.offsets) == 0 {
+		return n.Range.Start
+	}
+	if i >= len(n.ScalarValue) && len(n.ScalarValue) > 0 {
+		p := n.ValuePosition(len(n.ScalarValue) - 1)
+		p.Character++
+		return p
+	}
+	k := sort.Search(len(n.offsets), func(k int) bool { return n.offsets[k].offset > i }) - 1
+	if k < 0 {
+		k = 0
+	}
+	seg := n.offsets[k]
+	if seg.synthetic || i < seg.offset {
+		return seg.pos
+	}
+	return Position{Line: seg.pos.Line, Character: seg.pos.Character + uint32(i-seg.offset)}
+}
+
+// ValueRange returns the document range of ScalarValue[start:end], so that a
+// diagnostic about part of a scalar can point at the exact characters.
+func (n *Node) ValueRange(start, end int) Range {
+	r := Range{Start: n.ValuePosition(start)}
+	if end <= start {
+		r.End = r.Start
+		return r
+	}
+	r.End = n.ValuePosition(end - 1)
+	r.End.Character++
+	return r
+}
+
 // AsSequence returns the sequence items. Returns nil if not a sequence.
 func (n *Node) AsSequence() []*Node {
-	if n.Kind != NodeKindSequence {
+	if n == nil || n.Kind != NodeKindSequence {
 		return nil
 	}
 	return n.SequenceChildren
@@ -68,17 +134,17 @@ func (n *Node) AsSequence() []*Node {
 
 // IsMapping returns true if this node is a mapping.
 func (n *Node) IsMapping() bool {
-	return n.Kind == NodeKindMapping
+	return n != nil && n.Kind == NodeKindMapping
 }
 
 // IsSequence returns true if this node is a sequence.
 func (n *Node) IsSequence() bool {
-	return n.Kind == NodeKindSequence
+	return n != nil && n.Kind == NodeKindSequence
 }
 
 // IsScalar returns true if this node is a scalar.
 func (n *Node) IsScalar() bool {
-	return n.Kind == NodeKindScalar
+	return n != nil && n.Kind == NodeKindScalar
 }
 
 // Document represents a parsed YAML document.
@@ -93,6 +159,28 @@ type Document struct {
 	Kind string
 	// Index is the 0-based position of this document within a multi-document YAML file.
 	Index int
+	// Anchors maps anchor names declared in this document to their nodes.
+	Anchors map[string]*Node
+	// Comments holds every comment in this document, in source order.
+	Comments []*Comment
+	// Content is the text of the whole file the document was parsed from,
+	// which positions index into. Features that work on partly typed
+	// content, like completion, read the lines the AST cannot represent.
+	Content string
+}
+
+// Comment is a YAML comment attached to the node it documents.
+type Comment struct {
+	// Text is the comment without its leading '#' and surrounding whitespace.
+	Text string
+	// Range is the range of the comment, including the '#'.
+	Range Range
+	// Node is the node the comment is attached to, or nil if there is none
+	// (e.g. a comment at the end of the document).
+	Node *Node
+	// Trailing is true when the comment follows Node on the same line, and
+	// false when it sits on its own line above Node.
+	Trailing bool
 }
 
 // FindNodeAtPosition returns the most specific node at the given position.
@@ -111,6 +199,11 @@ func findNodeAtPosition(node *Node, pos Position) *Node {
 	// Depth-first: check children for a more specific match.
 	switch node.Kind {
 	case NodeKindMapping:
+		for _, merge := range node.Merges {
+			if positionInRange(pos, merge.Range) {
+				return merge
+			}
+		}
 		for _, child := range node.MappingChildren {
 			if found := findNodeAtPosition(child, pos); found != nil {
 				return found
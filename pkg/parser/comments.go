@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+
+	tree_sitter "github.com/tree-sitter/go-tree-sitter"
+)
+
+// collectComments returns the comment nodes below tsNode in source order.
+func collectComments(tsNode *tree_sitter.Node, content []byte) []*Comment {
+	var comments []*Comment
+	if tsNode.Kind() == "comment" {
+		return []*Comment{newComment(tsNode, content)}
+	}
+	for i := uint(0); i < tsNode.ChildCount(); i++ {
+		comments = append(comments, collectComments(tsNode.Child(i), content)...)
+	}
+	return comments
+}
+
+// newComment builds a Comment from a tree-sitter comment node.
+func newComment(tsNode *tree_sitter.Node, content []byte) *Comment {
+	text := strings.TrimPrefix(extractText(tsNode, content), "#")
+	return &Comment{
+		Text:  strings.TrimSpace(text),
+		Range: nodeRange(tsNode),
+	}
+}
+
+// attachComments attaches each comment to the node it documents. A comment
+// that follows a node on the same line trails the innermost such node; a
+// comment on its own line leads the outermost node starting after it.
+func attachComments(root *Node, comments []*Comment) {
+	if root == nil || len(comments) == 0 {
+		return
+	}
+
+	var nodes []*Node
+	collectOwnedNodes(root, &nodes)
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return positionBefore(nodes[i].Range.Start, nodes[j].Range.Start)
+	})
+
+	for _, c := range comments {
+		line := c.Range.Start.Line
+
+		var trailing *Node
+		for _, n := range nodes {
+			if n.Range.Start.Line > line {
+				break
+			}
+			if n.Range.Start.Line == line && n.Range.Start.Character < c.Range.Start.Character {
+				trailing = n
+			}
+		}
+		if trailing != nil {
+			c.Node = trailing
+			c.Trailing = true
+			trailing.TrailingComment = c
+			continue
+		}
+
+		i := sort.Search(len(nodes), func(i int) bool { return nodes[i].Range.Start.Line > line })
+		if i < len(nodes) {
+			c.Node = nodes[i]
+			nodes[i].LeadingComments = append(nodes[i].LeadingComments, c)
+		}
+	}
+}
+
+// collectOwnedNodes appends n and the nodes written below it in pre-order.
+// Nodes reached through aliases or merge keys live elsewhere in the source
+// and are skipped.
+func collectOwnedNodes(n *Node, out *[]*Node) {
+	*out = append(*out, n)
+	if n.Origin != nil {
+		return
+	}
+	switch n.Kind {
+	case NodeKindMapping:
+		children := make([]*Node, 0, len(n.MappingChildren))
+		for key, child := range n.MappingChildren {
+			if !isMerged(n, key, child) {
+				children = append(children, child)
+			}
+		}
+		sort.Slice(children, func(i, j int) bool {
+			return positionBefore(children[i].Range.Start, children[j].Range.Start)
+		})
+		for _, child := range children {
+			collectOwnedNodes(child, out)
+		}
+	case NodeKindSequence:
+		for _, child := range n.SequenceChildren {
+			collectOwnedNodes(child, out)
+		}
+	}
+}
+
+// isMerged reports whether child was added to n by a "<<" merge key.
+func isMerged(n *Node, key string, child *Node) bool {
+	for _, m := range n.Merges {
+		if m.MappingChildren[key] == child {
+			return true
+		}
+	}
+	return false
+}
+
+func positionBefore(a, b Position) bool {
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Character < b.Character
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComments_LeadingAndTrailing(t *testing.T) {
+	yaml := `# Build task
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build # the task name
+spec:
+  steps:
+    # Compile everything
+    # with the Go toolchain
+    - name: compile
+      image: golang:1.25
+# trailing document comment
+`
+	doc, err := ParseYAML("test.yaml", yaml)
+	require.NoError(t, err)
+	require.Len(t, doc.Comments, 5)
+
+	// File header comment leads the document root.
+	require.Len(t, doc.Root.LeadingComments, 1)
+	assert.Equal(t, "Build task", doc.Root.LeadingComments[0].Text)
+
+	// Same-line comment trails the entry.
+	name := doc.Root.Get("metadata").Get("name")
+	require.NotNil(t, name.TrailingComment)
+	assert.Equal(t, "the task name", name.TrailingComment.Text)
+	assert.True(t, name.TrailingComment.Trailing)
+	assert.Same(t, name, name.TrailingComment.Node)
+
+	// Own-line comments lead the sequence item below them.
+	step := doc.Root.Get("spec").Get("steps").AsSequence()[0]
+	require.Len(t, step.LeadingComments, 2)
+	assert.Equal(t, "Compile everything", step.LeadingComments[0].Text)
+	assert.Equal(t, "with the Go toolchain", step.LeadingComments[1].Text)
+
+	// A comment after the last node is kept but not attached.
+	last := doc.Comments[len(doc.Comments)-1]
+	assert.Equal(t, "trailing document comment", last.Text)
+	assert.Nil(t, last.Node)
+}
+
+func TestComments_MultiDocument(t *testing.T) {
+	yaml := `# first
+kind: ConfigMap
+---
+# second
+kind: Task
+`
+	docs, err := ParseAllYAML("test.yaml", yaml)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+
+	require.Len(t, docs[0].Comments, 1)
+	assert.Equal(t, "first", docs[0].Comments[0].Text)
+	require.Len(t, docs[1].Comments, 1)
+	assert.Equal(t, "second", docs[1].Comments[0].Text)
+	assert.Same(t, docs[1].Root, docs[1].Comments[0].Node)
+}
+
+func TestComments_DoNotReplaceValues(t *testing.T) {
+	yaml := `steps:
+  - # first step
+    name: build
+`
+	doc, err := ParseYAML("test.yaml", yaml)
+	require.NoError(t, err)
+
+	steps := doc.Root.Get("steps").AsSequence()
+	require.Len(t, steps, 1)
+	require.True(t, steps[0].IsMapping())
+	assert.Equal(t, "build", steps[0].Get("name").AsScalar())
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 
 	tree_sitter_yaml "github.com/tree-sitter-grammars/tree-sitter-yaml/bindings/go"
 	tree_sitter "github.com/tree-sitter/go-tree-sitter"
@@ -44,7 +45,11 @@ func ParseAllYAML(filename, content string) ([]*Document, error) {
 	rootNode := tree.RootNode()
 	contentBytes := []byte(content)
 
-	return buildDocuments(rootNode, contentBytes, filename)
+	docs, err := buildDocuments(rootNode, contentBytes, filename)
+	for _, doc := range docs {
+		doc.Content = content
+	}
+	return docs, err
 }
 
 // buildDocuments extracts all YAML documents from the tree-sitter stream node.
@@ -56,7 +61,7 @@ func buildDocuments(rootNode *tree_sitter.Node, content []byte, filename string)
 	// For multi-doc files: stream has N document children.
 	if rootNode.Kind() != "stream" {
 		// Shouldn't happen, but handle gracefully.
-		doc, err := buildSingleDocument(rootNode, content, filename, 0)
+		doc, err := buildSingleDocument(rootNode, content, filename, 0, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -66,30 +71,42 @@ func buildDocuments(rootNode *tree_sitter.Node, content []byte, filename string)
 		return docs, nil
 	}
 
+	// Comments outside any document (e.g. a file header) belong to the next one.
+	var pending []*Comment
 	for i := uint(0); i < rootNode.ChildCount(); i++ {
 		child := rootNode.Child(i)
+		if child.Kind() == "comment" {
+			pending = append(pending, newComment(child, content))
+			continue
+		}
 		if child.Kind() != "document" {
 			continue
 		}
-		doc, err := buildSingleDocument(child, content, filename, int(i))
+		doc, err := buildSingleDocument(child, content, filename, int(i), pending)
 		if err != nil {
 			return nil, err
 		}
 		if doc != nil {
 			docs = append(docs, doc)
+			pending = nil
 		}
 	}
+	if len(pending) > 0 && len(docs) > 0 {
+		last := docs[len(docs)-1]
+		last.Comments = append(last.Comments, pending...)
+	}
 
 	return docs, nil
 }
 
 // buildSingleDocument builds a Document from a tree-sitter "document" node.
-func buildSingleDocument(docNode *tree_sitter.Node, content []byte, filename string, index int) (*Document, error) {
-	// Find the content node (skip "---" separator).
+// leading holds comments found before the document node itself.
+func buildSingleDocument(docNode *tree_sitter.Node, content []byte, filename string, index int, leading []*Comment) (*Document, error) {
+	// Find the content node (skip "---" separator and comments).
 	var contentNode *tree_sitter.Node
 	for j := uint(0); j < docNode.ChildCount(); j++ {
 		child := docNode.Child(j)
-		if child.Kind() != "---" {
+		if child.Kind() != "---" && child.Kind() != "comment" {
 			contentNode = child
 			break
 		}
@@ -103,7 +120,8 @@ func buildSingleDocument(docNode *tree_sitter.Node, content []byte, filename str
 		contentNode = docNode
 	}
 
-	root, err := buildAST(contentNode, content, "")
+	b := &builder{content: content, anchors: make(map[string]*Node)}
+	root, err := b.buildAST(contentNode, "")
 	if err != nil {
 		return nil, err
 	}
@@ -111,6 +129,9 @@ func buildSingleDocument(docNode *tree_sitter.Node, content []byte, filename str
 		return nil, nil
 	}
 
+	comments := append(leading, collectComments(docNode, content)...)
+	attachComments(root, comments)
+
 	// Extract common Tekton fields for quick access.
 	var apiVersion, kind string
 	if v := root.Get("apiVersion"); v != nil {
@@ -126,11 +147,20 @@ func buildSingleDocument(docNode *tree_sitter.Node, content []byte, filename str
 		APIVersion: apiVersion,
 		Kind:       kind,
 		Index:      index,
+		Anchors:    b.anchors,
+		Comments:   comments,
 	}, nil
 }
 
+// builder converts a tree-sitter tree into our AST for a single document.
+// Anchors are scoped to the document, so a fresh builder is used for each one.
+type builder struct {
+	content []byte
+	anchors map[string]*Node
+}
+
 // buildAST converts a tree-sitter node into our AST representation.
-func buildAST(tsNode *tree_sitter.Node, content []byte, key string) (*Node, error) {
+func (b *builder) buildAST(tsNode *tree_sitter.Node, key string) (*Node, error) {
 	r := nodeRange(tsNode)
 	kind := tsNode.Kind()
 
@@ -138,39 +168,48 @@ func buildAST(tsNode *tree_sitter.Node, content []byte, key string) (*Node, erro
 	case "stream":
 		// For backward compat when called directly, take first child.
 		if tsNode.ChildCount() > 0 {
-			return buildAST(tsNode.Child(0), content, key)
+			return b.buildAST(tsNode.Child(0), key)
 		}
 		return &Node{Key: key, Kind: NodeKindNull, Range: r}, nil
 
 	case "document":
-		// Skip "---" separator, recurse into content.
+		// Skip "---" separator and comments, recurse into content.
 		for i := uint(0); i < tsNode.ChildCount(); i++ {
 			child := tsNode.Child(i)
-			if child.Kind() != "---" {
-				return buildAST(child, content, key)
+			if child.Kind() != "---" && child.Kind() != "comment" {
+				return b.buildAST(child, key)
 			}
 		}
 		return &Node{Key: key, Kind: NodeKindNull, Range: r}, nil
 
-	case "block_node":
-		// Wrapper — recurse into first child.
-		if tsNode.ChildCount() > 0 {
-			return buildAST(tsNode.Child(0), content, key)
-		}
-		return &Node{Key: key, Kind: NodeKindNull, Range: r}, nil
+	case "block_node", "flow_node":
+		// Wrapper — may carry an anchor and/or tag before the actual content.
+		return b.buildProperties(tsNode, key)
+
+	case "alias":
+		return b.resolveAlias(tsNode, key), nil
 
 	case "block_mapping", "flow_mapping":
 		mapping := make(map[string]*Node)
+		var merges []*Node
 		for i := uint(0); i < tsNode.ChildCount(); i++ {
 			child := tsNode.Child(i)
 			if child.Kind() == "block_mapping_pair" || child.Kind() == "flow_pair" {
 				keyNode := child.ChildByFieldName("key")
 				valueNode := child.ChildByFieldName("value")
 				if keyNode != nil {
-					keyText := extractText(keyNode, content)
+					keyText := b.keyText(keyNode)
 					pairRange := nodeRange(child)
+					if keyText == mergeKey && valueNode != nil {
+						valueAST, err := b.buildAST(valueNode, keyText)
+						if err != nil {
+							return nil, err
+						}
+						merges = append(merges, mergeSources(valueAST)...)
+						continue
+					}
 					if valueNode != nil {
-						valueAST, err := buildAST(valueNode, content, keyText)
+						valueAST, err := b.buildAST(valueNode, keyText)
 						if err != nil {
 							return nil, err
 						}
@@ -188,23 +227,28 @@ func buildAST(tsNode *tree_sitter.Node, content []byte, key string) (*Node, erro
 				}
 			}
 		}
-		return &Node{Key: key, Kind: NodeKindMapping, MappingChildren: mapping, Range: r}, nil
+		applyMerges(mapping, merges)
+		return &Node{Key: key, Kind: NodeKindMapping, MappingChildren: mapping, Merges: merges, Range: r}, nil
 
 	case "block_sequence", "flow_sequence":
 		var items []*Node
 		for i := uint(0); i < tsNode.ChildCount(); i++ {
 			child := tsNode.Child(i)
 			if child.Kind() == "block_sequence_item" {
-				// Skip the '-' marker (child 0), take value (child 1).
-				if child.ChildCount() > 1 {
-					item, err := buildAST(child.Child(1), content, "")
+				// Skip the '-' marker and comments, take the value.
+				for j := uint(1); j < child.ChildCount(); j++ {
+					if child.Child(j).Kind() == "comment" {
+						continue
+					}
+					item, err := b.buildAST(child.Child(j), "")
 					if err != nil {
 						return nil, err
 					}
 					items = append(items, item)
+					break
 				}
 			} else if child.Kind() == "flow_node" {
-				item, err := buildAST(child, content, "")
+				item, err := b.buildAST(child, "")
 				if err != nil {
 					return nil, err
 				}
@@ -214,25 +258,146 @@ func buildAST(tsNode *tree_sitter.Node, content []byte, key string) (*Node, erro
 		return &Node{Key: key, Kind: NodeKindSequence, SequenceChildren: items, Range: r}, nil
 
 	case "plain_scalar", "single_quote_scalar", "double_quote_scalar", "block_scalar":
-		text := extractText(tsNode, content)
-		return &Node{Key: key, Kind: NodeKindScalar, ScalarValue: text, Range: r}, nil
-
-	case "flow_node":
-		if tsNode.ChildCount() > 0 {
-			return buildAST(tsNode.Child(0), content, key)
+		raw := extractText(tsNode, b.content)
+		parentIndent, trailing := 0, 0
+		if kind == "block_scalar" {
+			parentIndent = blockParentIndent(b.content, tsNode.StartByte())
+			trailing = blockTrailingLines(b.content, tsNode.EndByte())
 		}
-		return &Node{Key: key, Kind: NodeKindNull, Range: r}, nil
+		value, style, offsets := decodeScalar(kind, raw, r.Start, parentIndent, trailing)
+		return &Node{
+			Key:         key,
+			Kind:        NodeKindScalar,
+			ScalarValue: value,
+			Raw:         raw,
+			Style:       style,
+			Range:       r,
+			offsets:     offsets,
+		}, nil
 
 	default:
 		// Try to recurse or extract text.
 		if tsNode.ChildCount() > 0 {
-			return buildAST(tsNode.Child(0), content, key)
+			return b.buildAST(tsNode.Child(0), key)
 		}
-		text := extractText(tsNode, content)
+		text := extractText(tsNode, b.content)
 		if text == "" {
 			return &Node{Key: key, Kind: NodeKindNull, Range: r}, nil
 		}
-		return &Node{Key: key, Kind: NodeKindScalar, ScalarValue: text, Range: r}, nil
+		return &Node{Key: key, Kind: NodeKindScalar, ScalarValue: text, Raw: text, Range: r}, nil
+	}
+}
+
+// keyText returns the decoded text of a mapping key, so "name" and 'name'
+// both produce the key name.
+func (b *builder) keyText(keyNode *tree_sitter.Node) string {
+	key, err := b.buildAST(keyNode, "")
+	if err != nil || !key.IsScalar() {
+		return extractText(keyNode, b.content)
+	}
+	return key.ScalarValue
+}
+
+// buildProperties builds a block_node/flow_node, registering its anchor (&name)
+// if present and skipping any tag (!!str, !custom) in front of the content.
+func (b *builder) buildProperties(tsNode *tree_sitter.Node, key string) (*Node, error) {
+	var anchorName string
+	var anchorRange Range
+	var node *Node
+
+	for i := uint(0); i < tsNode.ChildCount(); i++ {
+		child := tsNode.Child(i)
+		switch child.Kind() {
+		case "anchor":
+			anchorRange = nodeRange(child)
+			anchorName = strings.TrimPrefix(extractText(child, b.content), "&")
+		case "tag", "comment":
+			continue
+		default:
+			if node != nil {
+				continue
+			}
+			built, err := b.buildAST(child, key)
+			if err != nil {
+				return nil, err
+			}
+			node = built
+		}
+	}
+
+	if node == nil {
+		// Only properties (e.g. "key: &anchor"), no content: an empty value.
+		node = &Node{Key: key, Kind: NodeKindNull, Range: nodeRange(tsNode)}
+	}
+
+	if anchorName != "" {
+		node.Anchor = anchorName
+		node.AnchorRange = anchorRange
+		b.anchors[anchorName] = node
+	}
+	return node, nil
+}
+
+// resolveAlias returns a node standing in for the anchored node referenced by an
+// alias (*name). The returned node shares the anchored node's children but is
+// positioned at the alias itself, with Origin pointing back to the anchor.
+// Unknown aliases resolve to a null node.
+func (b *builder) resolveAlias(tsNode *tree_sitter.Node, key string) *Node {
+	r := nodeRange(tsNode)
+	name := strings.TrimPrefix(extractText(tsNode, b.content), "*")
+
+	target, ok := b.anchors[name]
+	if !ok {
+		return &Node{Key: key, Kind: NodeKindNull, Alias: name, AliasRange: r, Range: r}
+	}
+
+	return &Node{
+		Key:              key,
+		Kind:             target.Kind,
+		ScalarValue:      target.ScalarValue,
+		Raw:              target.Raw,
+		Style:            target.Style,
+		offsets:          target.offsets,
+		MappingChildren:  target.MappingChildren,
+		SequenceChildren: target.SequenceChildren,
+		Merges:           target.Merges,
+		Alias:            name,
+		AliasRange:       r,
+		Origin:           target,
+		Range:            r,
+	}
+}
+
+// mergeKey is the YAML merge key ("<<: *defaults").
+const mergeKey = "<<"
+
+// mergeSources returns the alias nodes named by a merge key value, which is
+// either a single alias or a sequence of aliases.
+func mergeSources(value *Node) []*Node {
+	if value.IsSequence() {
+		var sources []*Node
+		for _, item := range value.SequenceChildren {
+			if item.IsMapping() {
+				sources = append(sources, item)
+			}
+		}
+		return sources
+	}
+	if value.IsMapping() {
+		return []*Node{value}
+	}
+	return nil
+}
+
+// applyMerges adds the entries of each merged mapping to mapping. Explicit keys
+// always win, and earlier merge sources take precedence over later ones.
+func applyMerges(mapping map[string]*Node, merges []*Node) {
+	for _, src := range merges {
+		for k, child := range src.MappingChildren {
+			if _, exists := mapping[k]; !exists {
+				mapping[k] = child
+			}
+		}
 	}
 }
 
@@ -77,6 +77,11 @@ metadata:
 	// Non-existent key returns nil
 	missing := doc.Root.Get("nonexistent")
 	assert.Nil(t, missing, "non-existent key should return nil")
+
+	// Lookups can be chained through missing keys.
+	assert.Nil(t, doc.Root.Get("spec").Get("tasks"))
+	assert.Empty(t, doc.Root.Get("spec").AsScalar())
+	assert.False(t, doc.Root.Get("spec").IsMapping())
 }
 
 func TestNode_Sequence(t *testing.T) {
@@ -266,3 +271,90 @@ spec:
 	assert.True(t, steps.IsSequence())
 	assert.Len(t, steps.AsSequence(), 1)
 }
+
+func TestParseYAML_AnchorsAndAliases(t *testing.T) {
+	yaml := `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: &name hello
+spec:
+  description: *name
+  stepTemplate: &defaults
+    image: alpine
+    workingDir: /workspace
+  steps:
+    - name: build
+      <<: *defaults
+      workingDir: /src
+`
+	doc, err := ParseYAML("test.yaml", yaml)
+	require.NoError(t, err)
+
+	// Anchored scalar keeps its value and records the anchor.
+	name := doc.Root.Get("metadata").Get("name")
+	require.NotNil(t, name)
+	assert.Equal(t, "hello", name.AsScalar())
+	assert.Equal(t, "name", name.Anchor)
+	assert.Equal(t, uint32(3), name.AnchorRange.Start.Line)
+	require.Contains(t, doc.Anchors, "name")
+
+	// Alias resolves to the anchored value and points back at it.
+	desc := doc.Root.Get("spec").Get("description")
+	require.NotNil(t, desc)
+	assert.Equal(t, "hello", desc.AsScalar())
+	assert.Equal(t, "name", desc.Alias)
+	assert.Same(t, name, desc.Origin)
+	assert.Equal(t, uint32(5), desc.AliasRange.Start.Line)
+
+	// Merge key entries are part of the mapping; explicit keys win.
+	step := doc.Root.Get("spec").Get("steps").AsSequence()[0]
+	assert.Nil(t, step.Get("<<"), "merge key should not appear as a field")
+	require.NotNil(t, step.Get("image"))
+	assert.Equal(t, "alpine", step.Get("image").AsScalar())
+	assert.Equal(t, "/src", step.Get("workingDir").AsScalar())
+	require.Len(t, step.Merges, 1)
+	assert.Equal(t, "defaults", step.Merges[0].Alias)
+}
+
+func TestParseYAML_MergeSequenceAndUnknownAlias(t *testing.T) {
+	yaml := `a: &a
+  x: 1
+b: &b
+  x: 2
+  y: 2
+c:
+  <<: [*a, *b]
+d: *missing
+`
+	doc, err := ParseYAML("test.yaml", yaml)
+	require.NoError(t, err)
+
+	c := doc.Root.Get("c")
+	require.NotNil(t, c)
+	assert.Equal(t, "1", c.Get("x").AsScalar(), "earlier merge source should take precedence")
+	assert.Equal(t, "2", c.Get("y").AsScalar())
+	assert.Len(t, c.Merges, 2)
+
+	d := doc.Root.Get("d")
+	require.NotNil(t, d)
+	assert.Equal(t, NodeKindNull, d.Kind)
+	assert.Equal(t, "missing", d.Alias)
+	assert.Nil(t, d.Origin)
+}
+
+func TestDocument_FindNodeAtPosition_MergeAlias(t *testing.T) {
+	yaml := `defaults: &defaults
+  image: alpine
+step:
+  <<: *defaults
+  name: build
+`
+	doc, err := ParseYAML("test.yaml", yaml)
+	require.NoError(t, err)
+
+	node := doc.FindNodeAtPosition(Position{Line: 3, Character: 8})
+	require.NotNil(t, node)
+	assert.Equal(t, "defaults", node.Alias)
+	require.NotNil(t, node.Origin)
+	assert.Equal(t, uint32(0), node.Origin.AnchorRange.Start.Line)
+}
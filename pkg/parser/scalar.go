@@ -0,0 +1,375 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ScalarStyle identifies how a scalar is written in the source.
+type ScalarStyle int
+
+const (
+	// ScalarStylePlain is an unquoted scalar.
+	ScalarStylePlain ScalarStyle = iota
+	// ScalarStyleSingleQuoted is a 'single quoted' scalar.
+	ScalarStyleSingleQuoted
+	// ScalarStyleDoubleQuoted is a "double quoted" scalar.
+	ScalarStyleDoubleQuoted
+	// ScalarStyleLiteral is a | block scalar.
+	ScalarStyleLiteral
+	// ScalarStyleFolded is a > block scalar.
+	ScalarStyleFolded
+)
+
+// segment maps a run of decoded bytes, starting at offset, to consecutive
+// source characters on a single line starting at pos. Synthetic segments map
+// every byte to pos.
+type segment struct {
+	offset    int
+	pos       Position
+	synthetic bool
+}
+
+// scalarDecoder accumulates a decoded value and its offset map.
+type scalarDecoder struct {
+	buf  strings.Builder
+	segs []segment
+	// next is where the next byte would be in the source if it continued the
+	// current segment; valid is false after escapes and line folds.
+	next  Position
+	valid bool
+}
+
+// literal appends source text found at pos.
+func (d *scalarDecoder) literal(s string, pos Position) {
+	if s == "" {
+		return
+	}
+	if !d.valid || d.next != pos {
+		d.segs = append(d.segs, segment{offset: d.buf.Len(), pos: pos})
+	}
+	d.buf.WriteString(s)
+	d.next = Position{Line: pos.Line, Character: pos.Character + uint32(len(s))}
+	d.valid = true
+}
+
+// synthetic appends text that does not appear verbatim in the source (escape
+// sequences, folded line breaks), attributed to pos.
+func (d *scalarDecoder) synthetic(s string, pos Position) {
+	if s == "" {
+		return
+	}
+	d.segs = append(d.segs, segment{offset: d.buf.Len(), pos: pos, synthetic: true})
+	d.buf.WriteString(s)
+	d.valid = false
+}
+
+// decodeScalar decodes the raw text of a scalar of the given tree-sitter kind
+// that starts at start. parentIndent is the indentation of the node owning a
+// block scalar, used with an explicit indentation indicator, and trailing the
+// number of empty lines following it in the source, kept by keep chomping.
+func decodeScalar(kind, raw string, start Position, parentIndent, trailing int) (string, ScalarStyle, []segment) {
+	d := &scalarDecoder{}
+	var style ScalarStyle
+
+	switch kind {
+	case "single_quote_scalar":
+		style = ScalarStyleSingleQuoted
+		decodeFlow(d, unquote(raw, '\''), start, 1, unescapeSingle)
+	case "double_quote_scalar":
+		style = ScalarStyleDoubleQuoted
+		decodeFlow(d, unquote(raw, '"'), start, 1, unescapeDouble)
+	case "block_scalar":
+		style = decodeBlock(d, raw, start, parentIndent, trailing)
+	default:
+		style = ScalarStylePlain
+		decodeFlow(d, raw, start, 0, nil)
+	}
+
+	return d.buf.String(), style, d.segs
+}
+
+// unquote strips the surrounding quote characters from a flow scalar.
+func unquote(raw string, quote byte) string {
+	if len(raw) > 0 && raw[0] == quote {
+		raw = raw[1:]
+	}
+	if len(raw) > 0 && raw[len(raw)-1] == quote {
+		raw = raw[:len(raw)-1]
+	}
+	return raw
+}
+
+// unescapeFunc decodes one line of a quoted scalar starting at pos.
+type unescapeFunc func(d *scalarDecoder, line string, pos Position)
+
+// decodeFlow decodes a plain or quoted scalar, folding line breaks into
+// spaces (or newlines for empty lines) as YAML flow scalars do.
+func decodeFlow(d *scalarDecoder, inner string, start Position, quoteLen uint32, unescape unescapeFunc) {
+	lines := strings.Split(inner, "\n")
+	last := len(lines) - 1
+	emptyLines := 0
+	escapedBreak := false
+	var prevEnd Position
+
+	for i, line := range lines {
+		pos := Position{Line: start.Line + uint32(i)}
+		if i == 0 {
+			pos.Character = start.Character + quoteLen
+		} else {
+			trimmed := strings.TrimLeft(line, " \t")
+			pos.Character = uint32(len(line) - len(trimmed))
+			line = trimmed
+		}
+		if i < last {
+			line = strings.TrimRight(line, " \t")
+		}
+
+		if i > 0 {
+			if line == "" && i < last {
+				emptyLines++
+				continue
+			}
+			if !escapedBreak {
+				if emptyLines > 0 {
+					d.synthetic(strings.Repeat("\n", emptyLines), prevEnd)
+				} else {
+					d.synthetic(" ", prevEnd)
+				}
+			}
+			emptyLines = 0
+		}
+
+		escapedBreak = false
+		if unescape != nil && i < last && endsWithEscape(line) {
+			// "\" at the end of a line joins it to the next without a space.
+			line = line[:len(line)-1]
+			escapedBreak = true
+		}
+
+		if unescape != nil {
+			unescape(d, line, pos)
+		} else {
+			d.literal(line, pos)
+		}
+		prevEnd = Position{Line: pos.Line, Character: pos.Character + uint32(len(line))}
+	}
+}
+
+// endsWithEscape reports whether line ends with an unescaped backslash.
+func endsWithEscape(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// unescapeSingle decodes a doubled single quote into one within a
+// single-quoted line.
+func unescapeSingle(d *scalarDecoder, line string, pos Position) {
+	for {
+		i := strings.Index(line, "''")
+		if i < 0 {
+			d.literal(line, pos)
+			return
+		}
+		d.literal(line[:i], pos)
+		escPos := Position{Line: pos.Line, Character: pos.Character + uint32(i)}
+		d.synthetic("'", escPos)
+		line = line[i+2:]
+		pos = Position{Line: pos.Line, Character: escPos.Character + 2}
+	}
+}
+
+// doubleEscapes maps single-character escapes in double-quoted scalars.
+var doubleEscapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n",
+	'v': "\v", 'f': "\f", 'r': "\r", 'e': "\x1b", ' ': " ", '"': "\"",
+	'/': "/", '\\': "\\", 'N': "\u0085", '_': "\u00a0", 'L': "\u2028",
+	'P': "\u2029",
+}
+
+// unescapeDouble decodes backslash escapes within a double-quoted line.
+func unescapeDouble(d *scalarDecoder, line string, pos Position) {
+	for {
+		i := strings.IndexByte(line, '\\')
+		if i < 0 || i == len(line)-1 {
+			d.literal(line, pos)
+			return
+		}
+		d.literal(line[:i], pos)
+		escPos := Position{Line: pos.Line, Character: pos.Character + uint32(i)}
+
+		width := 2
+		decoded, ok := doubleEscapes[line[i+1]]
+		if !ok {
+			digits := 0
+			switch line[i+1] {
+			case 'x':
+				digits = 2
+			case 'u':
+				digits = 4
+			case 'U':
+				digits = 8
+			}
+			if digits > 0 && i+2+digits <= len(line) {
+				if r, err := strconv.ParseUint(line[i+2:i+2+digits], 16, 32); err == nil && utf8.ValidRune(rune(r)) {
+					decoded = string(rune(r))
+					width = 2 + digits
+					ok = true
+				}
+			}
+		}
+		if !ok {
+			// Invalid escape: keep it verbatim.
+			decoded = line[i : i+2]
+		}
+
+		d.synthetic(decoded, escPos)
+		line = line[i+width:]
+		pos = Position{Line: pos.Line, Character: escPos.Character + uint32(width)}
+	}
+}
+
+// decodeBlock decodes a literal (|) or folded (>) block scalar, honouring its
+// chomping (-, +) and indentation indicators. extra is the number of empty
+// lines following raw in the source.
+func decodeBlock(d *scalarDecoder, raw string, start Position, parentIndent, extra int) ScalarStyle {
+	header, body, _ := strings.Cut(raw, "\n")
+	if i := strings.Index(header, "#"); i >= 0 {
+		header = header[:i]
+	}
+	header = strings.TrimSpace(header)
+
+	style := ScalarStyleLiteral
+	chomp := byte(0)
+	indent := 0
+	for i := 0; i < len(header); i++ {
+		switch c := header[i]; {
+		case c == '>':
+			style = ScalarStyleFolded
+		case c == '-' || c == '+':
+			chomp = c
+		case c >= '1' && c <= '9':
+			indent = parentIndent + int(c-'0')
+		}
+	}
+
+	// A final line break ends the last line rather than starting a new one.
+	lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	if body == "" {
+		lines = nil
+	}
+	if indent == 0 {
+		for _, l := range lines {
+			if strings.TrimSpace(l) != "" {
+				indent = len(l) - len(strings.TrimLeft(l, " "))
+				break
+			}
+		}
+	}
+
+	// Separate trailing empty lines, which only matter for chomping. The
+	// block_scalar node stops before those that are not at the end of the
+	// file.
+	end := len(lines)
+	for end > 0 && strings.TrimSpace(lines[end-1]) == "" {
+		end--
+	}
+	trailing := len(lines) - end + extra
+
+	emptyLines := 0
+	prevMore := false
+	first := true
+	var prevEnd Position
+	for i, l := range lines[:end] {
+		pos := Position{Line: start.Line + 1 + uint32(i), Character: uint32(indent)}
+		content := ""
+		if len(l) > indent {
+			content = l[indent:]
+		}
+		if strings.TrimSpace(content) == "" {
+			emptyLines++
+			continue
+		}
+
+		more := content[0] == ' ' || content[0] == '\t'
+		switch {
+		case first:
+			d.synthetic(strings.Repeat("\n", emptyLines), pos)
+		case style == ScalarStyleLiteral:
+			d.synthetic(strings.Repeat("\n", emptyLines+1), prevEnd)
+		case emptyLines == 0 && !more && !prevMore:
+			d.synthetic(" ", prevEnd)
+		case !more && !prevMore:
+			d.synthetic(strings.Repeat("\n", emptyLines), prevEnd)
+		default:
+			d.synthetic(strings.Repeat("\n", emptyLines+1), prevEnd)
+		}
+
+		d.literal(content, pos)
+		prevEnd = Position{Line: pos.Line, Character: pos.Character + uint32(len(content))}
+		prevMore = more
+		emptyLines = 0
+		first = false
+	}
+
+	// Clip keeps the final line break, keep (+) also keeps trailing empty
+	// lines and strip (-) drops them all.
+	if !first && chomp != '-' {
+		breaks := 1
+		if chomp == '+' {
+			breaks += trailing
+		}
+		d.synthetic(strings.Repeat("\n", breaks), prevEnd)
+	}
+
+	return style
+}
+
+// blockTrailingLines returns the number of empty lines following the block
+// scalar that ends at byte offset end, up to the next line with content,
+// which is indented at or below the node owning the scalar.
+func blockTrailingLines(content []byte, end uint) int {
+	i := int(end)
+	if i > 0 && content[i-1] != '\n' {
+		// Finish the scalar's last line.
+		for i < len(content) && content[i] != '\n' {
+			i++
+		}
+		i++
+	}
+	n := 0
+	for ; i < len(content); i++ {
+		switch content[i] {
+		case ' ', '\t', '\r':
+		case '\n':
+			n++
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// blockParentIndent returns the indentation of the node owning a block scalar
+// that starts at byte offset start: the column of the first character on its
+// line that is neither a space nor a sequence entry marker.
+func blockParentIndent(content []byte, start uint) int {
+	lineStart := int(start)
+	for lineStart > 0 && content[lineStart-1] != '\n' {
+		lineStart--
+	}
+	col := 0
+	for i := lineStart; i < int(start); i++ {
+		c := content[i]
+		if c == ' ' || (c == '-' && i+1 < len(content) && content[i+1] == ' ') {
+			col++
+			continue
+		}
+		break
+	}
+	return col
+}
@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScalar_DecodedValues(t *testing.T) {
+	yaml := `plain: foo
+double: "foo\tbar é"
+single: 'it''s'
+"quoted key": value
+folded-plain: first
+  second
+
+  third
+literal: |
+  line one
+    indented
+  line three
+folded: >-
+  a
+  b
+
+  c
+keep: |+
+  x
+
+strip: |-
+  y
+`
+	doc, err := ParseYAML("test.yaml", yaml)
+	require.NoError(t, err)
+
+	tests := []struct {
+		key   string
+		value string
+		raw   string
+		style ScalarStyle
+	}{
+		{"plain", "foo", "foo", ScalarStylePlain},
+		{"double", "foo\tbar é", `"foo\tbar é"`, ScalarStyleDoubleQuoted},
+		{"single", "it's", "'it''s'", ScalarStyleSingleQuoted},
+		{"quoted key", "value", "value", ScalarStylePlain},
+		{"folded-plain", "first second\nthird", "first\n  second\n\n  third", ScalarStylePlain},
+		{"literal", "line one\n  indented\nline three\n", "|\n  line one\n    indented\n  line three", ScalarStyleLiteral},
+		{"folded", "a b\nc", ">-\n  a\n  b\n\n  c", ScalarStyleFolded},
+		{"strip", "y", "|-\n  y\n", ScalarStyleLiteral},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			node := doc.Root.Get(tt.key)
+			require.NotNil(t, node)
+			assert.Equal(t, tt.value, node.AsScalar())
+			assert.Equal(t, tt.raw, node.Raw)
+			assert.Equal(t, tt.style, node.Style)
+		})
+	}
+
+	keep := doc.Root.Get("keep")
+	require.NotNil(t, keep)
+	assert.Equal(t, ScalarStyleLiteral, keep.Style)
+	assert.Equal(t, "x\n\n", keep.AsScalar())
+}
+
+func TestScalar_KeepChomping(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		path []string
+		want string
+	}{
+		{"end of file", "keep: |+\n  x\n\n", []string{"keep"}, "x\n\n"},
+		{"before a key", "keep: |+\n  x\n\n\nnext: y\n", []string{"keep"}, "x\n\n\n"},
+		{"nested", "a:\n  keep: |+\n    x\n\n    \nb: 1\n", []string{"a", "keep"}, "x\n\n\n"},
+		{"folded", "keep: >+\n  a\n  b\n\nnext: y\n", []string{"keep"}, "a b\n\n"},
+		{"no trailing lines", "keep: |+\n  x\nnext: y\n", []string{"keep"}, "x\n"},
+		{"clip", "clip: |\n  x\n\n\nnext: y\n", []string{"clip"}, "x\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := ParseYAML("test.yaml", tt.yaml)
+			require.NoError(t, err)
+			node := doc.Root
+			for _, key := range tt.path {
+				node = node.Get(key)
+			}
+			require.NotNil(t, node)
+			assert.Equal(t, tt.want, node.AsScalar())
+		})
+	}
+}
+
+func TestScalar_ValueRange(t *testing.T) {
+	yaml := `steps:
+  - name: "a\tb-$(params.x)"
+    script: |
+      echo start
+      echo $(params.revision)
+`
+	doc, err := ParseYAML("test.yaml", yaml)
+	require.NoError(t, err)
+	step := doc.Root.Get("steps").AsSequence()[0]
+
+	// Double-quoted: the escape shifts source columns by one.
+	name := step.Get("name")
+	require.NotNil(t, name)
+	assert.Equal(t, "a\tb-$(params.x)", name.AsScalar())
+	assert.Equal(t, Range{
+		Start: Position{Line: 1, Character: 16},
+		End:   Position{Line: 1, Character: 28},
+	}, name.ValueRange(4, 16))
+
+	// Literal block: offsets map onto the exact line and column.
+	script := step.Get("script")
+	require.NotNil(t, script)
+	i := len("echo start\necho ")
+	assert.Equal(t, Range{
+		Start: Position{Line: 4, Character: 11},
+		End:   Position{Line: 4, Character: 29},
+	}, script.ValueRange(i, i+len("$(params.revision)")))
+}
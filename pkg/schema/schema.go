@@ -0,0 +1,211 @@
+// Package schema describes the fields of Tekton and Tekton Triggers
+// resources as a tree, so a field can be looked up by its path from the
+// document root.
+package schema
+
+// Type is the type of a field's value.
+type Type int
+
+const (
+	// String is a scalar string.
+	String Type = iota
+	// Boolean is true or false.
+	Boolean
+	// Integer is a whole number.
+	Integer
+	// Object is a mapping with the known keys listed in Fields.
+	Object
+	// Array is a sequence of values described by Items.
+	Array
+	// Map is a mapping with arbitrary keys, e.g. labels.
+	Map
+	// Any is a value of any type, e.g. a param value.
+	Any
+)
+
+func (t Type) String() string {
+	switch t {
+	case Boolean:
+		return "boolean"
+	case Integer:
+		return "integer"
+	case Object:
+		return "object"
+	case Array:
+		return "array"
+	case Map:
+		return "map"
+	case Any:
+		return "any"
+	default:
+		return "string"
+	}
+}
+
+// Field describes a field of a resource.
+type Field struct {
+	Name        string
+	Type        Type
+	Description string
+	Required    bool
+	// Default is the value used when the field is not set, if any.
+	Default string
+	// Since is the API version that introduced the field, when it is later
+	// than the version of its resource; the root field holds the latter.
+	Since string
+	// Docs is the URL of the upstream documentation of the field, when more
+	// precise than that of its parents.
+	Docs string
+	// Deprecated, when set, says what to use instead of the field.
+	Deprecated string
+	// Fields are the fields of an Object.
+	Fields []*Field
+	// Items describes the items of an Array.
+	Items *Field
+}
+
+// Field returns the child field with the given name of an Object, or nil.
+// It is safe to call on a nil field.
+func (f *Field) Field(name string) *Field {
+	if f == nil || f.Type != Object {
+		return nil
+	}
+	for _, child := range f.Fields {
+		if child.Name == name {
+			return child
+		}
+	}
+	return nil
+}
+
+// At returns the field path leads to from f, or nil when the path leaves
+// the schema. Keys name the fields of objects; an empty key steps into the
+// items of an array.
+func (f *Field) At(path ...string) *Field {
+	trail := f.Trail(path...)
+	if len(trail) != len(path)+1 {
+		return nil
+	}
+	return trail[len(path)]
+}
+
+// Trail returns f and the fields path goes through from it, stopping where
+// the path leaves the schema.
+func (f *Field) Trail(path ...string) []*Field {
+	if f == nil {
+		return nil
+	}
+	trail := []*Field{f}
+	for _, key := range path {
+		if key == "" {
+			if f.Type != Array {
+				break
+			}
+			f = f.Items
+		} else if f = f.Field(key); f == nil {
+			break
+		}
+		trail = append(trail, f)
+	}
+	return trail
+}
+
+// For returns the root of the documents of the given kind, or nil for kinds
+// that are not described.
+func For(kind string) *Field {
+	return kinds[kind]
+}
+
+// kinds maps each resource kind to its root field.
+var kinds = map[string]*Field{}
+
+// resource registers the root field of a kind with the given spec fields,
+// introduced by the given API version and documented at docs.
+func resource(kind, since, docs, description string, spec ...*Field) {
+	kinds[kind] = object(kind, description,
+		str("apiVersion", "The API version of the resource, e.g. `tekton.dev/v1`.").required(),
+		str("kind", "The kind of the resource.").required(),
+		object("metadata", "Standard Kubernetes object metadata.", metadataFields(kind)...).required(),
+		object("spec", "The specification of the "+kind+".", spec...).required(),
+	).since(since).docs(docs)
+}
+
+// metadataFields are the metadata fields of a kind. Runs are usually created
+// with a generateName, so only the other kinds require a name.
+func metadataFields(kind string) []*Field {
+	name := str("name", "The name of the resource, unique within its namespace.")
+	if kind != "PipelineRun" && kind != "TaskRun" {
+		name.required()
+	}
+	return []*Field{
+		name,
+		str("generateName", "A prefix from which the server generates a unique name, used when name is not set."),
+		str("namespace", "The namespace of the resource."),
+		mapping("labels", "Key-value pairs to organize and select resources."),
+		mapping("annotations", "Key-value pairs holding non-identifying metadata."),
+	}
+}
+
+func str(name, description string) *Field {
+	return &Field{Name: name, Type: String, Description: description}
+}
+
+func boolean(name, description string) *Field {
+	return &Field{Name: name, Type: Boolean, Description: description}
+}
+
+func integer(name, description string) *Field {
+	return &Field{Name: name, Type: Integer, Description: description}
+}
+
+func mapping(name, description string) *Field {
+	return &Field{Name: name, Type: Map, Description: description}
+}
+
+func value(name, description string) *Field {
+	return &Field{Name: name, Type: Any, Description: description}
+}
+
+func object(name, description string, fields ...*Field) *Field {
+	return &Field{Name: name, Type: Object, Description: description, Fields: fields}
+}
+
+// objects is an array of objects with the given fields.
+func objects(name, description string, fields ...*Field) *Field {
+	return &Field{Name: name, Type: Array, Description: description, Items: &Field{Type: Object, Fields: fields}}
+}
+
+// strs is an array of strings.
+func strs(name, description string) *Field {
+	return &Field{Name: name, Type: Array, Description: description, Items: &Field{Type: String}}
+}
+
+// values is an array of values of any type.
+func values(name, description string) *Field {
+	return &Field{Name: name, Type: Array, Description: description, Items: &Field{Type: Any}}
+}
+
+func (f *Field) required() *Field {
+	f.Required = true
+	return f
+}
+
+func (f *Field) withDefault(v string) *Field {
+	f.Default = v
+	return f
+}
+
+func (f *Field) since(apiVersion string) *Field {
+	f.Since = apiVersion
+	return f
+}
+
+func (f *Field) docs(url string) *Field {
+	f.Docs = url
+	return f
+}
+
+func (f *Field) deprecated(instead string) *Field {
+	f.Deprecated = instead
+	return f
+}
@@ -0,0 +1,72 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAt(t *testing.T) {
+	tests := []struct {
+		kind string
+		path []string
+		want string
+		typ  Type
+	}{
+		{kind: "Pipeline", path: []string{"spec", "tasks"}, want: "tasks", typ: Array},
+		{kind: "Pipeline", path: []string{"spec", "tasks", "", "taskRef", "resolver"}, want: "resolver", typ: String},
+		{kind: "Pipeline", path: []string{"spec", "finally", "", "taskSpec", "steps", "", "image"}, want: "image", typ: String},
+		{kind: "PipelineRun", path: []string{"spec", "pipelineSpec", "tasks", "", "when", "", "operator"}, want: "operator", typ: String},
+		{kind: "TaskRun", path: []string{"spec", "podTemplate", "nodeSelector"}, want: "nodeSelector", typ: Map},
+		{kind: "Task", path: []string{"spec", "stepTemplate", "env", "", "valueFrom"}, want: "valueFrom", typ: Object},
+		{kind: "EventListener", path: []string{"spec", "triggers", "", "interceptors", "", "ref", "name"}, want: "name", typ: String},
+		{kind: "ClusterTask", path: []string{"metadata", "name"}, want: "name", typ: String},
+	}
+	for _, tt := range tests {
+		f := For(tt.kind).At(tt.path...)
+		require.NotNil(t, f, "%s %v", tt.kind, tt.path)
+		assert.Equal(t, tt.want, f.Name)
+		assert.Equal(t, tt.typ, f.Type, "%s %v", tt.kind, tt.path)
+	}
+}
+
+func TestAt_Unknown(t *testing.T) {
+	assert.Nil(t, For("ConfigMap"))
+	assert.Nil(t, For("ConfigMap").At("spec"), "safe on unknown kinds")
+	assert.Nil(t, For("Pipeline").At("spec", "unknown"))
+	assert.Nil(t, For("Pipeline").At("spec", "", "tasks"), "spec is not an array")
+	assert.Nil(t, For("Task").At("metadata", "labels", "app"), "maps have no known keys")
+}
+
+func TestRequired(t *testing.T) {
+	step := For("Task").At("spec", "steps", "")
+	require.NotNil(t, step)
+	assert.True(t, step.Field("image").Required)
+	assert.False(t, step.Field("script").Required)
+	assert.False(t, For("Task").At("spec", "stepTemplate", "image").Required, "the template's image is optional")
+
+	// Runs usually set a generateName instead of a name.
+	assert.True(t, For("Pipeline").At("metadata", "name").Required)
+	assert.False(t, For("PipelineRun").At("metadata", "name").Required)
+	assert.False(t, For("TaskRun").At("metadata", "name").Required)
+}
+
+func TestDeprecated(t *testing.T) {
+	assert.NotEmpty(t, For("PipelineRun").At("spec", "timeout").Deprecated)
+	assert.NotEmpty(t, For("Task").At("spec", "steps", "", "resources").Deprecated)
+	assert.Empty(t, For("PipelineRun").At("spec", "timeouts").Deprecated)
+}
+
+func TestTrail(t *testing.T) {
+	trail := For("Pipeline").Trail("spec", "tasks", "", "when")
+	require.Len(t, trail, 5)
+	assert.Equal(t, "Pipeline", trail[0].Name)
+	assert.Equal(t, "tekton.dev/v1beta1", trail[0].Since)
+	assert.Equal(t, Object, trail[3].Type, "the items of tasks")
+	assert.Contains(t, trail[4].Docs, "when-expressions")
+
+	assert.Len(t, For("Pipeline").Trail("spec", "unknown", "name"), 2, "stops where the path leaves the schema")
+	assert.Nil(t, For("ConfigMap").Trail("spec"))
+	assert.Equal(t, "stopAndFail", For("Task").At("spec", "steps", "", "onError").Default)
+}
@@ -0,0 +1,370 @@
+package schema
+
+// The fields of the tekton.dev resources, following the v1 API. The
+// descriptions are written by hand, summarizing the upstream API reference.
+func init() {
+	resource("Pipeline", v1beta1, docs+"pipelines/", "A collection of Tasks run in a defined order.", pipelineSpecFields()...)
+	resource("Task", v1beta1, docs+"tasks/", "A collection of steps run sequentially in a Pod.", taskSpecFields()...)
+	resource("ClusterTask", v1beta1, docs+"tasks/", "A cluster-scoped Task (deprecated).", taskSpecFields()...)
+	resource("PipelineRun", v1beta1, docs+"pipelineruns/", "An execution of a Pipeline.", pipelineRunSpecFields()...)
+	resource("TaskRun", v1beta1, docs+"taskruns/", "An execution of a Task.", taskRunSpecFields()...)
+	resource("StepAction", "tekton.dev/v1alpha1", docs+"stepactions/", "A reusable step referenced by Task steps.", stepActionSpecFields()...)
+}
+
+const (
+	// docs is the root of the Tekton Pipelines documentation.
+	docs = "https://tekton.dev/docs/pipelines/"
+
+	v1beta1 = "tekton.dev/v1beta1"
+	v1      = "tekton.dev/v1"
+)
+
+func pipelineSpecFields() []*Field {
+	return []*Field{
+		str("displayName", "A user-facing name of the Pipeline."),
+		str("description", "A user-facing description of the Pipeline."),
+		objects("params", "The params the Pipeline accepts, referenced as `$(params.<name>)`.", paramSpecFields()...),
+		objects("workspaces", "The workspaces the Pipeline needs, bound by PipelineRuns.", pipelineWorkspaceFields()...).docs(docs + "workspaces/"),
+		objects("tasks", "The tasks of the Pipeline, ordered by `runAfter` and their result references.", pipelineTaskFields()...).required(),
+		objects("finally", "Tasks run after all the other tasks, whether they succeeded or not.", pipelineTaskFields()...).docs(docs + "pipelines/#adding-finally-to-the-pipeline"),
+		objects("results", "Values the Pipeline emits, computed from task results.", pipelineResultFields()...),
+	}
+}
+
+func pipelineTaskFields() []*Field {
+	return []*Field{
+		str("name", "The name of the pipeline task, unique in the Pipeline.").required(),
+		str("displayName", "A user-facing name of the pipeline task."),
+		str("description", "A user-facing description of the pipeline task."),
+		object("taskRef", "A reference to the Task to run.", refFields(true)...),
+		object("taskSpec", "An inline Task specification.", embeddedTaskSpecFields()...),
+		object("pipelineRef", "A reference to a Pipeline to run as a child pipeline.", refFields(false)...).docs(docs + "pipelines-in-pipelines/"),
+		objects("params", "The values of the Task's params.", paramFields()...),
+		object("matrix", "Runs the task once per combination of the matrix params.",
+			objects("params", "The array params to fan out on.", paramFields()...),
+			objects("include", "Extra combinations of params.",
+				str("name", "The name of the combination."),
+				objects("params", "The params of the combination.", paramFields()...),
+			),
+		).docs(docs + "matrix/"),
+		objects("workspaces", "Binds the Pipeline's workspaces to the Task's workspaces.",
+			str("name", "The name of the Task's workspace.").required(),
+			str("workspace", "The name of the Pipeline's workspace to bind."),
+			str("subPath", "A directory of the workspace to bind instead of its root."),
+		).docs(docs + "workspaces/"),
+		strs("runAfter", "The pipeline tasks that must finish before this one starts."),
+		objects("when", "Conditions guarding the execution of the task.", whenFields()...).docs(docs + "pipelines/#guard-task-execution-using-when-expressions"),
+		integer("retries", "The number of times to retry the task when it fails.").withDefault("0"),
+		str("timeout", "The time the task may run, e.g. `1h30m`."),
+		str("onError", "What to do when the task fails: `stopAndFail` (default) or `continue`.").withDefault("stopAndFail"),
+	}
+}
+
+func refFields(task bool) []*Field {
+	fields := []*Field{
+		str("name", "The name of the referenced resource."),
+	}
+	if task {
+		fields = append(fields, str("kind", "The kind of the referenced resource: `Task` (default), `ClusterTask` or a custom task kind.").withDefault("Task"))
+	}
+	return append(fields,
+		str("apiVersion", "The API version of the referenced resource, for custom tasks."),
+		str("resolver", "The remote resolver to fetch the resource with: `git`, `bundles`, `hub`, `cluster`, `http`...").docs(docs+"resolution/"),
+		objects("params", "The params of the resolver.", paramFields()...),
+		str("bundle", "The OCI bundle holding the resource.").deprecated("Use the `bundles` resolver."),
+	)
+}
+
+func whenFields() []*Field {
+	return []*Field{
+		str("input", "The value to test, usually a param or result reference."),
+		str("operator", "How input is compared to values: `in` or `notin`."),
+		strs("values", "The values input is compared to."),
+		str("cel", "A CEL expression, instead of input, operator and values."),
+	}
+}
+
+func paramSpecFields() []*Field {
+	return []*Field{
+		str("name", "The name of the param.").required(),
+		str("type", "The type of the param: `string` (default), `array` or `object`.").withDefault("string"),
+		str("description", "A user-facing description of the param."),
+		value("default", "The value used when the param is not passed."),
+		mapping("properties", "The keys of an object param, each with a `type`."),
+		strs("enum", "The values the param is restricted to."),
+	}
+}
+
+func paramFields() []*Field {
+	return []*Field{
+		str("name", "The name of the param.").required(),
+		value("value", "The value of the param: a string, an array or an object.").required(),
+	}
+}
+
+func pipelineWorkspaceFields() []*Field {
+	return []*Field{
+		str("name", "The name of the workspace.").required(),
+		str("description", "A user-facing description of the workspace."),
+		boolean("optional", "Whether PipelineRuns may leave the workspace unbound.").withDefault("false"),
+	}
+}
+
+func pipelineResultFields() []*Field {
+	return []*Field{
+		str("name", "The name of the result.").required(),
+		str("type", "The type of the result: `string` (default), `array` or `object`."),
+		str("description", "A user-facing description of the result."),
+		value("value", "The value of the result, usually a task result reference.").required(),
+	}
+}
+
+func taskSpecFields() []*Field {
+	return append([]*Field{
+		str("displayName", "A user-facing name of the Task."),
+		str("description", "A user-facing description of the Task."),
+	}, embeddedTaskSpecFields()...)
+}
+
+func embeddedTaskSpecFields() []*Field {
+	return []*Field{
+		objects("params", "The params the Task accepts, referenced as `$(params.<name>)`.", paramSpecFields()...),
+		objects("workspaces", "The volumes the Task's steps need, bound by TaskRuns.",
+			str("name", "The name of the workspace.").required(),
+			str("description", "A user-facing description of the workspace."),
+			str("mountPath", "Where the workspace is mounted, `/workspace/<name>` by default.").withDefault("/workspace/<name>"),
+			boolean("readOnly", "Whether the workspace is mounted read-only.").withDefault("false"),
+			boolean("optional", "Whether TaskRuns may leave the workspace unbound.").withDefault("false"),
+		).docs(docs + "workspaces/"),
+		objects("results", "The values the Task emits, written to `$(results.<name>.path)`.", resultFields()...),
+		objects("steps", "The containers run in order in the Task's Pod.", stepFields()...).required(),
+		object("stepTemplate", "Default container fields applied to every step.", containerFields(false)...),
+		objects("sidecars", "Containers run alongside the steps.", sidecarFields()...).docs(docs + "tasks/#specifying-sidecars"),
+		objects("volumes", "Kubernetes volumes available to steps and sidecars.", volumeFields()...),
+	}
+}
+
+func resultFields() []*Field {
+	return []*Field{
+		str("name", "The name of the result.").required(),
+		str("type", "The type of the result: `string` (default), `array` or `object`."),
+		str("description", "A user-facing description of the result."),
+		mapping("properties", "The keys of an object result, each with a `type`."),
+		value("value", "The value of the result, from a step result."),
+	}
+}
+
+func stepFields() []*Field {
+	fields := []*Field{
+		str("name", "The name of the step, unique in the Task."),
+	}
+	fields = append(fields, containerFields(true)...)
+	return append(fields,
+		str("script", "A script run in the container instead of command and args."),
+		str("timeout", "The time the step may run, e.g. `5m`."),
+		objects("workspaces", "The workspaces the step uses, when not all of them.",
+			str("name", "The name of the Task workspace.").required(),
+			str("mountPath", "Where the workspace is mounted in the step."),
+		),
+		str("onError", "What to do when the step fails: `stopAndFail` (default) or `continue`.").withDefault("stopAndFail"),
+		object("stdoutConfig", "Where the step's standard output is written.", str("path", "The file to write to.")),
+		object("stderrConfig", "Where the step's standard error is written.", str("path", "The file to write to.")),
+		objects("results", "The values the step emits, written to `$(step.results.<name>.path)`.", resultFields()...),
+		object("ref", "A reference to the StepAction to run.",
+			str("name", "The name of the StepAction."),
+			str("resolver", "The remote resolver to fetch the StepAction with."),
+			objects("params", "The params of the resolver.", paramFields()...),
+		),
+		objects("params", "The values of the StepAction's params.", paramFields()...),
+		objects("when", "Conditions guarding the execution of the step.", whenFields()...),
+	)
+}
+
+// containerFields are the fields steps share with Kubernetes containers.
+// image is required for steps but not for the step template.
+func containerFields(step bool) []*Field {
+	image := str("image", "The container image to run.")
+	if step {
+		image.required()
+	}
+	return []*Field{
+		image,
+		strs("command", "The entrypoint, overriding the image's."),
+		strs("args", "The arguments of the entrypoint."),
+		str("workingDir", "The working directory of the container."),
+		objects("env", "Environment variables of the container.", envFields()...),
+		objects("envFrom", "Sources of environment variables.",
+			str("prefix", "A prefix added to every variable name."),
+			object("configMapRef", "A ConfigMap to read variables from.", str("name", "The name of the ConfigMap."), boolean("optional", "Whether the ConfigMap may be missing.")),
+			object("secretRef", "A Secret to read variables from.", str("name", "The name of the Secret."), boolean("optional", "Whether the Secret may be missing.")),
+		),
+		object("computeResources", "The compute resources of the container.",
+			mapping("limits", "The maximum resources, e.g. `cpu: 500m`."),
+			mapping("requests", "The minimum resources, e.g. `memory: 1Gi`."),
+		).since(v1),
+		value("resources", "The compute resources of the container.").deprecated("Use `computeResources`."),
+		objects("volumeMounts", "Volumes mounted in the container.",
+			str("name", "The name of the volume.").required(),
+			str("mountPath", "Where the volume is mounted.").required(),
+			str("subPath", "A path in the volume to mount instead of its root."),
+			boolean("readOnly", "Whether the volume is mounted read-only."),
+		),
+		objects("volumeDevices", "Block devices used by the container.",
+			str("name", "The name of the volume.").required(),
+			str("devicePath", "Where the device is mapped.").required(),
+		),
+		str("imagePullPolicy", "When to pull the image: `Always`, `IfNotPresent` or `Never`."),
+		object("securityContext", "The security options of the container.",
+			integer("runAsUser", "The UID the container runs as."),
+			integer("runAsGroup", "The GID the container runs as."),
+			boolean("runAsNonRoot", "Whether the container must run as a non-root user."),
+			boolean("privileged", "Whether the container runs in privileged mode."),
+			boolean("allowPrivilegeEscalation", "Whether a process can gain more privileges than its parent."),
+			boolean("readOnlyRootFilesystem", "Whether the root filesystem is read-only."),
+			object("capabilities", "Capabilities added or dropped.", strs("add", "Capabilities to add."), strs("drop", "Capabilities to drop.")),
+		),
+	}
+}
+
+func envFields() []*Field {
+	return []*Field{
+		str("name", "The name of the variable.").required(),
+		str("value", "The value of the variable."),
+		object("valueFrom", "The source of the variable's value.",
+			object("secretKeyRef", "A key of a Secret.", str("name", "The name of the Secret."), str("key", "The key to read.")),
+			object("configMapKeyRef", "A key of a ConfigMap.", str("name", "The name of the ConfigMap."), str("key", "The key to read.")),
+			object("fieldRef", "A field of the Pod.", str("fieldPath", "The path of the field, e.g. `metadata.name`.")),
+		),
+	}
+}
+
+func sidecarFields() []*Field {
+	fields := []*Field{
+		str("name", "The name of the sidecar."),
+	}
+	fields = append(fields, containerFields(true)...)
+	return append(fields,
+		str("script", "A script run in the container instead of command and args."),
+		objects("ports", "The ports the sidecar exposes.",
+			integer("containerPort", "The port number.").required(),
+			str("name", "The name of the port."),
+			str("protocol", "The protocol: `TCP` (default), `UDP` or `SCTP`.").withDefault("TCP"),
+		),
+		value("readinessProbe", "When the sidecar is ready; steps start once every sidecar is."),
+		value("livenessProbe", "When the sidecar must be restarted."),
+		objects("workspaces", "The workspaces the sidecar uses.",
+			str("name", "The name of the Task workspace.").required(),
+			str("mountPath", "Where the workspace is mounted in the sidecar."),
+		),
+	)
+}
+
+func volumeFields() []*Field {
+	return []*Field{
+		str("name", "The name of the volume, used by volumeMounts.").required(),
+		object("emptyDir", "An empty directory sharing the Pod's lifetime.", str("medium", "`Memory` for a tmpfs."), str("sizeLimit", "The maximum size.")),
+		object("configMap", "A volume populated by a ConfigMap.", str("name", "The name of the ConfigMap."), values("items", "The keys to project.")),
+		object("secret", "A volume populated by a Secret.", str("secretName", "The name of the Secret."), values("items", "The keys to project.")),
+		object("persistentVolumeClaim", "A PersistentVolumeClaim.", str("claimName", "The name of the claim.").required(), boolean("readOnly", "Whether the volume is read-only.")),
+		object("hostPath", "A directory of the node.", str("path", "The path on the node.").required(), str("type", "The type of the path.")),
+		value("projected", "Several volume sources projected in one directory."),
+		value("csi", "A volume provided by a CSI driver."),
+	}
+}
+
+func workspaceBindingFields() []*Field {
+	return []*Field{
+		str("name", "The name of the workspace to bind.").required(),
+		str("subPath", "A directory of the volume to bind instead of its root."),
+		object("emptyDir", "An empty directory sharing the run's lifetime.", str("medium", "`Memory` for a tmpfs."), str("sizeLimit", "The maximum size.")),
+		object("persistentVolumeClaim", "An existing PersistentVolumeClaim.", str("claimName", "The name of the claim.").required(), boolean("readOnly", "Whether the volume is read-only.")),
+		value("volumeClaimTemplate", "A PersistentVolumeClaim created for the run and deleted with it."),
+		object("configMap", "A ConfigMap, mounted read-only.", str("name", "The name of the ConfigMap."), values("items", "The keys to project.")),
+		object("secret", "A Secret, mounted read-only.", str("secretName", "The name of the Secret."), values("items", "The keys to project.")),
+		value("projected", "Several sources projected in one directory."),
+		value("csi", "A volume provided by a CSI driver."),
+	}
+}
+
+func podTemplate() *Field {
+	return object("podTemplate", "Pod fields applied to the Pods of the run.",
+		mapping("nodeSelector", "Labels the node must have."),
+		values("tolerations", "Taints of nodes the Pods tolerate."),
+		value("affinity", "The scheduling constraints of the Pods."),
+		value("securityContext", "The security options of the Pods."),
+		values("volumes", "Extra volumes of the Pods."),
+		str("runtimeClassName", "The RuntimeClass of the Pods."),
+		boolean("automountServiceAccountToken", "Whether the service account token is mounted."),
+		str("dnsPolicy", "The DNS policy of the Pods."),
+		boolean("hostNetwork", "Whether the Pods use the node's network."),
+		str("priorityClassName", "The PriorityClass of the Pods."),
+		str("schedulerName", "The scheduler of the Pods."),
+		values("imagePullSecrets", "Secrets used to pull images."),
+		mapping("env", "Environment variables set in every container."),
+	)
+}
+
+func pipelineRunSpecFields() []*Field {
+	return []*Field{
+		object("pipelineRef", "A reference to the Pipeline to run.", refFields(false)...),
+		object("pipelineSpec", "An inline Pipeline specification.", pipelineSpecFields()...),
+		objects("params", "The values of the Pipeline's params.", paramFields()...),
+		str("status", "Set to `Cancelled`, `CancelledRunFinally`, `StoppedRunFinally` or `PipelineRunPending` to control the run."),
+		object("timeouts", "How long the run may take.",
+			str("pipeline", "The time the whole run may take, e.g. `1h`.").withDefault("1h"),
+			str("tasks", "The time the tasks may take."),
+			str("finally", "The time the finally tasks may take."),
+		),
+		str("timeout", "The time the whole run may take.").deprecated("Use `timeouts.pipeline`."),
+		object("taskRunTemplate", "Defaults of the TaskRuns of the run.",
+			str("serviceAccountName", "The ServiceAccount the TaskRuns run as.").withDefault("default"),
+			podTemplate(),
+		).since(v1),
+		str("serviceAccountName", "The ServiceAccount the TaskRuns run as.").deprecated("Use `taskRunTemplate.serviceAccountName`."),
+		objects("workspaces", "Binds volumes to the Pipeline's workspaces.", workspaceBindingFields()...).docs(docs + "workspaces/"),
+		objects("taskRunSpecs", "Overrides for the TaskRuns of given pipeline tasks.",
+			str("pipelineTaskName", "The pipeline task the overrides apply to.").required(),
+			str("serviceAccountName", "The ServiceAccount the TaskRun runs as."),
+			podTemplate(),
+			values("stepSpecs", "Compute resources of given steps."),
+			values("sidecarSpecs", "Compute resources of given sidecars."),
+			object("metadata", "Labels and annotations of the TaskRun.", mapping("labels", "Extra labels."), mapping("annotations", "Extra annotations.")),
+			value("computeResources", "Compute resources of the whole TaskRun."),
+		),
+	}
+}
+
+func taskRunSpecFields() []*Field {
+	return []*Field{
+		object("taskRef", "A reference to the Task to run.", refFields(true)...),
+		object("taskSpec", "An inline Task specification.", embeddedTaskSpecFields()...),
+		objects("params", "The values of the Task's params.", paramFields()...),
+		str("serviceAccountName", "The ServiceAccount the TaskRun runs as.").withDefault("default"),
+		str("status", "Set to `TaskRunCancelled` to cancel the run."),
+		str("statusMessage", "A message explaining the status."),
+		integer("retries", "The number of times to retry the run when it fails.").withDefault("0"),
+		str("timeout", "The time the run may take, e.g. `30m`.").withDefault("1h"),
+		podTemplate(),
+		objects("workspaces", "Binds volumes to the Task's workspaces.", workspaceBindingFields()...).docs(docs + "workspaces/"),
+		values("stepSpecs", "Compute resources of given steps."),
+		values("sidecarSpecs", "Compute resources of given sidecars."),
+		value("computeResources", "Compute resources of the whole TaskRun."),
+		object("debug", "Debugging options.", strs("breakpoints", "Where to pause, e.g. `onFailure`.")),
+	}
+}
+
+func stepActionSpecFields() []*Field {
+	return []*Field{
+		str("description", "A user-facing description of the StepAction."),
+		str("image", "The container image to run.").required(),
+		strs("command", "The entrypoint, overriding the image's."),
+		strs("args", "The arguments of the entrypoint."),
+		objects("env", "Environment variables of the container.", envFields()...),
+		str("script", "A script run in the container instead of command and args."),
+		str("workingDir", "The working directory of the container."),
+		objects("params", "The params the StepAction accepts.", paramSpecFields()...),
+		objects("results", "The values the StepAction emits, written to `$(step.results.<name>.path)`.", resultFields()...),
+		value("securityContext", "The security options of the container."),
+		values("volumeMounts", "Volumes mounted in the container."),
+	}
+}
@@ -0,0 +1,106 @@
+package schema
+
+// The fields of the triggers.tekton.dev resources, following the v1beta1
+// API.
+func init() {
+	resource("TriggerTemplate", triggersV1alpha1, triggersDocs+"triggertemplates/", "Resources created when a trigger fires.",
+		objects("params", "The params the template accepts, referenced as `$(tt.params.<name>)`.",
+			str("name", "The name of the param.").required(),
+			str("description", "A user-facing description of the param."),
+			str("default", "The value used when the param is not passed."),
+		),
+		values("resourcetemplates", "The resources to create, usually PipelineRuns.").required(),
+	)
+	resource("TriggerBinding", triggersV1alpha1, triggersDocs+"triggerbindings/", "Params extracted from an event.", bindingParams())
+	resource("ClusterTriggerBinding", triggersV1alpha1, triggersDocs+"triggerbindings/", "A cluster-scoped TriggerBinding.", bindingParams())
+	resource("Trigger", triggersV1alpha1, triggersDocs+"triggers/", "Bindings, interceptors and a template run for matching events.", triggerFields()...)
+	resource("EventListener", triggersV1alpha1, triggersDocs+"eventlisteners/", "A service receiving events and running triggers.",
+		str("serviceAccountName", "The ServiceAccount the triggers create resources as."),
+		objects("triggers", "The triggers run for each event.", triggerFields()...),
+		objects("triggerGroups", "Triggers sharing interceptors.",
+			str("name", "The name of the group."),
+			objects("interceptors", "The interceptors run before the group's triggers.", interceptorFields()...),
+			object("triggerSelector", "The triggers of the group.",
+				strs("namespaceSelector", "The namespaces of the triggers."),
+				value("labelSelector", "The labels of the triggers."),
+			),
+		),
+		object("namespaceSelector", "The namespaces to serve Triggers from.", strs("matchNames", "The names of the namespaces, `*` for all.")),
+		value("labelSelector", "The labels of the Triggers to serve."),
+		object("resources", "The resources running the EventListener.",
+			object("kubernetesResource", "A Deployment and a Service.",
+				integer("replicas", "The number of replicas."),
+				str("serviceType", "The type of the Service, e.g. `ClusterIP` or `LoadBalancer`."),
+				integer("servicePort", "The port of the Service."),
+				value("spec", "Pod template fields."),
+			),
+			value("customResource", "A custom resource, e.g. a Knative Service."),
+		),
+		str("cloudEventURI", "Where to send CloudEvents about the triggers."),
+	)
+	resource("Interceptor", triggersV1alpha1, triggersDocs+"interceptors/", "A service processing events before triggers run.", interceptorSpec()...)
+	resource("ClusterInterceptor", triggersV1alpha1, triggersDocs+"interceptors/", "A cluster-scoped Interceptor.", interceptorSpec()...)
+}
+
+const (
+	// triggersDocs is the root of the Tekton Triggers documentation.
+	triggersDocs = "https://tekton.dev/docs/triggers/"
+
+	triggersV1alpha1 = "triggers.tekton.dev/v1alpha1"
+)
+
+func bindingParams() *Field {
+	return objects("params", "The params extracted, e.g. `$(body.head_commit.id)`.",
+		str("name", "The name of the param.").required(),
+		str("value", "The value, usually a `$(body...)` or `$(header...)` expression.").required(),
+	)
+}
+
+func triggerFields() []*Field {
+	return []*Field{
+		str("name", "The name of the trigger."),
+		objects("bindings", "The bindings extracting the template's params.",
+			str("ref", "The name of a TriggerBinding."),
+			str("kind", "`TriggerBinding` (default) or `ClusterTriggerBinding`.").withDefault("TriggerBinding"),
+			str("name", "The name of an inline param."),
+			str("value", "The value of an inline param."),
+		),
+		object("template", "The TriggerTemplate run.",
+			str("ref", "The name of a TriggerTemplate."),
+			value("spec", "An inline TriggerTemplate specification."),
+		),
+		objects("interceptors", "The interceptors filtering and transforming events.", interceptorFields()...),
+		str("serviceAccountName", "The ServiceAccount the trigger creates resources as."),
+		str("triggerRef", "The name of a Trigger to run, instead of an inline trigger."),
+	}
+}
+
+func interceptorFields() []*Field {
+	return []*Field{
+		str("name", "The name of the interceptor."),
+		object("ref", "The interceptor to run.",
+			str("name", "The name of the interceptor, e.g. `github` or `cel`."),
+			str("kind", "`ClusterInterceptor` (default) or `NamespacedInterceptor`.").withDefault("ClusterInterceptor"),
+			str("apiVersion", "The API version of the interceptor."),
+		),
+		objects("params", "The params of the interceptor.",
+			str("name", "The name of the param.").required(),
+			value("value", "The value of the param.").required(),
+		),
+	}
+}
+
+func interceptorSpec() []*Field {
+	return []*Field{
+		object("clientConfig", "How to reach the interceptor.",
+			str("url", "The URL of the interceptor."),
+			object("service", "The Service of the interceptor.",
+				str("name", "The name of the Service."),
+				str("namespace", "The namespace of the Service."),
+				str("path", "The URL path."),
+				integer("port", "The port."),
+			),
+			str("caBundle", "The CA certificates of the interceptor."),
+		),
+	}
+}
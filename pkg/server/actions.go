@@ -5,6 +5,7 @@ import (
 	protocol "github.com/tliron/glsp/protocol_3_16"
 
 	"github.com/vdemeester/tekton-lsp-go/pkg/actions"
+	"github.com/vdemeester/tekton-lsp-go/pkg/config"
 	"github.com/vdemeester/tekton-lsp-go/pkg/validator"
 )
 
@@ -14,10 +15,14 @@ func (s *Server) textDocumentCodeAction(context *glsp.Context, params *protocol.
 	if !ok {
 		return nil, nil
 	}
+	if !s.enabled(params.TextDocument.URI, config.FeatureCodeActions) {
+		return nil, nil
+	}
 
+	opts := s.validatorOptions(params.TextDocument.URI)
 	var allDiags []validator.Diagnostic
 	for _, doc := range docs {
-		allDiags = append(allDiags, validator.Validate(doc)...)
+		allDiags = append(allDiags, validator.ValidateWithOptions(doc, opts)...)
 	}
 	codeActions := actions.CodeActions(params.TextDocument.URI, allDiags)
 
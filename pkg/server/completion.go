@@ -1,10 +1,14 @@
 package server
 
 import (
+	"encoding/json"
+	"sync"
+
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 
 	"github.com/vdemeester/tekton-lsp-go/pkg/completion"
+	"github.com/vdemeester/tekton-lsp-go/pkg/config"
 	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
 )
 
@@ -15,22 +19,32 @@ func (s *Server) textDocumentCompletion(context *glsp.Context, params *protocol.
 
 // handleCompletion returns completion items for a position in a document.
 func (s *Server) handleCompletion(uri string, pos protocol.Position) any {
-	docs, ok := s.cache.GetAllParsed(uri)
+	entry, ok := s.cache.Get(uri)
 	if !ok {
 		return nil
 	}
+	if !s.enabled(uri, config.FeatureCompletion) {
+		return nil
+	}
 
 	parserPos := parser.Position{
 		Line:      pos.Line,
 		Character: pos.Character,
 	}
 
-	// Try each document — the position will only match one.
+	// An empty document has nothing to parse: offer resource skeletons.
 	var items []completion.CompletionItem
-	for _, doc := range docs {
-		if result := completion.Complete(doc, parserPos); len(result) > 0 {
-			items = result
-			break
+	if !isProjectFile(uri) {
+		items = completion.Skeletons(entry.Content, parserPos)
+	}
+	if docs, ok := s.cache.GetAllParsed(uri); ok && len(items) == 0 {
+		// Try each document — the position will only match one.
+		opts := s.completionOptions(uri)
+		for _, doc := range docs {
+			if result := completion.CompleteWithOptions(doc, parserPos, opts); len(result) > 0 {
+				items = result
+				break
+			}
 		}
 	}
 
@@ -38,21 +52,120 @@ func (s *Server) handleCompletion(uri string, pos protocol.Position) any {
 		return nil
 	}
 
+	id := s.resolvable.store(items)
 	result := make([]protocol.CompletionItem, len(items))
 	for i, item := range items {
 		detail := item.Detail
 		result[i] = protocol.CompletionItem{
 			Label:  item.Label,
-			Kind:   completionItemKind(item.Kind),
+			Kind:   completionItemKind(item),
 			Detail: &detail,
 		}
+		if item.Lazy() {
+			// Computed by completionItem/resolve when the item is selected.
+			result[i].Data = resolveData{ID: id, Index: i}
+		} else if item.Documentation != "" {
+			result[i].Documentation = markdown(item.Documentation)
+		}
+		if item.SortText != "" {
+			result[i].SortText = &item.SortText
+		}
+		if item.FilterText != "" {
+			result[i].FilterText = &item.FilterText
+		}
+		if item.Deprecated {
+			result[i].Tags = []protocol.CompletionItemTag{protocol.CompletionItemTagDeprecated}
+		}
+		if item.Snippet {
+			format := protocol.InsertTextFormatSnippet
+			result[i].InsertTextFormat = &format
+		}
+		if item.Edit != nil {
+			result[i].TextEdit = protocol.TextEdit{
+				Range:   protocolRange(item.Edit.Range),
+				NewText: item.Edit.NewText,
+			}
+		}
 	}
 	return result
 }
 
-func completionItemKind(ft completion.FieldType) *protocol.CompletionItemKind {
+// completionItemResolve handles the completionItem/resolve request, adding
+// the documentation left out of the items of the last completion.
+func (s *Server) completionItemResolve(context *glsp.Context, params *protocol.CompletionItem) (*protocol.CompletionItem, error) {
+	var data resolveData
+	if raw, err := json.Marshal(params.Data); err != nil || json.Unmarshal(raw, &data) != nil {
+		return params, nil
+	}
+	if item, ok := s.resolvable.get(data); ok {
+		if doc := item.Describe(); doc != "" {
+			params.Documentation = markdown(doc)
+		}
+	}
+	return params, nil
+}
+
+// resolveData identifies an item of a completion for completionItem/resolve.
+type resolveData struct {
+	ID    int `json:"id"`
+	Index int `json:"index"`
+}
+
+// resolvable holds the items of the last completion, whose documentation
+// is computed when the client resolves them.
+type resolvable struct {
+	mu    sync.Mutex
+	id    int
+	items []completion.CompletionItem
+}
+
+// store replaces the items held and returns the ID of the completion.
+func (r *resolvable) store(items []completion.CompletionItem) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.id++
+	r.items = items
+	return r.id
+}
+
+// get returns the item data identifies, if it is from the last completion.
+func (r *resolvable) get(data resolveData) (completion.CompletionItem, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if data.ID != r.id || data.Index < 0 || data.Index >= len(r.items) {
+		return completion.CompletionItem{}, false
+	}
+	return r.items[data.Index], true
+}
+
+func markdown(value string) protocol.MarkupContent {
+	return protocol.MarkupContent{Kind: protocol.MarkupKindMarkdown, Value: value}
+}
+
+func protocolRange(r parser.Range) protocol.Range {
+	return protocol.Range{
+		Start: protocol.Position{Line: r.Start.Line, Character: r.Start.Character},
+		End:   protocol.Position{Line: r.End.Line, Character: r.End.Character},
+	}
+}
+
+func completionItemKind(item completion.CompletionItem) *protocol.CompletionItemKind {
 	var kind protocol.CompletionItemKind
-	switch ft {
+	switch item.Category {
+	case completion.CategoryReference:
+		kind = protocol.CompletionItemKindReference
+		return &kind
+	case completion.CategoryVariable:
+		kind = protocol.CompletionItemKindVariable
+		return &kind
+	case completion.CategorySnippet:
+		kind = protocol.CompletionItemKindSnippet
+		return &kind
+	case completion.CategoryValue:
+		kind = protocol.CompletionItemKindEnumMember
+		return &kind
+	}
+	switch item.Kind {
 	case completion.FieldTypeString:
 		kind = protocol.CompletionItemKindField
 	case completion.FieldTypeArray:
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -40,3 +41,125 @@ func TestServer_Completion_MissingDocument(t *testing.T) {
 	result := s.handleCompletion("file:///nonexistent.yaml", protocol.Position{Line: 0, Character: 0})
 	assert.Nil(t, result, "missing document should return nil")
 }
+
+func TestServer_Completion_RefName(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	s.roots = []string{"file:///a", "file:///b"}
+	s.cache.Load("file:///a/build.yaml", buildTaskYAML)
+	s.cache.Load("file:///b/test.yaml", "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: test\n")
+	s.cache.Open("file:///a/pipeline.yaml", "yaml", 1, buildPipelineYAML)
+
+	pos := protocol.Position{Line: 8, Character: 15}
+	items, ok := s.handleCompletion("file:///a/pipeline.yaml", pos).([]protocol.CompletionItem)
+	require.True(t, ok)
+	require.Len(t, items, 1, "only Tasks of the same workspace folder")
+	assert.Equal(t, "build", items[0].Label)
+	assert.Equal(t, protocol.CompletionItemKindReference, *items[0].Kind)
+	assert.Nil(t, items[0].Documentation, "documentation is resolved lazily")
+	raw, err := json.Marshal(items[0])
+	require.NoError(t, err)
+	var sent protocol.CompletionItem
+	require.NoError(t, json.Unmarshal(raw, &sent))
+	resolved, err := s.completionItemResolve(nil, &sent)
+	require.NoError(t, err)
+	require.IsType(t, protocol.MarkupContent{}, resolved.Documentation)
+	assert.Contains(t, resolved.Documentation.(protocol.MarkupContent).Value, "build.yaml")
+	edit, ok := items[0].TextEdit.(protocol.TextEdit)
+	require.True(t, ok)
+	assert.Equal(t, "build", edit.NewText)
+	assert.Equal(t, protocol.Position{Line: 8, Character: 14}, edit.Range.Start)
+
+	s.settings.CrossFolderLookup = true
+	items, _ = s.handleCompletion("file:///a/pipeline.yaml", pos).([]protocol.CompletionItem)
+	assert.Len(t, items, 2)
+}
+
+func TestServer_Completion_Variable(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	s.cache.Insert("file:///task.yaml", "yaml", 1, `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: test
+spec:
+  params:
+    - name: revision
+  steps:
+    - name: a
+      image: alpine
+      script: echo $(params.
+`)
+
+	items, ok := s.handleCompletion("file:///task.yaml", protocol.Position{Line: 10, Character: 28}).([]protocol.CompletionItem)
+	require.True(t, ok)
+	require.Len(t, items, 1)
+	assert.Equal(t, "params.revision", items[0].Label)
+	assert.Equal(t, protocol.CompletionItemKindVariable, *items[0].Kind)
+	edit, ok := items[0].TextEdit.(protocol.TextEdit)
+	require.True(t, ok)
+	assert.Equal(t, "params.revision)", edit.NewText)
+	assert.Equal(t, protocol.Position{Line: 10, Character: 21}, edit.Range.Start)
+}
+
+func TestServer_Completion_Skeletons(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	s.cache.Insert("file:///new.yaml", "yaml", 1, "")
+
+	items, ok := s.handleCompletion("file:///new.yaml", protocol.Position{Line: 0, Character: 0}).([]protocol.CompletionItem)
+	require.True(t, ok)
+	require.NotEmpty(t, items)
+	assert.Equal(t, "Task", items[0].Label)
+	assert.Equal(t, protocol.CompletionItemKindSnippet, *items[0].Kind)
+	require.NotNil(t, items[0].InsertTextFormat)
+	assert.Equal(t, protocol.InsertTextFormatSnippet, *items[0].InsertTextFormat)
+}
+
+func TestServer_CompletionItemResolve(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	s.cache.Insert("file:///task.yaml", "yaml", 1, `apiVersion: tekton.dev/v1
+kind: PipelineRun
+metadata:
+  name: run
+spec:
+  
+`)
+
+	items, ok := s.handleCompletion("file:///task.yaml", protocol.Position{Line: 5, Character: 2}).([]protocol.CompletionItem)
+	require.True(t, ok)
+	var timeout protocol.CompletionItem
+	for i, item := range items {
+		require.NotNil(t, item.SortText)
+		if i > 0 {
+			assert.Less(t, *items[i-1].SortText, *item.SortText)
+		}
+		if item.Label == "timeout" {
+			timeout = item
+		}
+	}
+	require.Equal(t, "timeout", timeout.Label)
+	assert.Equal(t, []protocol.CompletionItemTag{protocol.CompletionItemTagDeprecated}, timeout.Tags)
+	assert.Nil(t, timeout.Documentation, "documentation is resolved lazily")
+	edit, ok := timeout.TextEdit.(protocol.TextEdit)
+	require.True(t, ok)
+	assert.Equal(t, "timeout: $1", edit.NewText)
+
+	// The item comes back from the client as JSON.
+	raw, err := json.Marshal(timeout)
+	require.NoError(t, err)
+	var sent protocol.CompletionItem
+	require.NoError(t, json.Unmarshal(raw, &sent))
+
+	resolved, err := s.completionItemResolve(nil, &sent)
+	require.NoError(t, err)
+	require.IsType(t, protocol.MarkupContent{}, resolved.Documentation)
+	doc := resolved.Documentation.(protocol.MarkupContent).Value
+	assert.Contains(t, doc, "**timeout**")
+	assert.Contains(t, doc, "Deprecated")
+
+	// Items of an older completion are not resolved.
+	s.handleCompletion("file:///task.yaml", protocol.Position{Line: 5, Character: 2})
+	var stale protocol.CompletionItem
+	require.NoError(t, json.Unmarshal(raw, &stale))
+	resolved, err = s.completionItemResolve(nil, &stale)
+	require.NoError(t, err)
+	assert.Nil(t, resolved.Documentation)
+}
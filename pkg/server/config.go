@@ -0,0 +1,213 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/completion"
+	"github.com/vdemeester/tekton-lsp-go/pkg/config"
+	"github.com/vdemeester/tekton-lsp-go/pkg/definition"
+	"github.com/vdemeester/tekton-lsp-go/pkg/validator"
+	"github.com/vdemeester/tekton-lsp-go/pkg/workspace"
+)
+
+// didChangeConfiguration handles the workspace/didChangeConfiguration
+// notification. Clients supporting workspace/configuration are asked for
+// the settings, as many only send a change signal; otherwise the settings
+// sent with the notification are applied.
+func (s *Server) didChangeConfiguration(context *glsp.Context, params *protocol.DidChangeConfigurationParams) error {
+	s.mu.RLock()
+	configurationSupport := s.configurationSupport
+	s.mu.RUnlock()
+	if configurationSupport {
+		go s.pullConfiguration(context)
+		return nil
+	}
+	settings, err := config.Parse(params.Settings)
+	if err != nil {
+		log.Warningf("Ignoring settings: %v", err)
+		return nil
+	}
+	s.applySettings(context, settings)
+	return nil
+}
+
+// pullConfiguration requests the settings with workspace/configuration and
+// applies them. It blocks until the client answers, so it must not run on the
+// connection's read loop.
+func (s *Server) pullConfiguration(context *glsp.Context) {
+	section := config.Section
+	var result []any
+	context.Call(protocol.ServerWorkspaceConfiguration, protocol.ConfigurationParams{
+		Items: []protocol.ConfigurationItem{{Section: &section}},
+	}, &result)
+	if len(result) == 0 {
+		return
+	}
+	settings, err := config.Parse(result[0])
+	if err != nil {
+		log.Warningf("Ignoring settings: %v", err)
+		return
+	}
+	s.applySettings(context, settings)
+}
+
+// applySettings replaces the settings and applies them without a restart:
+// the workspace is rescanned when the scan settings changed and open
+// documents are re-validated.
+func (s *Server) applySettings(context *glsp.Context, settings config.Settings) {
+	s.mu.Lock()
+	previous := s.settings
+	s.settings = settings
+	s.mu.Unlock()
+	log.Info("Settings updated")
+
+	if !reflect.DeepEqual(previous.Scan, settings.Scan) {
+		// Rescanning re-validates once done.
+		go func() {
+			s.scanWorkspace(context)
+			s.revalidateAll(context, 0)
+		}()
+		return
+	}
+	s.revalidateAll(context, 0)
+}
+
+// revalidateAll re-validates every open document after delay, or asks a
+// pull client to pull diagnostics again.
+func (s *Server) revalidateAll(context *glsp.Context, delay time.Duration) {
+	if pull, refresh := s.diagnosticsMode(); pull {
+		if refresh {
+			s.requestDiagnosticRefresh(context)
+		}
+		return
+	}
+	for _, e := range s.cache.All() {
+		if e.Open {
+			s.publishDiagnostics(context, e.URI, delay)
+		}
+	}
+}
+
+// config returns the current settings.
+func (s *Server) config() config.Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.settings
+}
+
+// validatorOptions returns the validation options for uri from the
+// settings and its project configuration.
+func (s *Server) validatorOptions(uri string) validator.Options {
+	settings := s.settingsFor(uri)
+	opts := validator.Options{
+		Severities: make(map[string]validator.Severity),
+		Disabled:   make(map[string]bool),
+		APIVersion: settings.TektonVersion,
+		Index:      s.cache.Index(),
+		Scope:      s.folderScope(uri),
+	}
+	for rule, severity := range settings.Rules {
+		if severity == config.SeverityOff {
+			opts.Disabled[rule] = true
+		} else if sev, ok := validator.ParseSeverity(severity); ok {
+			opts.Severities[rule] = sev
+		}
+	}
+	return opts
+}
+
+// scanOptions returns the workspace scan options from the settings. Files
+// ignored by their project configuration are skipped.
+func (s *Server) scanOptions() workspace.Options {
+	scan := s.config().Scan
+	return workspace.Options{
+		Include:     scan.Include,
+		Exclude:     scan.Exclude,
+		GitIgnore:   scan.GitIgnore,
+		MaxFileSize: scan.MaxFileSize,
+		Ignore: func(path string) bool {
+			return s.ignored(workspace.URIFromPath(path))
+		},
+	}
+}
+
+// definitionOptions returns the go-to-definition options for uri from the
+// settings and its project configuration. References resolve within the
+// workspace folder of uri, and in the other folders as well when
+// crossFolderLookup is set. Relative resolver mapping paths from the
+// settings are resolved against the folder.
+func (s *Server) definitionOptions(uri string) definition.Options {
+	settings := s.settingsFor(uri)
+	root := ""
+	if folder, ok := s.folderOf(uri); ok {
+		root = workspace.PathFromURI(folder)
+	}
+
+	opts := definition.Options{
+		Scope:      s.folderScope(uri),
+		CrossScope: settings.CrossFolderLookup,
+	}
+	for _, m := range settings.Resolvers {
+		if !filepath.IsAbs(m.Path) {
+			m.Path = filepath.Join(root, m.Path)
+		}
+		opts.Resolvers = append(opts.Resolvers, m)
+	}
+	return opts
+}
+
+// completionOptions returns the completion options for uri. Resources of
+// other workspace folders are offered only when crossFolderLookup is set.
+func (s *Server) completionOptions(uri string) completion.Options {
+	opts := completion.Options{Index: s.cache.Index()}
+	if !s.settingsFor(uri).CrossFolderLookup {
+		opts.Scope = s.folderScope(uri)
+	}
+	return opts
+}
+
+// indexed reports whether uri is a workspace file selected by the scan
+// settings, i.e. one that stays indexed while not open in the editor.
+func (s *Server) indexed(uri string) bool {
+	selected, inRoot := s.selected(uri)
+	return inRoot && selected
+}
+
+// excluded reports whether uri is a workspace file left out by the scan
+// settings.
+func (s *Server) excluded(uri string) bool {
+	selected, inRoot := s.selected(uri)
+	return inRoot && !selected
+}
+
+// selected reports whether uri is selected by the scan settings and its
+// project configuration, and whether it is inside a workspace root (or a
+// catalog) at all.
+func (s *Server) selected(uri string) (selected, inRoot bool) {
+	s.mu.RLock()
+	opts := workspace.Options{Include: s.settings.Scan.Include, Exclude: s.settings.Scan.Exclude}
+	maxSize := s.settings.Scan.MaxFileSize
+	gitIgnore := s.settings.Scan.GitIgnore
+	roots := append(append([]string(nil), s.roots...), s.catalogs...)
+	s.mu.RUnlock()
+	for _, root := range roots {
+		if rel, ok := workspace.Rel(root, uri); ok {
+			if maxSize > 0 {
+				if info, err := os.Stat(workspace.PathFromURI(uri)); err == nil && info.Size() > maxSize {
+					return false, true
+				}
+			}
+			if gitIgnore && workspace.GitIgnored(workspace.PathFromURI(root), rel) {
+				return false, true
+			}
+			return opts.Selects(rel) && !s.ignored(uri), true
+		}
+	}
+	return false, false
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/workspace"
+)
+
+const unknownFieldPipeline = `apiVersion: tekton.dev/v1beta1
+kind: Pipeline
+metadata:
+  name: ci
+spec:
+  bogus: true
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+`
+
+func TestInitialize_InitializationOptions(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	_, err := s.initialize(&glsp.Context{}, &protocol.InitializeParams{
+		InitializationOptions: map[string]any{
+			"tekton": map[string]any{"formatting": map[string]any{"indentSize": 4}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 4, s.config().Formatting.IndentSize)
+}
+
+func TestValidateDocument_RuleSettings(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	s.cache.Insert("file:///p.yaml", "yaml", 1, unknownFieldPipeline)
+
+	diags := s.validateDocument("file:///p.yaml")
+	require.Len(t, diags, 1)
+	assert.Equal(t, protocol.DiagnosticSeverityWarning, *diags[0].Severity)
+
+	n := &notifications{}
+	require.NoError(t, s.didChangeConfiguration(n.context(), &protocol.DidChangeConfigurationParams{
+		Settings: map[string]any{"rules": map[string]any{"unknown-field": "hint"}, "tektonVersion": "v1"},
+	}))
+	diags = s.validateDocument("file:///p.yaml")
+	require.Len(t, diags, 2)
+	codes := map[string]protocol.DiagnosticSeverity{}
+	for _, d := range diags {
+		codes[d.Code.Value.(string)] = *d.Severity
+	}
+	assert.Equal(t, protocol.DiagnosticSeverityHint, codes["unknown-field"])
+	assert.Equal(t, protocol.DiagnosticSeverityWarning, codes["deprecated-api-version"])
+
+	require.NoError(t, s.didChangeConfiguration(n.context(), &protocol.DidChangeConfigurationParams{
+		Settings: map[string]any{"rules": map[string]any{"unknown-field": "off"}},
+	}))
+	assert.Empty(t, s.validateDocument("file:///p.yaml"))
+}
+
+func TestDidChangeConfiguration_RevalidatesOpenDocuments(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	s.cache.Open("file:///p.yaml", "yaml", 1, unknownFieldPipeline)
+	s.cache.Insert("file:///closed.yaml", "yaml", 1, unknownFieldPipeline)
+
+	n := &notifications{}
+	require.NoError(t, s.didChangeConfiguration(n.context(), &protocol.DidChangeConfigurationParams{
+		Settings: map[string]any{"rules": map[string]any{"unknown-field": "warning"}},
+	}))
+	assert.Eventually(t, func() bool { return len(n.published()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"file:///p.yaml"}, n.published())
+}
+
+func TestDidChangeConfiguration_InvalidSettingsIgnored(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	require.NoError(t, s.didChangeConfiguration(nil, &protocol.DidChangeConfigurationParams{
+		Settings: map[string]any{"formatting": map[string]any{"indentSize": 4}},
+	}))
+	require.NoError(t, s.didChangeConfiguration(nil, &protocol.DidChangeConfigurationParams{
+		Settings: map[string]any{"rules": map[string]any{"unknown-field": "fatal"}},
+	}))
+	assert.Equal(t, 4, s.config().Formatting.IndentSize)
+}
+
+func TestFormatting_IndentSizeSetting(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	s.settings.Formatting.IndentSize = 4
+	s.cache.Open("file:///t.yaml", "yaml", 1, "kind: Task\nspec:\n  steps: []\n")
+
+	edits, err := s.textDocumentFormatting(nil, &protocol.DocumentFormattingParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: "file:///t.yaml"},
+		Options:      protocol.FormattingOptions{"tabSize": float64(8)},
+	})
+	require.NoError(t, err)
+	require.Len(t, edits, 1)
+	assert.Equal(t, "kind: Task\nspec:\n    steps: []\n", edits[0].NewText)
+}
+
+func TestDidChangeConfiguration_Rescan(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "vendor"), 0o755))
+	task := "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "task.yaml"), []byte(task), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "vendor", "task.yaml"), []byte(task), 0o644))
+	vendored := workspace.URIFromPath(filepath.Join(dir, "vendor", "task.yaml"))
+
+	s := New("test-lsp", "0.1.0")
+	s.roots = []string{workspace.URIFromPath(dir)}
+	s.scanWorkspace(nil)
+	_, ok := s.cache.Get(vendored)
+	require.True(t, ok)
+
+	require.NoError(t, s.didChangeConfiguration(nil, &protocol.DidChangeConfigurationParams{
+		Settings: map[string]any{"scan": map[string]any{"exclude": []any{"vendor"}}},
+	}))
+	assert.Eventually(t, func() bool {
+		_, ok := s.cache.Get(vendored)
+		return !ok
+	}, time.Second, 10*time.Millisecond, "excluded files are dropped from the index")
+	assert.False(t, s.indexed(vendored))
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 
+	"github.com/vdemeester/tekton-lsp-go/pkg/config"
 	"github.com/vdemeester/tekton-lsp-go/pkg/definition"
 	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
 )
@@ -14,6 +15,9 @@ func (s *Server) textDocumentDefinition(context *glsp.Context, params *protocol.
 	if !ok {
 		return nil, nil
 	}
+	if !s.enabled(params.TextDocument.URI, config.FeatureDefinition) {
+		return nil, nil
+	}
 
 	pos := parser.Position{
 		Line:      params.Position.Line,
@@ -21,9 +25,10 @@ func (s *Server) textDocumentDefinition(context *glsp.Context, params *protocol.
 	}
 
 	// Try each document — the position will only match one.
+	opts := s.definitionOptions(params.TextDocument.URI)
 	var loc *definition.Location
 	for _, doc := range docs {
-		if l := definition.GotoDefinition(doc, pos, s.cache); l != nil {
+		if l := definition.GotoDefinitionWithOptions(doc, pos, s.cache, opts); l != nil {
 			loc = l
 			break
 		}
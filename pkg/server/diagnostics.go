@@ -1,36 +1,105 @@
 package server
 
 import (
+	"context"
+	"time"
+
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 
+	"github.com/vdemeester/tekton-lsp-go/pkg/config"
 	"github.com/vdemeester/tekton-lsp-go/pkg/validator"
 )
 
 // validateDocument runs validation on all documents in a file and returns LSP diagnostics.
 func (s *Server) validateDocument(uri string) []protocol.Diagnostic {
+	if isProjectFile(uri) {
+		return convertDiagnostics(s.validateProjectFile(uri))
+	}
 	docs, ok := s.cache.GetAllParsed(uri)
 	if !ok {
 		return []protocol.Diagnostic{}
 	}
+	if !s.enabled(uri, config.FeatureDiagnostics) || s.ignored(uri) {
+		return []protocol.Diagnostic{}
+	}
 
+	opts := s.validatorOptions(uri)
 	var allDiags []validator.Diagnostic
 	for _, doc := range docs {
-		allDiags = append(allDiags, validator.Validate(doc)...)
+		allDiags = append(allDiags, validator.ValidateWithOptions(doc, opts)...)
 	}
 	return convertDiagnostics(allDiags)
 }
 
-// publishDiagnostics sends diagnostics to the LSP client.
-func (s *Server) publishDiagnostics(context *glsp.Context, uri string) {
-	diags := s.validateDocument(uri)
+// publishDiagnostics validates uri off the request goroutine after delay and
+// sends the diagnostics to the LSP client. Scheduling again for the same URI
+// supersedes pending and running validation, and results are dropped if the
+// document changed in the meantime, so the client never sees stale versions.
+func (s *Server) publishDiagnostics(glspContext *glsp.Context, uri string, delay time.Duration) {
+	if pull, _ := s.diagnosticsMode(); pull {
+		return
+	}
+	entry, ok := s.cache.Get(uri)
+	if !ok {
+		return
+	}
+	version := entry.Version
+	current := func(ctx context.Context) bool {
+		e, ok := s.cache.Get(uri)
+		return ctx.Err() == nil && ok && e.Version == version
+	}
 
-	go context.Notify(protocol.ServerTextDocumentPublishDiagnostics, &protocol.PublishDiagnosticsParams{
-		URI:         uri,
-		Diagnostics: diags,
+	s.scheduler.schedule(uri, delay, func(ctx context.Context) {
+		if !current(ctx) {
+			return
+		}
+		diags := s.validateDocument(uri)
+		if !current(ctx) {
+			return
+		}
+		glspContext.Notify(protocol.ServerTextDocumentPublishDiagnostics, &protocol.PublishDiagnosticsParams{
+			URI:         uri,
+			Diagnostics: diags,
+		})
 	})
 }
 
+// diagnosticsMode reports whether the client pulls diagnostics, and whether
+// it accepts workspace/diagnostic/refresh requests.
+func (s *Server) diagnosticsMode() (pull, refresh bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.pullDiagnostics, s.refreshSupport
+}
+
+// trackDependents runs update, which changes uris in the cache, and schedules
+// re-validation of the open documents depending on them. Dependents are
+// computed both before and after the update: references to resources that
+// went away must be re-checked as well as references that now resolve.
+func (s *Server) trackDependents(context *glsp.Context, uris []string, update func()) {
+	idx := s.cache.Index()
+	before := idx.Dependents(uris...)
+	update()
+	after := idx.Dependents(uris...)
+
+	if pull, refresh := s.diagnosticsMode(); pull {
+		// The client pulls the documents it shows; tell it to pull again.
+		if refresh && len(before)+len(after) > 0 {
+			s.requestDiagnosticRefresh(context)
+		}
+		return
+	}
+
+	seen := make(map[string]bool, len(before)+len(after))
+	for _, uri := range append(before, after...) {
+		if !seen[uri] && s.cache.IsOpen(uri) {
+			seen[uri] = true
+			s.publishDiagnostics(context, uri, s.debounce)
+		}
+	}
+}
+
 // convertDiagnostics converts our validator diagnostics to LSP protocol diagnostics.
 func convertDiagnostics(diags []validator.Diagnostic) []protocol.Diagnostic {
 	if len(diags) == 0 {
@@ -56,6 +125,9 @@ func convertDiagnostics(diags []validator.Diagnostic) []protocol.Diagnostic {
 			Source:   &source,
 			Message:  d.Message,
 		}
+		if d.Code != "" {
+			result[i].Code = &protocol.IntegerOrString{Value: d.Code}
+		}
 	}
 	return result
 }
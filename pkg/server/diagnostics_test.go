@@ -29,6 +29,7 @@ func TestConvertDiagnostics(t *testing.T) {
 			},
 			Severity: validator.SeverityWarning,
 			Source:   "tekton-lsp",
+			Code:     validator.RuleUnknownField,
 			Message:  "Unknown field 'taskz' in spec",
 		},
 	}
@@ -49,6 +50,9 @@ func TestConvertDiagnostics(t *testing.T) {
 	assert.Equal(t, protocol.DiagnosticSeverityWarning, *result[1].Severity)
 	assert.Equal(t, "Unknown field 'taskz' in spec", result[1].Message)
 	assert.Equal(t, uint32(5), result[1].Range.Start.Line)
+	require.NotNil(t, result[1].Code)
+	assert.Equal(t, "unknown-field", result[1].Code.Value)
+	assert.Nil(t, result[0].Code, "diagnostics without a rule should have no code")
 }
 
 func TestConvertDiagnostics_Empty(t *testing.T) {
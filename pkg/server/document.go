@@ -3,6 +3,8 @@ package server
 import (
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/workspace"
 )
 
 // didOpen handles the textDocument/didOpen notification
@@ -14,9 +16,11 @@ func (s *Server) didOpen(context *glsp.Context, params *protocol.DidOpenTextDocu
 
 	log.Infof("Document opened: %s (%d bytes)", uri, len(text))
 
-	s.cache.Insert(uri, langID, version, text)
+	s.trackDependents(context, []string{uri}, func() {
+		s.cache.Open(uri, langID, version, text)
+	})
 
-	s.publishDiagnostics(context, uri)
+	s.publishDiagnostics(context, uri, 0)
 
 	return nil
 }
@@ -28,8 +32,13 @@ func (s *Server) didChange(context *glsp.Context, params *protocol.DidChangeText
 
 	log.Infof("Document changed: %s (v%d)", uri, version)
 
-	s.handleContentChange(uri, version, params.ContentChanges)
-	s.publishDiagnostics(context, uri)
+	s.trackDependents(context, []string{uri}, func() {
+		s.handleContentChange(uri, version, params.ContentChanges)
+	})
+	s.publishDiagnostics(context, uri, s.debounce)
+	if isProjectFile(uri) {
+		s.projectFileEdited(context)
+	}
 
 	return nil
 }
@@ -56,7 +65,21 @@ func (s *Server) didClose(context *glsp.Context, params *protocol.DidCloseTextDo
 
 	log.Infof("Document closed: %s", uri)
 
-	s.cache.Remove(uri)
+	// Workspace files stay indexed with their content on disk, so references
+	// to them keep resolving; anything else is dropped.
+	s.scheduler.cancel(uri)
+	s.trackDependents(context, []string{uri}, func() {
+		s.cache.Close(uri)
+		if s.indexed(uri) {
+			workspace.Reload(uri, s.cache)
+		} else {
+			s.cache.Remove(uri)
+		}
+	})
+	if isProjectFile(uri) {
+		// Unsaved edits are discarded.
+		s.projectFileEdited(context)
+	}
 
 	return nil
 }
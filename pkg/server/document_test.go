@@ -1,11 +1,18 @@
 package server
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/index"
+	"github.com/vdemeester/tekton-lsp-go/pkg/validator"
+	"github.com/vdemeester/tekton-lsp-go/pkg/workspace"
 )
 
 func TestDidChange_UpdatesCacheWithFullSync(t *testing.T) {
@@ -75,3 +82,95 @@ func TestDidChange_HandlesBothChangeTypes(t *testing.T) {
 	entry, _ = s.cache.Get("file:///test.yaml")
 	assert.Equal(t, "new content via full", entry.Content)
 }
+
+func TestDidClose_KeepsWorkspaceFilesIndexed(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "task.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build\n"), 0o644))
+	uri := workspace.URIFromPath(path)
+
+	s := New("test-lsp", "0.1.0")
+	s.roots = []string{workspace.URIFromPath(dir)}
+	s.cache.Open(uri, "yaml", 1, "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: unsaved\n")
+
+	require.NoError(t, s.didClose(nil, &protocol.DidCloseTextDocumentParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	}))
+
+	entry, ok := s.cache.Get(uri)
+	require.True(t, ok, "workspace files stay cached after close")
+	assert.False(t, entry.Open)
+	assert.Contains(t, entry.Content, "name: build")
+	assert.NotNil(t, s.cache.Index().Resolve(index.Key{Group: "tekton.dev", Kind: "Task", Name: "build"}))
+}
+
+func TestDidClose_DropsFilesOutsideWorkspace(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	s.roots = []string{"file:///ws"}
+	s.cache.Open("file:///elsewhere/task.yaml", "yaml", 1, "kind: Task\n")
+
+	require.NoError(t, s.didClose(nil, &protocol.DidCloseTextDocumentParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: "file:///elsewhere/task.yaml"},
+	}))
+
+	_, ok := s.cache.Get("file:///elsewhere/task.yaml")
+	assert.False(t, ok)
+}
+
+func TestDidChange_RevalidatesOpenDependents(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	s.debounce = 20 * time.Millisecond
+	s.cache.Open("file:///pipeline.yaml", "yaml", 1, `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: ci
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+`)
+	s.cache.Open("file:///task.yaml", "yaml", 1, "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build\n")
+
+	n := &notifications{}
+	for version := int32(2); version <= 3; version++ {
+		require.NoError(t, s.didChange(n.context(), &protocol.DidChangeTextDocumentParams{
+			TextDocument: protocol.VersionedTextDocumentIdentifier{
+				TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: "file:///task.yaml"},
+				Version:                version,
+			},
+			ContentChanges: []any{protocol.TextDocumentContentChangeEventWhole{
+				Text: "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build\nspec: {}\n",
+			}},
+		}))
+	}
+
+	assert.Eventually(t, func() bool { return len(n.published()) == 2 }, time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.ElementsMatch(t, []string{"file:///task.yaml", "file:///pipeline.yaml"}, n.published(),
+		"each document is validated once, after edits settle")
+}
+
+func TestDidChange_DependentDiagnostics(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	s.debounce = 0
+	s.cache.Open("file:///pipeline.yaml", "yaml", 1, buildPipelineYAML)
+	s.cache.Open("file:///task.yaml", "yaml", 1, buildTaskYAML+"spec:\n  steps:\n    - name: build\n      image: golang\n")
+	assert.Empty(t, s.validateDocument("file:///pipeline.yaml"))
+
+	// The Task now requires a param the Pipeline does not pass.
+	n := &notifications{}
+	require.NoError(t, s.didChange(n.context(), &protocol.DidChangeTextDocumentParams{
+		TextDocument: protocol.VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: "file:///task.yaml"},
+			Version:                2,
+		},
+		ContentChanges: []any{protocol.TextDocumentContentChangeEventWhole{
+			Text: buildTaskYAML + "spec:\n  params:\n    - name: revision\n  steps:\n    - name: build\n      image: golang\n",
+		}},
+	}))
+	assert.Eventually(t, func() bool {
+		diags := n.last("file:///pipeline.yaml")
+		return len(diags) == 1 && diags[0].Code.Value == validator.RuleMissingParam
+	}, time.Second, 5*time.Millisecond, "the Pipeline is re-validated against the edited Task")
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"slices"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/workspace"
+)
+
+// workspaceFolders returns the workspace root URIs from the initialize
+// params: the workspace folders, or the root URI for clients that do not
+// support them.
+func workspaceFolders(params *protocol.InitializeParams) []string {
+	var roots []string
+	for _, f := range params.WorkspaceFolders {
+		roots = append(roots, f.URI)
+	}
+	if len(roots) == 0 && params.RootURI != nil {
+		roots = append(roots, *params.RootURI)
+	}
+	return roots
+}
+
+// didChangeWorkspaceFolders handles the workspace/didChangeWorkspaceFolders
+// notification: added folders are scanned and the files of removed folders
+// are dropped from the index.
+func (s *Server) didChangeWorkspaceFolders(context *glsp.Context, params *protocol.DidChangeWorkspaceFoldersParams) error {
+	var added, removed []string
+	s.mu.Lock()
+	for _, f := range params.Event.Removed {
+		if i := slices.Index(s.roots, f.URI); i >= 0 {
+			s.roots = slices.Delete(s.roots, i, i+1)
+			removed = append(removed, f.URI)
+		}
+	}
+	for _, f := range params.Event.Added {
+		if !slices.Contains(s.roots, f.URI) {
+			s.roots = append(s.roots, f.URI)
+			added = append(added, f.URI)
+		}
+	}
+	roots := append([]string(nil), s.roots...)
+	var stopped []*folderScan
+	for _, fs := range s.folderScans {
+		if slices.Contains(removed, fs.root) {
+			stopped = append(stopped, fs)
+		}
+	}
+	s.mu.Unlock()
+	log.Infof("Workspace folders changed: %d added, %d removed", len(added), len(removed))
+
+	// Stop the scans of removed folders before dropping their files, so
+	// that they neither index them again nor prune files being dropped.
+	for _, fs := range stopped {
+		fs.cancel()
+		<-fs.done
+	}
+
+	if len(removed) > 0 {
+		var dropped []string
+		for _, e := range s.cache.All() {
+			if !e.Open && inRoots(removed, e.URI) && !inRoots(roots, e.URI) {
+				dropped = append(dropped, e.URI)
+			}
+		}
+		s.trackDependents(context, dropped, func() {
+			for _, uri := range dropped {
+				s.cache.Remove(uri)
+			}
+		})
+	}
+
+	go func() {
+		// Catalogs are rescanned too: they come from project files, which
+		// may have been added or removed with the folders.
+		s.scan(context, added)
+		s.revalidateAll(context, 0)
+	}()
+	return nil
+}
+
+// folderOf returns the workspace folder containing uri; the innermost one
+// when folders are nested.
+func (s *Server) folderOf(uri string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	folder, depth := "", -1
+	for _, root := range s.roots {
+		if !workspace.Contains(root, uri) {
+			continue
+		}
+		if d := len(workspace.PathFromURI(root)); d > depth {
+			folder, depth = root, d
+		}
+	}
+	return folder, depth >= 0
+}
+
+// folderScope returns whether a file is in the same workspace folder as uri.
+// Files outside every folder, such as catalogs, are shared by all folders. It
+// returns nil when uri is not in a folder.
+func (s *Server) folderScope(uri string) func(string) bool {
+	folder, ok := s.folderOf(uri)
+	if !ok {
+		return nil
+	}
+	return func(other string) bool {
+		f, ok := s.folderOf(other)
+		return !ok || f == folder
+	}
+}
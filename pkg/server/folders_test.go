@@ -0,0 +1,183 @@
+package server
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/index"
+	"github.com/vdemeester/tekton-lsp-go/pkg/workspace"
+)
+
+const buildTaskYAML = "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build\n"
+
+const buildPipelineYAML = `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: ci
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+`
+
+func TestInitialize_WorkspaceFolders(t *testing.T) {
+	dir := t.TempDir()
+	a := writeFile(t, filepath.Join(dir, "a", "task.yaml"), buildTaskYAML)
+	b := writeFile(t, filepath.Join(dir, "b", "pipeline.yaml"), buildPipelineYAML)
+	rootA := workspace.URIFromPath(filepath.Join(dir, "a"))
+	rootB := workspace.URIFromPath(filepath.Join(dir, "b"))
+
+	s := New("test-lsp", "0.1.0")
+	unused := workspace.URIFromPath(filepath.Join(dir, "unused"))
+	result, err := s.initialize(&glsp.Context{}, &protocol.InitializeParams{
+		RootURI: &unused,
+		WorkspaceFolders: []protocol.WorkspaceFolder{
+			{URI: rootA, Name: "a"},
+			{URI: rootB, Name: "b"},
+		},
+	})
+	require.NoError(t, err)
+	caps := result.(initializeResult).Capabilities
+	require.NotNil(t, caps.Workspace)
+	assert.True(t, *caps.Workspace.WorkspaceFolders.Supported)
+	require.NoError(t, s.initialized(&glsp.Context{}, &protocol.InitializedParams{}))
+
+	assert.Eventually(t, func() bool {
+		_, okA := s.cache.Get(a)
+		_, okB := s.cache.Get(b)
+		return okA && okB
+	}, time.Second, 10*time.Millisecond, "every folder is scanned")
+	assert.Equal(t, []string{rootA, rootB}, s.roots)
+}
+
+func TestDidChangeWorkspaceFolders(t *testing.T) {
+	dir := t.TempDir()
+	task := writeFile(t, filepath.Join(dir, "a", "task.yaml"), buildTaskYAML)
+	rootA := workspace.URIFromPath(filepath.Join(dir, "a"))
+	rootB := workspace.URIFromPath(filepath.Join(dir, "b"))
+	open := writeFile(t, filepath.Join(dir, "a", "open.yaml"), buildTaskYAML)
+	build := index.Key{Group: "tekton.dev", Kind: "Task", Name: "build"}
+
+	s := New("test-lsp", "0.1.0")
+	s.roots = []string{rootB}
+	s.cache.Open(open, "yaml", 1, buildTaskYAML)
+
+	n := &notifications{}
+	require.NoError(t, s.didChangeWorkspaceFolders(n.context(), &protocol.DidChangeWorkspaceFoldersParams{
+		Event: protocol.WorkspaceFoldersChangeEvent{Added: []protocol.WorkspaceFolder{{URI: rootA}}},
+	}))
+	assert.Eventually(t, func() bool {
+		_, ok := s.cache.Get(task)
+		return ok
+	}, time.Second, 10*time.Millisecond, "added folders are scanned")
+	assert.Equal(t, []string{rootB, rootA}, s.roots)
+
+	require.NoError(t, s.didChangeWorkspaceFolders(n.context(), &protocol.DidChangeWorkspaceFoldersParams{
+		Event: protocol.WorkspaceFoldersChangeEvent{Removed: []protocol.WorkspaceFolder{{URI: rootA}}},
+	}))
+	_, ok := s.cache.Get(task)
+	assert.False(t, ok, "files of removed folders are dropped")
+	assert.True(t, s.cache.IsOpen(open), "open documents are kept")
+	assert.Len(t, s.cache.Index().Lookup(build), 1)
+	assert.Equal(t, []string{rootB}, s.roots)
+}
+
+func TestDidChangeWorkspaceFolders_StopsScan(t *testing.T) {
+	dir := t.TempDir()
+	task := writeFile(t, filepath.Join(dir, "a", "task.yaml"), buildTaskYAML)
+	rootA := workspace.URIFromPath(filepath.Join(dir, "a"))
+
+	s := New("test-lsp", "0.1.0")
+	s.workDoneProgress = true
+	s.roots = []string{rootA}
+
+	// The scan blocks on its first report, once the folder has been walked.
+	reached, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	blocking := &glsp.Context{
+		Call: func(string, any, any) {},
+		Notify: func(method string, params any) {
+			if p, ok := params.(protocol.ProgressParams); ok {
+				if _, ok := p.Value.(protocol.WorkDoneProgressReport); ok {
+					once.Do(func() {
+						close(reached)
+						<-release
+					})
+				}
+			}
+		},
+	}
+	scanned := make(chan struct{})
+	go func() {
+		s.scan(blocking, []string{rootA})
+		close(scanned)
+	}()
+	<-reached
+
+	removed := make(chan struct{})
+	go func() {
+		assert.NoError(t, s.didChangeWorkspaceFolders(&glsp.Context{Notify: func(string, any) {}, Call: func(string, any, any) {}}, &protocol.DidChangeWorkspaceFoldersParams{
+			Event: protocol.WorkspaceFoldersChangeEvent{Removed: []protocol.WorkspaceFolder{{URI: rootA}}},
+		}))
+		close(removed)
+	}()
+	assert.Never(t, func() bool {
+		select {
+		case <-removed:
+			return true
+		default:
+			return false
+		}
+	}, 50*time.Millisecond, 10*time.Millisecond, "removal waits for the folder's scan to stop")
+	close(release)
+	<-removed
+	<-scanned
+
+	_, ok := s.cache.Get(task)
+	assert.False(t, ok, "the cancelled scan does not index the removed folder")
+}
+
+func TestDefinition_FolderScope(t *testing.T) {
+	rootA, rootB := "file:///ws/a", "file:///ws/b"
+	pipeline := "file:///ws/b/pipeline.yaml"
+
+	s := New("test-lsp", "0.1.0")
+	s.roots = []string{rootA, rootB}
+	s.cache.Insert("file:///ws/a/task.yaml", "yaml", 1, buildTaskYAML)
+	s.cache.Open(pipeline, "yaml", 1, buildPipelineYAML)
+
+	params := &protocol.DefinitionParams{TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: pipeline},
+		Position:     protocol.Position{Line: 8, Character: 15},
+	}}
+	result, err := s.textDocumentDefinition(nil, params)
+	require.NoError(t, err)
+	assert.Nil(t, result, "references resolve within their folder")
+
+	s.settings.CrossFolderLookup = true
+	result, err = s.textDocumentDefinition(nil, params)
+	require.NoError(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, "file:///ws/a/task.yaml", result.(protocol.Location).URI)
+}
+
+func TestFolderOf_Nested(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	s.roots = []string{"file:///ws", "file:///ws/sub"}
+
+	folder, ok := s.folderOf("file:///ws/sub/task.yaml")
+	require.True(t, ok)
+	assert.Equal(t, "file:///ws/sub", folder)
+	folder, _ = s.folderOf("file:///ws/task.yaml")
+	assert.Equal(t, "file:///ws", folder)
+	_, ok = s.folderOf("file:///elsewhere/task.yaml")
+	assert.False(t, ok)
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 
+	"github.com/vdemeester/tekton-lsp-go/pkg/config"
 	"github.com/vdemeester/tekton-lsp-go/pkg/formatting"
 )
 
@@ -13,14 +14,13 @@ func (s *Server) textDocumentFormatting(context *glsp.Context, params *protocol.
 	if !ok {
 		return nil, nil
 	}
-
-	indent := 2
-	if tabSize, ok := params.Options["tabSize"]; ok {
-		if ts, ok := tabSize.(float64); ok {
-			indent = int(ts)
-		}
+	if !s.enabled(params.TextDocument.URI, config.FeatureFormatting) {
+		return nil, nil
 	}
-	opts := formatting.Options{IndentSize: indent}
+
+	// The editor's tabSize is not used: YAML is indented with spaces and
+	// tabSize is often 4 or 8. Indentation comes from the settings.
+	opts := formatting.Options{IndentSize: s.config().Formatting.IndentSize}
 
 	formatted, err := formatting.Format(entry.Content, opts)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 
+	"github.com/vdemeester/tekton-lsp-go/pkg/config"
 	"github.com/vdemeester/tekton-lsp-go/pkg/hover"
 	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
 )
@@ -14,6 +15,9 @@ func (s *Server) textDocumentHover(context *glsp.Context, params *protocol.Hover
 	if !ok {
 		return nil, nil
 	}
+	if !s.enabled(params.TextDocument.URI, config.FeatureHover) {
+		return nil, nil
+	}
 
 	pos := parser.Position{
 		Line:      params.Position.Line,
@@ -21,9 +25,10 @@ func (s *Server) textDocumentHover(context *glsp.Context, params *protocol.Hover
 	}
 
 	// Try each document — the position will only match one.
+	opts := hover.Options{Cache: s.cache, Definition: s.definitionOptions(params.TextDocument.URI)}
 	var result *hover.HoverResult
 	for _, doc := range docs {
-		if r := hover.Hover(doc, pos); r != nil {
+		if r := hover.HoverWithOptions(doc, pos, opts); r != nil {
 			result = r
 			break
 		}
@@ -1,10 +1,12 @@
 package server
 
 import (
+	"encoding/json"
+
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 
-	"github.com/vdemeester/tekton-lsp-go/pkg/workspace"
+	"github.com/vdemeester/tekton-lsp-go/pkg/config"
 )
 
 // initialize handles the initialize request from the client
@@ -16,15 +18,54 @@ func (s *Server) initialize(context *glsp.Context, params *protocol.InitializePa
 		log.Infof("Client: %s %s", params.ClientInfo.Name, *params.ClientInfo.Version)
 	}
 
+	// initialize runs concurrently with the read loop like every request, so
+	// the client capabilities are recorded under s.mu.
+	s.mu.Lock()
+	if ws := params.Capabilities.Workspace; ws != nil {
+		if ws.DidChangeWatchedFiles != nil {
+			s.watchFiles = ws.DidChangeWatchedFiles.DynamicRegistration != nil && *ws.DidChangeWatchedFiles.DynamicRegistration
+		}
+		s.configurationSupport = ws.Configuration != nil && *ws.Configuration
+	}
+	if w := params.Capabilities.Window; w != nil && w.WorkDoneProgress != nil {
+		s.workDoneProgress = *w.WorkDoneProgress
+	}
+	s.mu.Unlock()
+
+	if params.InitializationOptions != nil {
+		settings, err := config.Parse(params.InitializationOptions)
+		if err != nil {
+			log.Warningf("Ignoring initializationOptions: %v", err)
+		} else {
+			s.mu.Lock()
+			s.settings = settings
+			s.mu.Unlock()
+		}
+	}
+
+	// protocol_3_16 does not know the 3.17 capabilities, read them from the
+	// raw params.
+	var caps struct {
+		Capabilities clientCapabilities `json:"capabilities"`
+	}
+	if err := json.Unmarshal(context.Params, &caps); err == nil {
+		s.mu.Lock()
+		s.pullDiagnostics = caps.Capabilities.TextDocument.Diagnostic != nil
+		s.refreshSupport = caps.Capabilities.Workspace.Diagnostics.RefreshSupport
+		s.mu.Unlock()
+	}
+
 	// Create server capabilities
 	capabilities := s.handler.CreateServerCapabilities()
 
 	// Configure text document sync
 	capabilities.TextDocumentSync = protocol.TextDocumentSyncKindFull
 
-	// Completion
+	// Completion, with documentation resolved lazily
+	resolveProvider := true
 	capabilities.CompletionProvider = &protocol.CompletionOptions{
-		TriggerCharacters: []string{":", "-", " "},
+		TriggerCharacters: []string{":", "-", " ", "$", "(", "."},
+		ResolveProvider:   &resolveProvider,
 	}
 
 	// Hover
@@ -41,20 +82,34 @@ func (s *Server) initialize(context *glsp.Context, params *protocol.InitializePa
 
 	// Code Actions
 	capabilities.CodeActionProvider = true
-	// Scan workspace on init if rootUri is provided.
-	if params.RootURI != nil {
-		go func() {
-			n, err := workspace.Scan(*params.RootURI, s.cache)
-			if err != nil {
-				log.Warningf("Workspace scan error: %v", err)
-			} else {
-				log.Infof("Indexed %d YAML files from workspace", n)
-			}
-		}()
+
+	// Pull diagnostics (LSP 3.17)
+	diagnosticProvider := &diagnosticOptions{
+		Identifier:            s.name,
+		InterFileDependencies: true,
+		WorkspaceDiagnostics:  true,
+	}
+
+	// Workspace folders
+	supported := true
+	capabilities.Workspace = &protocol.ServerCapabilitiesWorkspace{
+		WorkspaceFolders: &protocol.WorkspaceFoldersServerCapabilities{
+			Supported:           &supported,
+			ChangeNotifications: &protocol.BoolOrString{Value: true},
+		},
 	}
 
-	return protocol.InitializeResult{
-		Capabilities: capabilities,
+	// The folders are scanned once initialized, when progress can be
+	// reported.
+	s.mu.Lock()
+	s.roots = workspaceFolders(params)
+	s.mu.Unlock()
+
+	return initializeResult{
+		Capabilities: serverCapabilities{
+			ServerCapabilities: capabilities,
+			DiagnosticProvider: diagnosticProvider,
+		},
 		ServerInfo: &protocol.InitializeResultServerInfo{
 			Name:    s.name,
 			Version: &s.version,
@@ -65,12 +120,24 @@ func (s *Server) initialize(context *glsp.Context, params *protocol.InitializePa
 // initialized handles the initialized notification from the client
 func (s *Server) initialized(context *glsp.Context, params *protocol.InitializedParams) error {
 	log.Info("Server initialized")
+	s.mu.RLock()
+	watchFiles, configurationSupport := s.watchFiles, s.configurationSupport
+	s.mu.RUnlock()
+	if watchFiles {
+		s.registerFileWatchers(context)
+	}
+	if configurationSupport {
+		go s.pullConfiguration(context)
+	}
+	go s.scanWorkspace(context)
 	return nil
 }
 
 // shutdown handles the shutdown request from the client
 func (s *Server) shutdown(context *glsp.Context) error {
 	log.Info("Shutting down Tekton LSP server")
+	s.scheduler.cancelAll()
+	s.cancelScans()
 	protocol.SetTraceValue(protocol.TraceValueOff)
 	return nil
 }
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// progress reports the progress of server-initiated work through a
+// work-done token. A nil progress reports nothing, so callers need not
+// check whether the client supports it.
+type progress struct {
+	context *glsp.Context
+	token   string
+	release func()
+
+	mu         sync.Mutex
+	percentage protocol.UInteger
+}
+
+// beginProgress creates a work-done token and reports the beginning of the
+// work titled title. The work is cancellable: cancel is called when the user
+// cancels it in the editor. It returns nil when the client does not support
+// work-done progress. Creating the token waits for the client, so it must
+// not be called from a notification handler.
+func (s *Server) beginProgress(glspContext *glsp.Context, title string, cancel context.CancelFunc) *progress {
+	if glspContext == nil {
+		return nil
+	}
+	s.mu.Lock()
+	if !s.workDoneProgress {
+		s.mu.Unlock()
+		return nil
+	}
+	s.progressID++
+	token := fmt.Sprintf("%s/%d", s.name, s.progressID)
+	s.progressCancels[token] = cancel
+	s.mu.Unlock()
+
+	glspContext.Call(protocol.ServerWindowWorkDoneProgressCreate, protocol.WorkDoneProgressCreateParams{
+		Token: protocol.ProgressToken{Value: token},
+	}, nil)
+
+	p := &progress{
+		context: glspContext,
+		token:   token,
+		release: func() {
+			s.mu.Lock()
+			delete(s.progressCancels, token)
+			s.mu.Unlock()
+		},
+	}
+	cancellable := true
+	var zero protocol.UInteger
+	p.notify(protocol.WorkDoneProgressBegin{
+		Kind:        "begin",
+		Title:       title,
+		Cancellable: &cancellable,
+		Percentage:  &zero,
+	})
+	return p
+}
+
+// report reports that done out of total items are processed. Reports that
+// would not change the percentage shown are skipped, except the last one.
+func (p *progress) report(message string, done, total int) {
+	if p == nil {
+		return
+	}
+	percentage := protocol.UInteger(100)
+	if total > 0 {
+		percentage = protocol.UInteger(done * 100 / total)
+	}
+	p.mu.Lock()
+	if percentage == p.percentage && done < total {
+		p.mu.Unlock()
+		return
+	}
+	p.percentage = percentage
+	p.mu.Unlock()
+
+	p.notify(protocol.WorkDoneProgressReport{
+		Kind:       "report",
+		Message:    &message,
+		Percentage: &percentage,
+	})
+}
+
+// end reports the end of the work and releases the token.
+func (p *progress) end(message string) {
+	if p == nil {
+		return
+	}
+	p.release()
+	p.notify(protocol.WorkDoneProgressEnd{Kind: "end", Message: &message})
+}
+
+func (p *progress) notify(value any) {
+	p.context.Notify(string(protocol.MethodProgress), protocol.ProgressParams{
+		Token: protocol.ProgressToken{Value: p.token},
+		Value: value,
+	})
+}
+
+// workDoneProgressCancel handles the window/workDoneProgress/cancel
+// notification sent when the user cancels server-initiated work.
+func (s *Server) workDoneProgressCancel(context *glsp.Context, params *protocol.WorkDoneProgressCancelParams) error {
+	token := fmt.Sprint(params.Token.Value)
+	s.mu.RLock()
+	cancel, ok := s.progressCancels[token]
+	s.mu.RUnlock()
+	if ok {
+		log.Infof("Work cancelled by the client: %s", token)
+		cancel()
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/tliron/glsp"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/config"
+	"github.com/vdemeester/tekton-lsp-go/pkg/validator"
+	"github.com/vdemeester/tekton-lsp-go/pkg/workspace"
+)
+
+// projects memoizes parsed project configuration files by path, and the
+// nearest one to each directory. Entries read from the editor are reparsed
+// when their content changes; those read from disk are kept until forget
+// is called, on file events and scans.
+type projects struct {
+	mu      sync.Mutex
+	files   map[string]projectFile
+	nearest map[projectSearch]string
+}
+
+type projectFile struct {
+	content string
+	// disk is true when content was read from disk rather than the editor.
+	disk    bool
+	project *config.Project
+	diags   []validator.Diagnostic
+}
+
+// projectSearch is a lookup of the project file nearest to dir, up to stop.
+type projectSearch struct {
+	dir, stop string
+}
+
+// forget drops everything memoized from disk, after project files were
+// created, changed or deleted.
+func (p *projects) forget() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for path, f := range p.files {
+		if f.disk {
+			delete(p.files, path)
+		}
+	}
+	clear(p.nearest)
+}
+
+// isProjectFile reports whether uri is a project configuration file.
+func isProjectFile(uri string) bool {
+	return filepath.Base(workspace.PathFromURI(uri)) == config.ProjectFile
+}
+
+// loadProject returns the project configuration file at path, preferring
+// the cached (possibly unsaved) content over the file on disk. Files loaded
+// by a scan are only cached as a summary and read from disk.
+func (s *Server) loadProject(path string) (*config.Project, []validator.Diagnostic) {
+	entry, ok := s.cache.Get(workspace.URIFromPath(path))
+	edited := ok && !entry.Summary
+
+	s.projects.mu.Lock()
+	defer s.projects.mu.Unlock()
+	f, ok := s.projects.files[path]
+	switch {
+	case edited && ok && !f.disk && f.content == entry.Content:
+		return f.project, f.diags
+	case edited:
+		f = projectFile{content: entry.Content}
+	case ok && f.disk:
+		return f.project, f.diags
+	default:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil
+		}
+		f = projectFile{content: string(data), disk: true}
+	}
+	f.project, f.diags = config.ParseProject(path, f.content)
+	s.projects.files[path] = f
+	return f.project, f.diags
+}
+
+// projectFor returns the project configuration applying to uri: the nearest
+// .tekton-lsp.yaml in its directory or a parent, up to its workspace root.
+// Only the directory of a file outside every workspace folder is searched.
+// It returns nil when there is none.
+func (s *Server) projectFor(uri string) *config.Project {
+	dir := filepath.Dir(workspace.PathFromURI(uri))
+	search := projectSearch{dir: dir, stop: dir}
+	if folder, ok := s.folderOf(uri); ok {
+		search.stop = workspace.PathFromURI(folder)
+	}
+
+	s.projects.mu.Lock()
+	found, ok := s.projects.nearest[search]
+	if !ok {
+		found, _ = config.FindProject(search.dir, search.stop)
+		s.projects.nearest[search] = found
+	}
+	s.projects.mu.Unlock()
+	if found == "" {
+		return nil
+	}
+	p, _ := s.loadProject(found)
+	return p
+}
+
+// settingsFor returns the settings applying to uri: the editor settings
+// overridden by the project configuration.
+func (s *Server) settingsFor(uri string) config.Settings {
+	return s.projectFor(uri).Apply(s.config())
+}
+
+// enabled reports whether feature is turned on for uri.
+func (s *Server) enabled(uri, feature string) bool {
+	return s.projectFor(uri).Enabled(feature)
+}
+
+// ignored reports whether uri is ignored by its project configuration.
+func (s *Server) ignored(uri string) bool {
+	return s.projectFor(uri).Ignores(workspace.PathFromURI(uri))
+}
+
+// validateProjectFile returns the problems found in a project configuration
+// file.
+func (s *Server) validateProjectFile(uri string) []validator.Diagnostic {
+	_, diags := s.loadProject(workspace.PathFromURI(uri))
+	return diags
+}
+
+// scanCatalogs indexes the catalog directories of the project configuration
+// files found in the workspace, except those already inside a root.
+func (s *Server) scanCatalogs(ctx context.Context, p *progress) {
+	s.mu.RLock()
+	roots := append([]string(nil), s.roots...)
+	s.mu.RUnlock()
+
+	var catalogs []string
+	seen := make(map[string]bool)
+	for _, e := range s.cache.All() {
+		if !isProjectFile(e.URI) {
+			continue
+		}
+		p, _ := s.loadProject(workspace.PathFromURI(e.URI))
+		for _, dir := range p.Catalogs {
+			uri := workspace.URIFromPath(dir)
+			if seen[uri] || inRoots(roots, uri) {
+				continue
+			}
+			seen[uri] = true
+			catalogs = append(catalogs, uri)
+		}
+	}
+
+	s.mu.Lock()
+	previous := s.catalogs
+	s.catalogs = catalogs
+	s.mu.Unlock()
+
+	// Drop the files of catalogs no longer configured.
+	for _, e := range s.cache.All() {
+		if !e.Open && inRoots(previous, e.URI) && !inRoots(catalogs, e.URI) && !inRoots(roots, e.URI) {
+			s.cache.Remove(e.URI)
+		}
+	}
+
+	for _, uri := range catalogs {
+		if _, err := s.scanRoot(ctx, uri, p); err != nil {
+			return
+		}
+	}
+}
+
+func inRoots(roots []string, uri string) bool {
+	for _, root := range roots {
+		if workspace.Contains(root, uri) {
+			return true
+		}
+	}
+	return false
+}
+
+// projectFileEdited re-validates the open documents after an edit to a
+// project configuration file in the editor.
+func (s *Server) projectFileEdited(context *glsp.Context) {
+	s.projects.forget()
+	s.revalidateAll(context, s.debounce)
+}
+
+// projectFileSaved applies a project configuration file changed on disk:
+// its ignore paths and catalogs may select other files, so the workspace is
+// rescanned before open documents are re-validated.
+func (s *Server) projectFileSaved(context *glsp.Context) {
+	s.projects.forget()
+	go func() {
+		s.scanWorkspace(context)
+		s.revalidateAll(context, 0)
+	}()
+}
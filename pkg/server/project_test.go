@@ -0,0 +1,150 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/config"
+	"github.com/vdemeester/tekton-lsp-go/pkg/index"
+	"github.com/vdemeester/tekton-lsp-go/pkg/workspace"
+)
+
+func writeFile(t *testing.T, path, content string) string {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return workspace.URIFromPath(path)
+}
+
+func TestProjectConfig_NearestFileWins(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, config.ProjectFile), "rules:\n  unknown-field: off\n")
+	writeFile(t, filepath.Join(root, "team", config.ProjectFile), "rules:\n  unknown-field: error\n")
+
+	s := New("test-lsp", "0.1.0")
+	s.roots = []string{workspace.URIFromPath(root)}
+	top := workspace.URIFromPath(filepath.Join(root, "pipeline.yaml"))
+	team := workspace.URIFromPath(filepath.Join(root, "team", "tekton", "pipeline.yaml"))
+	s.cache.Open(top, "yaml", 1, unknownFieldPipeline)
+	s.cache.Open(team, "yaml", 1, strings.Replace(unknownFieldPipeline, "name: ci", "name: team", 1))
+
+	assert.Empty(t, s.validateDocument(top))
+	diags := s.validateDocument(team)
+	require.Len(t, diags, 1)
+	assert.Equal(t, protocol.DiagnosticSeverityError, *diags[0].Severity)
+}
+
+func TestProjectConfig_OverridesSettings(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, config.ProjectFile), "enable: [unknown-field]\n")
+
+	s := New("test-lsp", "0.1.0")
+	s.roots = []string{workspace.URIFromPath(root)}
+	s.settings.Rules = map[string]string{"unknown-field": "off"}
+	uri := workspace.URIFromPath(filepath.Join(root, "pipeline.yaml"))
+	s.cache.Open(uri, "yaml", 1, unknownFieldPipeline)
+
+	assert.Len(t, s.validateDocument(uri), 1, "the project re-enables a rule turned off in the editor")
+}
+
+func TestProjectConfig_Features(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, config.ProjectFile), "features:\n  formatting: false\n  diagnostics: false\n")
+
+	s := New("test-lsp", "0.1.0")
+	s.roots = []string{workspace.URIFromPath(root)}
+	uri := workspace.URIFromPath(filepath.Join(root, "pipeline.yaml"))
+	s.cache.Open(uri, "yaml", 1, "kind:   Task\n"+unknownFieldPipeline)
+
+	assert.Empty(t, s.validateDocument(uri))
+	edits, err := s.textDocumentFormatting(nil, &protocol.DocumentFormattingParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, edits)
+}
+
+func TestProjectConfig_Diagnostics(t *testing.T) {
+	root := t.TempDir()
+	uri := writeFile(t, filepath.Join(root, config.ProjectFile), "rules:\n  unknown-field: off\n")
+
+	s := New("test-lsp", "0.1.0")
+	s.roots = []string{workspace.URIFromPath(root)}
+	pipeline := workspace.URIFromPath(filepath.Join(root, "pipeline.yaml"))
+	s.cache.Open(pipeline, "yaml", 1, unknownFieldPipeline)
+	assert.Empty(t, s.validateDocument(pipeline))
+
+	// Unsaved edits to the project file apply and are validated.
+	s.cache.Open(uri, "yaml", 1, "rules:\n  unknown-field: loud\n")
+	diags := s.validateDocument(uri)
+	require.Len(t, diags, 1)
+	assert.Equal(t, config.RuleInvalidConfig, diags[0].Code.Value)
+	assert.Len(t, s.validateDocument(pipeline), 1)
+}
+
+func TestProjectConfig_IgnoreAndCatalogs(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "repo")
+	writeFile(t, filepath.Join(root, config.ProjectFile), "ignore: [generated]\ncatalogs: [../catalog]\n")
+	generated := writeFile(t, filepath.Join(root, "generated", "task.yaml"), "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: generated\n")
+	writeFile(t, filepath.Join(dir, "catalog", "git-clone.yaml"), "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: git-clone\n")
+
+	s := New("test-lsp", "0.1.0")
+	s.roots = []string{workspace.URIFromPath(root)}
+	s.scanWorkspace(nil)
+
+	_, ok := s.cache.Get(generated)
+	assert.False(t, ok, "ignored files are not indexed")
+	assert.NotNil(t, s.cache.Index().Resolve(index.Key{Group: "tekton.dev", Kind: "Task", Name: "git-clone"}),
+		"catalog tasks are indexed")
+	assert.True(t, s.indexed(workspace.URIFromPath(filepath.Join(dir, "catalog", "git-clone.yaml"))))
+
+	// Dropping the catalog drops its files.
+	writeFile(t, filepath.Join(root, config.ProjectFile), "ignore: [generated]\n")
+	s.scanWorkspace(nil)
+	assert.Nil(t, s.cache.Index().Resolve(index.Key{Group: "tekton.dev", Kind: "Task", Name: "git-clone"}))
+}
+
+func TestProjectConfig_Memoized(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, config.ProjectFile), "rules:\n  unknown-field: off\n")
+
+	s := New("test-lsp", "0.1.0")
+	s.roots = []string{workspace.URIFromPath(root)}
+	uri := workspace.URIFromPath(filepath.Join(root, "team", "pipeline.yaml"))
+	s.cache.Open(uri, "yaml", 1, unknownFieldPipeline)
+	assert.Empty(t, s.validateDocument(uri))
+
+	// Project files are looked up once, until the client reports a change.
+	team := writeFile(t, filepath.Join(root, "team", config.ProjectFile), "rules:\n  unknown-field: error\n")
+	assert.Empty(t, s.validateDocument(uri))
+
+	n := &notifications{}
+	require.NoError(t, s.didChangeWatchedFiles(n.context(), &protocol.DidChangeWatchedFilesParams{
+		Changes: []protocol.FileEvent{{URI: team, Type: protocol.FileChangeTypeCreated}},
+	}))
+	assert.Len(t, s.validateDocument(uri), 1)
+	assert.Eventually(t, func() bool { return len(n.published()) > 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestProjectConfig_OutsideFolders(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, config.ProjectFile), "rules:\n  unknown-field: off\n")
+
+	// Without a workspace folder, only the file's own directory is searched.
+	s := New("test-lsp", "0.1.0")
+	top := workspace.URIFromPath(filepath.Join(root, "pipeline.yaml"))
+	nested := workspace.URIFromPath(filepath.Join(root, "team", "pipeline.yaml"))
+	s.cache.Open(top, "yaml", 1, unknownFieldPipeline)
+	s.cache.Open(nested, "yaml", 1, strings.Replace(unknownFieldPipeline, "name: ci", "name: nested", 1))
+
+	assert.Empty(t, s.validateDocument(top))
+	assert.Len(t, s.validateDocument(nested), 1)
+}
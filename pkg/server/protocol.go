@@ -0,0 +1,133 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// LSP 3.17 additions missing from protocol_3_16. Only what the server uses
+// is declared here.
+
+const (
+	methodTextDocumentDiagnostic     = "textDocument/diagnostic"
+	methodWorkspaceDiagnostic        = "workspace/diagnostic"
+	methodWorkspaceDiagnosticRefresh = "workspace/diagnostic/refresh"
+)
+
+// Document diagnostic report kinds.
+const (
+	diagnosticReportFull      = "full"
+	diagnosticReportUnchanged = "unchanged"
+)
+
+// serverCapabilities extends the 3.16 capabilities with 3.17 ones.
+type serverCapabilities struct {
+	protocol.ServerCapabilities
+	DiagnosticProvider *diagnosticOptions `json:"diagnosticProvider,omitempty"`
+}
+
+type diagnosticOptions struct {
+	Identifier            string `json:"identifier,omitempty"`
+	InterFileDependencies bool   `json:"interFileDependencies"`
+	WorkspaceDiagnostics  bool   `json:"workspaceDiagnostics"`
+}
+
+type initializeResult struct {
+	Capabilities serverCapabilities                   `json:"capabilities"`
+	ServerInfo   *protocol.InitializeResultServerInfo `json:"serverInfo,omitempty"`
+}
+
+// clientCapabilities holds the 3.17 client capabilities the server checks.
+type clientCapabilities struct {
+	TextDocument struct {
+		Diagnostic *struct{} `json:"diagnostic"`
+	} `json:"textDocument"`
+	Workspace struct {
+		Diagnostics struct {
+			RefreshSupport bool `json:"refreshSupport"`
+		} `json:"diagnostics"`
+	} `json:"workspace"`
+}
+
+type documentDiagnosticParams struct {
+	TextDocument     protocol.TextDocumentIdentifier `json:"textDocument"`
+	Identifier       string                          `json:"identifier,omitempty"`
+	PreviousResultID string                          `json:"previousResultId,omitempty"`
+}
+
+// documentDiagnosticReport is a full or unchanged report; Items is nil for
+// unchanged reports and never nil for full ones.
+type documentDiagnosticReport struct {
+	Kind     string                `json:"kind"`
+	ResultID string                `json:"resultId,omitempty"`
+	Items    []protocol.Diagnostic `json:"items,omitzero"`
+}
+
+type workspaceDiagnosticParams struct {
+	Identifier        string           `json:"identifier,omitempty"`
+	PreviousResultIDs []previousResult `json:"previousResultIds"`
+}
+
+type previousResult struct {
+	URI   protocol.DocumentUri `json:"uri"`
+	Value string               `json:"value"`
+}
+
+type workspaceDiagnosticReport struct {
+	Items []workspaceDocumentDiagnosticReport `json:"items"`
+}
+
+type workspaceDocumentDiagnosticReport struct {
+	documentDiagnosticReport
+	URI protocol.DocumentUri `json:"uri"`
+	// Version is the version of an open document, nil for files on disk.
+	Version *protocol.Integer `json:"version"`
+}
+
+// handler317 dispatches the LSP 3.17 methods, in the style of
+// protocol.Handler.
+type handler317 struct {
+	TextDocumentDiagnostic func(context *glsp.Context, params *documentDiagnosticParams) (*documentDiagnosticReport, error)
+	WorkspaceDiagnostic    func(context *glsp.Context, params *workspaceDiagnosticParams) (*workspaceDiagnosticReport, error)
+}
+
+// Handle implements glsp.Handler.
+func (h *handler317) Handle(context *glsp.Context) (r any, validMethod bool, validParams bool, err error) {
+	switch context.Method {
+	case methodTextDocumentDiagnostic:
+		if h.TextDocumentDiagnostic != nil {
+			validMethod = true
+			var params documentDiagnosticParams
+			if err = json.Unmarshal(context.Params, &params); err == nil {
+				validParams = true
+				r, err = h.TextDocumentDiagnostic(context, &params)
+			}
+		}
+	case methodWorkspaceDiagnostic:
+		if h.WorkspaceDiagnostic != nil {
+			validMethod = true
+			var params workspaceDiagnosticParams
+			if err = json.Unmarshal(context.Params, &params); err == nil {
+				validParams = true
+				r, err = h.WorkspaceDiagnostic(context, &params)
+			}
+		}
+	}
+	return
+}
+
+// handle dispatches a message to the 3.17 handler, falling back to the 3.16
+// one for every other method.
+func (s *Server) handle(context *glsp.Context) (r any, validMethod bool, validParams bool, err error) {
+	if s.handler.IsInitialized() {
+		if r, validMethod, validParams, err = s.handler317.Handle(context); validMethod {
+			return
+		}
+	} else if context.Method == methodTextDocumentDiagnostic || context.Method == methodWorkspaceDiagnostic {
+		return nil, true, true, errors.New("server not initialized")
+	}
+	return s.handler.Handle(context)
+}
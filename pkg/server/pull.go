@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"sort"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/workspace"
+)
+
+// textDocumentDiagnostic handles the textDocument/diagnostic request (pull
+// diagnostics, LSP 3.17).
+func (s *Server) textDocumentDiagnostic(context *glsp.Context, params *documentDiagnosticParams) (*documentDiagnosticReport, error) {
+	report := s.diagnosticReport(params.TextDocument.URI, params.PreviousResultID)
+	return &report, nil
+}
+
+// workspaceDiagnostic handles the workspace/diagnostic request: every cached
+// YAML file is validated, open or not. Files whose diagnostics did not change
+// since the client's previous result are reported as unchanged.
+func (s *Server) workspaceDiagnostic(context *glsp.Context, params *workspaceDiagnosticParams) (*workspaceDiagnosticReport, error) {
+	ctx := s.rpc.requestContext(context)
+	previous := make(map[string]string, len(params.PreviousResultIDs))
+	for _, p := range params.PreviousResultIDs {
+		previous[p.URI] = p.Value
+	}
+
+	entries := s.cache.All()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].URI < entries[j].URI })
+
+	result := &workspaceDiagnosticReport{Items: []workspaceDocumentDiagnosticReport{}}
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !workspace.IsYAML(entry.URI) {
+			continue
+		}
+		item := workspaceDocumentDiagnosticReport{
+			documentDiagnosticReport: s.diagnosticReport(entry.URI, previous[entry.URI]),
+			URI:                      entry.URI,
+		}
+		if entry.Open {
+			version := entry.Version
+			item.Version = &version
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result, nil
+}
+
+// diagnosticReport validates uri and returns a full report, or an unchanged
+// one if the diagnostics match previousResultID.
+func (s *Server) diagnosticReport(uri, previousResultID string) documentDiagnosticReport {
+	diags := s.validateDocument(uri)
+	resultID := diagnosticsResultID(diags)
+	if previousResultID != "" && previousResultID == resultID {
+		return documentDiagnosticReport{Kind: diagnosticReportUnchanged, ResultID: resultID}
+	}
+	return documentDiagnosticReport{Kind: diagnosticReportFull, ResultID: resultID, Items: diags}
+}
+
+// diagnosticsResultID identifies a set of diagnostics. Identical diagnostics
+// get the same ID, so a document is "unchanged" exactly when re-validating it
+// would not change what the client shows.
+func diagnosticsResultID(diags []protocol.Diagnostic) string {
+	h := fnv.New64a()
+	// Diagnostics only hold plain values, encoding cannot fail.
+	data, _ := json.Marshal(diags)
+	h.Write(data)
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// requestDiagnosticRefresh asks a pull-diagnostics client to pull again,
+// once edits have settled. It is used when other documents than the edited
+// one may be affected.
+func (s *Server) requestDiagnosticRefresh(glspContext *glsp.Context) {
+	s.scheduler.schedule(methodWorkspaceDiagnosticRefresh, s.debounce, func(ctx context.Context) {
+		if ctx.Err() == nil {
+			glspContext.Call(methodWorkspaceDiagnosticRefresh, nil, nil)
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+const invalidTask = `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  namespace: default
+spec:
+  steps:
+    - name: build
+      image: golang:1.25
+`
+
+const validTask = `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  steps:
+    - name: build
+      image: golang:1.25
+`
+
+func TestTextDocumentDiagnostic(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	s.cache.Open("file:///task.yaml", "yaml", 1, invalidTask)
+	params := &documentDiagnosticParams{TextDocument: protocol.TextDocumentIdentifier{URI: "file:///task.yaml"}}
+
+	report, err := s.textDocumentDiagnostic(&glsp.Context{}, params)
+	require.NoError(t, err)
+	assert.Equal(t, diagnosticReportFull, report.Kind)
+	assert.NotEmpty(t, report.Items)
+	require.NotEmpty(t, report.ResultID)
+
+	params.PreviousResultID = report.ResultID
+	unchanged, err := s.textDocumentDiagnostic(&glsp.Context{}, params)
+	require.NoError(t, err)
+	assert.Equal(t, diagnosticReportUnchanged, unchanged.Kind)
+	assert.Equal(t, report.ResultID, unchanged.ResultID)
+	assert.Nil(t, unchanged.Items)
+
+	s.cache.Update("file:///task.yaml", 2, validTask)
+	fixed, err := s.textDocumentDiagnostic(&glsp.Context{}, params)
+	require.NoError(t, err)
+	assert.Equal(t, diagnosticReportFull, fixed.Kind)
+	assert.Empty(t, fixed.Items)
+	assert.NotEqual(t, report.ResultID, fixed.ResultID)
+}
+
+func TestDiagnosticReport_JSON(t *testing.T) {
+	full, err := json.Marshal(documentDiagnosticReport{Kind: diagnosticReportFull, ResultID: "1", Items: []protocol.Diagnostic{}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"kind":"full","resultId":"1","items":[]}`, string(full))
+
+	unchanged, err := json.Marshal(documentDiagnosticReport{Kind: diagnosticReportUnchanged, ResultID: "1"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"kind":"unchanged","resultId":"1"}`, string(unchanged))
+}
+
+func TestWorkspaceDiagnostic(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	s.cache.Open("file:///ws/open.yaml", "yaml", 3, validTask)
+	s.cache.Load("file:///ws/disk.yaml", invalidTask)
+	s.cache.Load("file:///ws/README.md", "# readme")
+
+	report, err := s.workspaceDiagnostic(&glsp.Context{}, &workspaceDiagnosticParams{})
+	require.NoError(t, err)
+	require.Len(t, report.Items, 2)
+
+	disk, open := report.Items[0], report.Items[1]
+	assert.Equal(t, "file:///ws/disk.yaml", disk.URI)
+	assert.Equal(t, diagnosticReportFull, disk.Kind)
+	assert.NotEmpty(t, disk.Items, "files that are not open are validated too")
+	assert.Nil(t, disk.Version)
+	assert.Equal(t, "file:///ws/open.yaml", open.URI)
+	require.NotNil(t, open.Version)
+	assert.Equal(t, protocol.Integer(3), *open.Version)
+
+	report, err = s.workspaceDiagnostic(&glsp.Context{}, &workspaceDiagnosticParams{
+		PreviousResultIDs: []previousResult{{URI: disk.URI, Value: disk.ResultID}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, diagnosticReportUnchanged, report.Items[0].Kind)
+	assert.Equal(t, diagnosticReportFull, report.Items[1].Kind)
+}
+
+func TestPullDiagnostics_OverTransport(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	n := &notifications{}
+	conn, init := connect(t, s, json.RawMessage(`{"capabilities":{"textDocument":{"diagnostic":{}}}}`), n)
+
+	ctx := context.Background()
+	caps := init["capabilities"].(map[string]any)
+	assert.Equal(t, map[string]any{"identifier": "test-lsp", "interFileDependencies": true, "workspaceDiagnostics": true}, caps["diagnosticProvider"])
+	pull, _ := s.diagnosticsMode()
+	assert.True(t, pull)
+
+	require.NoError(t, conn.Notify(ctx, "textDocument/didOpen", protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: "file:///task.yaml", LanguageID: "yaml", Version: 1, Text: invalidTask},
+	}))
+	var report documentDiagnosticReport
+	require.NoError(t, conn.Call(ctx, methodTextDocumentDiagnostic, documentDiagnosticParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: "file:///task.yaml"},
+	}, &report))
+	assert.Equal(t, diagnosticReportFull, report.Kind)
+	assert.NotEmpty(t, report.Items)
+
+	// Pull clients do not get pushed diagnostics.
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, n.published())
+}
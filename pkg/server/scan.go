@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+
+	"github.com/tliron/glsp"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/workspace"
+)
+
+// scanWorkspace indexes every workspace folder, then the catalogs they
+// configure.
+func (s *Server) scanWorkspace(glspContext *glsp.Context) {
+	s.mu.RLock()
+	roots := append([]string(nil), s.roots...)
+	s.mu.RUnlock()
+	if len(roots) > 0 {
+		s.scan(glspContext, roots)
+	}
+}
+
+// scan indexes the given workspace folders, then the catalogs configured by
+// the project files of the workspace. Progress is reported to the client
+// through a work-done token; the scan stops when the server shuts down or
+// the user cancels it.
+func (s *Server) scan(glspContext *glsp.Context, roots []string) {
+	ctx, cancel := context.WithCancel(s.scanCtx)
+	defer cancel()
+	p := s.beginProgress(glspContext, "Indexing Tekton files", cancel)
+
+	// Project files select the scanned files: read them afresh.
+	s.projects.forget()
+
+	total := 0
+	for _, root := range roots {
+		n, err := s.scanRoot(ctx, root, p)
+		total += n
+		if err != nil && ctx.Err() != nil {
+			break
+		}
+	}
+	if ctx.Err() == nil {
+		s.scanCatalogs(ctx, p)
+	}
+
+	if ctx.Err() != nil {
+		log.Info("Workspace scan cancelled")
+		p.end("Cancelled")
+		return
+	}
+	p.end(fmt.Sprintf("Indexed %d files", total))
+}
+
+// folderScan is a running scan of a workspace folder or catalog.
+type folderScan struct {
+	root   string
+	cancel context.CancelFunc
+	// done is closed once the scan no longer changes the cache.
+	done chan struct{}
+}
+
+// scanRoot indexes the directory root, reporting the number of files indexed
+// through p. The scan stops when root is removed from the workspace folders;
+// it does not start if root is no longer a folder or a catalog.
+func (s *Server) scanRoot(ctx context.Context, root string, p *progress) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	fs := &folderScan{root: root, cancel: cancel, done: make(chan struct{})}
+	s.mu.Lock()
+	if !slices.Contains(s.roots, root) && !slices.Contains(s.catalogs, root) {
+		s.mu.Unlock()
+		return 0, nil
+	}
+	s.folderScans = append(s.folderScans, fs)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.folderScans = slices.DeleteFunc(s.folderScans, func(f *folderScan) bool { return f == fs })
+		s.mu.Unlock()
+		close(fs.done)
+	}()
+
+	opts := s.scanOptions()
+	name := path.Base(root)
+	opts.Progress = func(done, total int) {
+		p.report(fmt.Sprintf("%s: %d/%d files", name, done, total), done, total)
+	}
+	n, err := workspace.ScanContext(ctx, root, s.cache, opts)
+	if err != nil {
+		log.Warningf("Scan of %s stopped: %v", root, err)
+	} else {
+		log.Infof("Indexed %d YAML files from %s", n, root)
+	}
+	return n, err
+}
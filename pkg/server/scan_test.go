@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/workspace"
+)
+
+// progressRecorder records the requests and $/progress notifications sent
+// through a glsp.Context.
+type progressRecorder struct {
+	mu     sync.Mutex
+	calls  []string
+	values []map[string]any
+}
+
+func (r *progressRecorder) context() *glsp.Context {
+	return &glsp.Context{
+		Call: func(method string, params any, result any) {
+			r.mu.Lock()
+			r.calls = append(r.calls, method)
+			r.mu.Unlock()
+		},
+		Notify: func(method string, params any) {
+			if method != string(protocol.MethodProgress) {
+				return
+			}
+			// Round-trip through JSON as the client would see it.
+			data, _ := json.Marshal(params.(protocol.ProgressParams).Value)
+			var value map[string]any
+			_ = json.Unmarshal(data, &value)
+			r.mu.Lock()
+			r.values = append(r.values, value)
+			r.mu.Unlock()
+		},
+	}
+}
+
+func TestScan_Progress(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.yaml", "b.yaml", "c.yaml"} {
+		writeFile(t, filepath.Join(dir, name), buildTaskYAML)
+	}
+
+	s := New("test-lsp", "0.1.0")
+	s.workDoneProgress = true
+	s.roots = []string{workspace.URIFromPath(dir)}
+	r := &progressRecorder{}
+	s.scanWorkspace(r.context())
+
+	assert.Equal(t, []string{string(protocol.ServerWindowWorkDoneProgressCreate)}, r.calls)
+	require.GreaterOrEqual(t, len(r.values), 3)
+	begin, last := r.values[0], r.values[len(r.values)-1]
+	assert.Equal(t, "begin", begin["kind"])
+	assert.Equal(t, true, begin["cancellable"])
+	assert.Equal(t, map[string]any{"kind": "end", "message": "Indexed 3 files"}, last)
+
+	report := r.values[len(r.values)-2]
+	assert.Equal(t, "report", report["kind"])
+	assert.True(t, strings.HasSuffix(report["message"].(string), ": 3/3 files"), report["message"])
+	assert.Equal(t, float64(100), report["percentage"])
+	assert.Empty(t, s.progressCancels, "the token is released")
+}
+
+func TestScan_NoProgressWithoutClientSupport(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "task.yaml"), buildTaskYAML)
+
+	s := New("test-lsp", "0.1.0")
+	s.roots = []string{workspace.URIFromPath(dir)}
+	r := &progressRecorder{}
+	s.scanWorkspace(r.context())
+
+	assert.Empty(t, r.calls)
+	assert.Empty(t, r.values)
+	assert.Len(t, s.cache.All(), 1)
+}
+
+func TestScan_CancelledOnShutdown(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "task.yaml"), buildTaskYAML)
+
+	s := New("test-lsp", "0.1.0")
+	s.workDoneProgress = true
+	s.roots = []string{workspace.URIFromPath(dir)}
+	require.NoError(t, s.shutdown(nil))
+
+	r := &progressRecorder{}
+	s.scanWorkspace(r.context())
+	assert.Empty(t, s.cache.All())
+	require.NotEmpty(t, r.values)
+	assert.Equal(t, map[string]any{"kind": "end", "message": "Cancelled"}, r.values[len(r.values)-1])
+}
+
+func TestWorkDoneProgressCancel(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	s.workDoneProgress = true
+	ctx, cancel := context.WithCancel(context.Background())
+	p := s.beginProgress((&progressRecorder{}).context(), "Indexing", cancel)
+	require.NotNil(t, p)
+
+	require.NoError(t, s.workDoneProgressCancel(nil, &protocol.WorkDoneProgressCancelParams{
+		Token: protocol.ProgressToken{Value: p.token},
+	}))
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+}
+
+func TestIndexed_MaxFileSize(t *testing.T) {
+	dir := t.TempDir()
+	small := writeFile(t, filepath.Join(dir, "task.yaml"), buildTaskYAML)
+	large := writeFile(t, filepath.Join(dir, "generated.yaml"), strings.Repeat("# padding\n", 100))
+
+	s := New("test-lsp", "0.1.0")
+	s.roots = []string{workspace.URIFromPath(dir)}
+	s.settings.Scan.MaxFileSize = 100
+	assert.True(t, s.indexed(small))
+	assert.False(t, s.indexed(large))
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// scheduler runs debounced work keyed by URI. Scheduling work for a key
+// cancels the work already scheduled or running for it, so only the latest
+// request completes.
+type scheduler struct {
+	mu    sync.Mutex
+	tasks map[string]*scheduledTask
+}
+
+type scheduledTask struct {
+	timer  *time.Timer
+	cancel context.CancelFunc
+}
+
+func newScheduler() *scheduler {
+	return &scheduler{tasks: make(map[string]*scheduledTask)}
+}
+
+// schedule runs fn for key after delay, in its own goroutine. The context
+// passed to fn is cancelled when newer work is scheduled for key or the work
+// is cancelled; fn should check it before publishing results.
+func (s *scheduler) schedule(key string, delay time.Duration, fn func(ctx context.Context)) {
+	ctx, cancel := context.WithCancel(context.Background())
+	task := &scheduledTask{cancel: cancel}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stop(key)
+	s.tasks[key] = task
+	task.timer = time.AfterFunc(delay, func() {
+		defer s.done(key, task)
+		fn(ctx)
+	})
+}
+
+// cancel cancels the work scheduled or running for key.
+func (s *scheduler) cancel(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stop(key)
+}
+
+// cancelAll cancels all scheduled and running work.
+func (s *scheduler) cancelAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.tasks {
+		s.stop(key)
+	}
+}
+
+func (s *scheduler) stop(key string) {
+	if task, ok := s.tasks[key]; ok {
+		task.timer.Stop()
+		task.cancel()
+		delete(s.tasks, key)
+	}
+}
+
+// done forgets task once it has run, unless newer work replaced it.
+func (s *scheduler) done(key string, task *scheduledTask) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task.cancel()
+	if s.tasks[key] == task {
+		delete(s.tasks, key)
+	}
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduler_Debounces(t *testing.T) {
+	s := newScheduler()
+	var runs, last atomic.Int32
+	for i := int32(1); i <= 3; i++ {
+		s.schedule("file:///a.yaml", 20*time.Millisecond, func(ctx context.Context) {
+			runs.Add(1)
+			last.Store(i)
+		})
+	}
+
+	assert.Eventually(t, func() bool { return runs.Load() == 1 }, time.Second, 5*time.Millisecond)
+	time.Sleep(40 * time.Millisecond)
+	assert.Equal(t, int32(1), runs.Load(), "only the latest work runs")
+	assert.Equal(t, int32(3), last.Load())
+}
+
+func TestScheduler_CancelsRunningWork(t *testing.T) {
+	s := newScheduler()
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	s.schedule("file:///a.yaml", 0, func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+	})
+
+	<-started
+	s.schedule("file:///a.yaml", time.Hour, func(context.Context) {})
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("running work was not cancelled by newer work")
+	}
+	s.cancel("file:///a.yaml")
+}
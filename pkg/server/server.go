@@ -1,22 +1,70 @@
 package server
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	"github.com/tliron/commonlog"
 	protocol "github.com/tliron/glsp/protocol_3_16"
-	"github.com/tliron/glsp/server"
 
 	"github.com/vdemeester/tekton-lsp-go/pkg/cache"
+	"github.com/vdemeester/tekton-lsp-go/pkg/config"
 )
 
 var log = commonlog.GetLogger("tekton-lsp")
 
+// defaultDebounce is how long validation waits for edits to settle.
+const defaultDebounce = 300 * time.Millisecond
+
 // Server represents the Tekton LSP server
 type Server struct {
-	name    string
-	version string
-	glsp    *server.Server
-	handler protocol.Handler
-	cache   *cache.Cache
+	name       string
+	version    string
+	rpc        *rpcHandler
+	handler    protocol.Handler
+	handler317 handler317
+	cache      *cache.Cache
+	projects   projects
+
+	// scanCtx is cancelled on shutdown, stopping workspace scans.
+	scanCtx     context.Context
+	cancelScans context.CancelFunc
+
+	// scheduler debounces validation per URI; debounce is its delay.
+	scheduler *scheduler
+	debounce  time.Duration
+
+	mu sync.RWMutex
+	// roots are the workspace folder URIs.
+	roots []string
+	// catalogs are the URIs of the catalog directories indexed in addition
+	// to the roots, from project configuration files.
+	catalogs []string
+	// folderScans are the running scans of roots and catalogs.
+	folderScans []*folderScan
+	// settings is the configuration sent by the client.
+	settings config.Settings
+	// configurationSupport is true when the client answers
+	// workspace/configuration requests.
+	configurationSupport bool
+	// workDoneProgress is true when the client shows progress reported
+	// through work-done tokens; progressCancels holds the functions
+	// cancelling the work of the active tokens.
+	workDoneProgress bool
+	progressCancels  map[string]context.CancelFunc
+	progressID       int
+	// watchFiles is true when the client can register file watchers
+	// dynamically.
+	watchFiles bool
+	// pullDiagnostics is true when the client pulls diagnostics (LSP 3.17)
+	// instead of receiving them; refreshSupport when it also accepts
+	// workspace/diagnostic/refresh requests.
+	pullDiagnostics bool
+	refreshSupport  bool
+
+	// resolvable holds the items of the last completion.
+	resolvable resolvable
 }
 
 // New creates a new Tekton LSP server
@@ -25,6 +73,15 @@ func New(name, version string) *Server {
 		name:    name,
 		version: version,
 		cache:   cache.New(),
+
+		projects: projects{files: make(map[string]projectFile), nearest: make(map[projectSearch]string)},
+
+		settings: config.Default(),
+
+		progressCancels: make(map[string]context.CancelFunc),
+
+		scheduler: newScheduler(),
+		debounce:  defaultDebounce,
 	}
 
 	// Initialize handler with lifecycle methods
@@ -37,15 +94,25 @@ func New(name, version string) *Server {
 		TextDocumentDidChange:      s.didChange,
 		TextDocumentDidClose:       s.didClose,
 		TextDocumentCompletion:     s.textDocumentCompletion,
+		CompletionItemResolve:      s.completionItemResolve,
 		TextDocumentHover:          s.textDocumentHover,
 		TextDocumentDocumentSymbol: s.textDocumentDocumentSymbol,
 		TextDocumentFormatting:     s.textDocumentFormatting,
 		TextDocumentDefinition:     s.textDocumentDefinition,
 		TextDocumentCodeAction:     s.textDocumentCodeAction,
+
+		WorkspaceDidChangeWatchedFiles:     s.didChangeWatchedFiles,
+		WorkspaceDidChangeConfiguration:    s.didChangeConfiguration,
+		WorkspaceDidChangeWorkspaceFolders: s.didChangeWorkspaceFolders,
+		WindowWorkDoneProgressCancel:       s.workDoneProgressCancel,
+	}
+	s.handler317 = handler317{
+		TextDocumentDiagnostic: s.textDocumentDiagnostic,
+		WorkspaceDiagnostic:    s.workspaceDiagnostic,
 	}
 
-	// Create GLSP server
-	s.glsp = server.NewServer(&s.handler, name, false)
+	s.scanCtx, s.cancelScans = context.WithCancel(context.Background())
+	s.rpc = newRPCHandler(s)
 
 	return s
 }
@@ -53,11 +120,18 @@ func New(name, version string) *Server {
 // RunStdio runs the server using stdio transport
 func (s *Server) RunStdio() error {
 	log.Info("Starting Tekton LSP server (stdio)")
-	return s.glsp.RunStdio()
+	s.serve(stdio{})
+	return nil
 }
 
 // RunTCP runs the server using TCP transport
 func (s *Server) RunTCP(address string) error {
 	log.Infof("Starting Tekton LSP server (TCP: %s)", address)
-	return s.glsp.RunTCP(address)
+	return s.listen(address)
+}
+
+// SetDebounce sets how long validation waits for edits to settle before
+// publishing diagnostics.
+func (s *Server) SetDebounce(d time.Duration) {
+	s.debounce = d
 }
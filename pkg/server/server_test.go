@@ -1,10 +1,15 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
 func TestNew(t *testing.T) {
@@ -13,7 +18,7 @@ func TestNew(t *testing.T) {
 	assert.NotNil(t, s)
 	assert.Equal(t, "test-lsp", s.name)
 	assert.Equal(t, "0.1.0", s.version)
-	assert.NotNil(t, s.glsp)
+	assert.NotNil(t, s.rpc)
 }
 
 func TestServer_HasDocumentCache(t *testing.T) {
@@ -21,3 +26,46 @@ func TestServer_HasDocumentCache(t *testing.T) {
 
 	require.NotNil(t, s.cache, "server should have a document cache")
 }
+
+func TestInitialize_ConcurrentReads(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	s.debounce = 0
+	s.cache.Open("file:///task.yaml", "yaml", 1, invalidTask)
+	glspContext := &glsp.Context{
+		Notify: func(string, any) {},
+		Call:   func(string, any, any) {},
+		Params: json.RawMessage(`{"capabilities":{
+			"textDocument":{"diagnostic":{}},
+			"workspace":{"configuration":true,"diagnostics":{"refreshSupport":true}},
+			"window":{"workDoneProgress":true}}}`),
+	}
+	var params protocol.InitializeParams
+	require.NoError(t, json.Unmarshal(glspContext.Params, &params))
+
+	// initialize runs on its own goroutine, concurrently with the handlers
+	// reading the client capabilities; run with -race.
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, err := s.initialize(glspContext, &params)
+		assert.NoError(t, err)
+	}()
+	go func() {
+		defer wg.Done()
+		s.publishDiagnostics(glspContext, "file:///task.yaml", 0)
+		s.revalidateAll(glspContext, 0)
+		if p := s.beginProgress(glspContext, "Indexing", func() {}); p != nil {
+			p.end("done")
+		}
+	}()
+	wg.Wait()
+
+	pull, refresh := s.diagnosticsMode()
+	assert.True(t, pull)
+	assert.True(t, refresh)
+	assert.NotNil(t, s.beginProgress(glspContext, "Indexing", context.CancelFunc(func() {})))
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	assert.True(t, s.configurationSupport)
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 
+	"github.com/vdemeester/tekton-lsp-go/pkg/config"
 	"github.com/vdemeester/tekton-lsp-go/pkg/symbols"
 )
 
@@ -13,6 +14,9 @@ func (s *Server) textDocumentDocumentSymbol(context *glsp.Context, params *proto
 	if !ok {
 		return nil, nil
 	}
+	if !s.enabled(params.TextDocument.URI, config.FeatureSymbols) {
+		return nil, nil
+	}
 
 	var allSyms []symbols.Symbol
 	for _, doc := range docs {
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// codeRequestCancelled is the LSP error code for cancelled requests.
+const codeRequestCancelled = -32800
+
+// rpcHandler dispatches JSON-RPC messages to the LSP handlers.
+//
+// Notifications run in order on the connection's read loop, so document
+// changes are applied in the order the client sent them and a request always
+// sees the changes sent before it. Requests run in their own goroutine and can
+// be cancelled with $/cancelRequest.
+type rpcHandler struct {
+	server *Server
+
+	mu       sync.Mutex
+	requests map[jsonrpc2.ID]*pendingRequest
+	contexts map[*glsp.Context]context.Context
+}
+
+// pendingRequest is a request being handled.
+type pendingRequest struct {
+	cancel context.CancelFunc
+	reply  sync.Once
+}
+
+func newRPCHandler(s *Server) *rpcHandler {
+	return &rpcHandler{
+		server:   s,
+		requests: make(map[jsonrpc2.ID]*pendingRequest),
+		contexts: make(map[*glsp.Context]context.Context),
+	}
+}
+
+// Handle implements jsonrpc2.Handler.
+func (h *rpcHandler) Handle(ctx context.Context, conn *jsonrpc2.Conn, req *jsonrpc2.Request) {
+	glspContext := &glsp.Context{
+		Method: req.Method,
+		Notify: func(method string, params any) {
+			if err := conn.Notify(ctx, method, params); err != nil {
+				log.Errorf("%s", err.Error())
+			}
+		},
+		Call: func(method string, params any, result any) {
+			if err := conn.Call(ctx, method, params, result); err != nil {
+				log.Errorf("%s", err.Error())
+			}
+		},
+	}
+	if req.Params != nil {
+		glspContext.Params = *req.Params
+	}
+
+	switch {
+	case req.Method == string(protocol.MethodCancelRequest):
+		h.cancelRequest(ctx, conn, glspContext.Params)
+	case req.Method == "exit":
+		h.server.handle(glspContext)
+		conn.Close()
+	case req.Notif:
+		if _, _, _, err := h.server.handle(glspContext); err != nil {
+			log.Errorf("%s: %s", req.Method, err.Error())
+		}
+	default:
+		// Register before returning to the read loop so that a cancellation
+		// following the request always finds it.
+		reqCtx, cancel := context.WithCancel(ctx)
+		pending := &pendingRequest{cancel: cancel}
+		h.mu.Lock()
+		h.requests[req.ID] = pending
+		h.contexts[glspContext] = reqCtx
+		h.mu.Unlock()
+
+		go func() {
+			defer h.done(req.ID, glspContext)
+			result, respErr := h.call(glspContext)
+			pending.reply.Do(func() {
+				if respErr != nil {
+					conn.ReplyWithError(ctx, req.ID, respErr)
+				} else {
+					conn.Reply(ctx, req.ID, result)
+				}
+			})
+		}()
+	}
+}
+
+// call runs a request handler and maps its outcome to a JSON-RPC response.
+func (h *rpcHandler) call(glspContext *glsp.Context) (any, *jsonrpc2.Error) {
+	r, validMethod, validParams, err := h.server.handle(glspContext)
+	switch {
+	case !validMethod:
+		return nil, &jsonrpc2.Error{
+			Code:    jsonrpc2.CodeMethodNotFound,
+			Message: fmt.Sprintf("method not supported: %s", glspContext.Method),
+		}
+	case !validParams:
+		respErr := &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidParams}
+		if err != nil {
+			respErr.Message = err.Error()
+		}
+		return nil, respErr
+	case err != nil:
+		return nil, &jsonrpc2.Error{Code: jsonrpc2.CodeInvalidRequest, Message: err.Error()}
+	}
+	return r, nil
+}
+
+// cancelRequest handles $/cancelRequest: the request's context is cancelled
+// and the client gets a RequestCancelled error right away, whether or not the
+// handler notices.
+func (h *rpcHandler) cancelRequest(ctx context.Context, conn *jsonrpc2.Conn, params json.RawMessage) {
+	var p struct {
+		ID jsonrpc2.ID `json:"id"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		log.Warningf("Invalid $/cancelRequest: %v", err)
+		return
+	}
+
+	h.mu.Lock()
+	pending, ok := h.requests[p.ID]
+	h.mu.Unlock()
+	if !ok {
+		return // Already answered.
+	}
+	pending.cancel()
+	pending.reply.Do(func() {
+		conn.ReplyWithError(ctx, p.ID, &jsonrpc2.Error{Code: codeRequestCancelled, Message: "request cancelled"})
+	})
+}
+
+func (h *rpcHandler) done(id jsonrpc2.ID, glspContext *glsp.Context) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if pending, ok := h.requests[id]; ok {
+		pending.cancel()
+		delete(h.requests, id)
+	}
+	delete(h.contexts, glspContext)
+}
+
+// requestContext returns the context of the request being handled with
+// glspContext. It is cancelled when the client cancels the request. Outside
+// of a request it never is.
+func (h *rpcHandler) requestContext(glspContext *glsp.Context) context.Context {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if ctx, ok := h.contexts[glspContext]; ok {
+		return ctx
+	}
+	return context.Background()
+}
+
+// serve handles a client connection until it disconnects.
+func (s *Server) serve(stream io.ReadWriteCloser) {
+	conn := jsonrpc2.NewConn(context.Background(), jsonrpc2.NewBufferedStream(stream, jsonrpc2.VSCodeObjectCodec{}), s.rpc)
+	<-conn.DisconnectNotify()
+}
+
+// stdio is the server's standard input and output as a single stream.
+type stdio struct{}
+
+func (stdio) Read(p []byte) (int, error)  { return os.Stdin.Read(p) }
+func (stdio) Write(p []byte) (int, error) { return os.Stdout.Write(p) }
+
+func (stdio) Close() error {
+	if err := os.Stdin.Close(); err != nil {
+		return err
+	}
+	return os.Stdout.Close()
+}
+
+// listen accepts TCP connections on address and serves each of them.
+func (s *Server) listen(address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		log.Infof("Accepted TCP connection from %s", conn.RemoteAddr())
+		go s.serve(conn)
+	}
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonrpc2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// connect serves s over an in-memory pipe, initializes it with params and
+// returns the client side. Published diagnostics are recorded in n.
+func connect(t *testing.T, s *Server, params any, n *notifications) (*jsonrpc2.Conn, map[string]any) {
+	t.Helper()
+	serverSide, clientSide := net.Pipe()
+	go s.serve(serverSide)
+	conn := jsonrpc2.NewConn(context.Background(), jsonrpc2.NewBufferedStream(clientSide, jsonrpc2.VSCodeObjectCodec{}),
+		jsonrpc2.HandlerWithError(func(_ context.Context, _ *jsonrpc2.Conn, req *jsonrpc2.Request) (any, error) {
+			var p protocol.PublishDiagnosticsParams
+			if req.Method == protocol.ServerTextDocumentPublishDiagnostics && req.Params != nil && json.Unmarshal(*req.Params, &p) == nil {
+				n.context().Notify(req.Method, &p)
+			}
+			return nil, nil
+		}))
+	t.Cleanup(func() { conn.Close() })
+
+	var result map[string]any
+	require.NoError(t, conn.Call(context.Background(), "initialize", params, &result))
+	return conn, result
+}
+
+func TestTransport_CancelRequest(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	s.handler.TextDocumentHover = func(context *glsp.Context, params *protocol.HoverParams) (*protocol.Hover, error) {
+		close(started)
+		<-s.rpc.requestContext(context).Done()
+		close(cancelled)
+		return nil, nil
+	}
+	conn, _ := connect(t, s, protocol.InitializeParams{}, &notifications{})
+
+	errs := make(chan error, 1)
+	go func() {
+		var result any
+		errs <- conn.Call(context.Background(), "textDocument/hover", protocol.HoverParams{}, &result, jsonrpc2.PickID(jsonrpc2.ID{Num: 42}))
+	}()
+
+	<-started
+	// The hover request blocks its handler, not the connection.
+	var symbols any
+	require.NoError(t, conn.Call(context.Background(), "textDocument/documentSymbol", protocol.DocumentSymbolParams{}, &symbols))
+
+	require.NoError(t, conn.Notify(context.Background(), "$/cancelRequest", map[string]any{"id": 42}))
+	select {
+	case err := <-errs:
+		var rpcErr *jsonrpc2.Error
+		require.ErrorAs(t, err, &rpcErr)
+		assert.Equal(t, int64(codeRequestCancelled), rpcErr.Code)
+	case <-time.After(time.Second):
+		t.Fatal("cancelled request was not answered")
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("request context was not cancelled")
+	}
+}
+
+func TestTransport_NotificationsRunInOrder(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	s.SetDebounce(10 * time.Millisecond)
+	n := &notifications{}
+	conn, _ := connect(t, s, protocol.InitializeParams{}, n)
+
+	ctx := context.Background()
+	require.NoError(t, conn.Notify(ctx, "textDocument/didOpen", protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: "file:///task.yaml", LanguageID: "yaml", Version: 1, Text: "kind: Task\n"},
+	}))
+	for version := int32(2); version <= 20; version++ {
+		require.NoError(t, conn.Notify(ctx, "textDocument/didChange", protocol.DidChangeTextDocumentParams{
+			TextDocument: protocol.VersionedTextDocumentIdentifier{
+				TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: "file:///task.yaml"},
+				Version:                version,
+			},
+			ContentChanges: []any{protocol.TextDocumentContentChangeEventWhole{Text: "kind: Task\n"}},
+		}))
+	}
+	// A request sees every change sent before it.
+	var symbols any
+	require.NoError(t, conn.Call(ctx, "textDocument/documentSymbol", protocol.DocumentSymbolParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: "file:///task.yaml"},
+	}, &symbols))
+
+	entry, ok := s.cache.Get("file:///task.yaml")
+	require.True(t, ok)
+	assert.Equal(t, int32(20), entry.Version)
+
+	// Diagnostics are pushed once typing settles, not for every version.
+	assert.Eventually(t, func() bool { return len(n.published()) > 0 }, time.Second, 5*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	assert.Less(t, len(n.published()), 20)
+}
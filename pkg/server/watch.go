@@ -0,0 +1,81 @@
+package server
+
+import (
+	"path/filepath"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/workspace"
+)
+
+// watchedFilesRegistrationID identifies the file watcher registration.
+const watchedFilesRegistrationID = "tekton-lsp-watched-files"
+
+// registerFileWatchers asks the client to notify the server of changes to
+// YAML files in the workspace, so files created, deleted or modified outside
+// the editor (git checkout, code generators) reach the index, and to
+// .gitignore files, which select the indexed files.
+func (s *Server) registerFileWatchers(context *glsp.Context) {
+	params := protocol.RegistrationParams{
+		Registrations: []protocol.Registration{{
+			ID:     watchedFilesRegistrationID,
+			Method: string(protocol.MethodWorkspaceDidChangeWatchedFiles),
+			RegisterOptions: protocol.DidChangeWatchedFilesRegistrationOptions{
+				Watchers: []protocol.FileSystemWatcher{
+					{GlobPattern: "**/*.{yaml,yml}"},
+					{GlobPattern: "**/" + workspace.GitIgnoreFile},
+				},
+			},
+		}},
+	}
+	// The client answers asynchronously; calling from the handler goroutine
+	// would block the connection.
+	go context.Call(protocol.ServerClientRegisterCapability, params, nil)
+}
+
+// didChangeWatchedFiles handles the workspace/didChangeWatchedFiles
+// notification: changed files are reloaded from disk (or dropped when
+// deleted) and the open documents depending on them are re-validated.
+func (s *Server) didChangeWatchedFiles(context *glsp.Context, params *protocol.DidChangeWatchedFilesParams) error {
+	var uris []string
+	projectChanged := false
+	for _, change := range params.Changes {
+		if filepath.Base(workspace.PathFromURI(change.URI)) == workspace.GitIgnoreFile {
+			log.Infof("Ignore file changed: %s (type %d)", change.URI, change.Type)
+			projectChanged = true
+			continue
+		}
+		if workspace.IsYAML(change.URI) {
+			projectChanged = projectChanged || isProjectFile(change.URI)
+			log.Infof("Watched file changed: %s (type %d)", change.URI, change.Type)
+			uris = append(uris, change.URI)
+		}
+	}
+	if len(uris) == 0 && !projectChanged {
+		return nil
+	}
+
+	s.trackDependents(context, uris, func() {
+		for _, uri := range uris {
+			if s.excluded(uri) {
+				if !s.cache.IsOpen(uri) {
+					s.cache.Remove(uri)
+				}
+				continue
+			}
+			// Reload also drops files that no longer exist, whatever the
+			// event type says, and leaves documents open in the editor alone.
+			workspace.Reload(uri, s.cache)
+		}
+	})
+	if projectChanged {
+		// Project and .gitignore files select the indexed files alike.
+		s.projectFileSaved(context)
+	}
+	if pull, refresh := s.diagnosticsMode(); pull && refresh {
+		// Workspace diagnostics of the changed files are stale too.
+		s.requestDiagnosticRefresh(context)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/index"
+	"github.com/vdemeester/tekton-lsp-go/pkg/workspace"
+)
+
+// notifications records the diagnostics published through a glsp.Context.
+type notifications struct {
+	mu    sync.Mutex
+	uris  []string
+	diags map[string][]protocol.Diagnostic
+}
+
+func (n *notifications) context() *glsp.Context {
+	return &glsp.Context{
+		Notify: func(method string, params any) {
+			if p, ok := params.(*protocol.PublishDiagnosticsParams); ok {
+				n.mu.Lock()
+				n.uris = append(n.uris, p.URI)
+				if n.diags == nil {
+					n.diags = make(map[string][]protocol.Diagnostic)
+				}
+				n.diags[p.URI] = p.Diagnostics
+				n.mu.Unlock()
+			}
+		},
+	}
+}
+
+// last returns the diagnostics last published for uri.
+func (n *notifications) last(uri string) []protocol.Diagnostic {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.diags[uri]
+}
+
+func (n *notifications) published() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]string(nil), n.uris...)
+}
+
+func TestDidChangeWatchedFiles(t *testing.T) {
+	dir := t.TempDir()
+	taskPath := filepath.Join(dir, "task.yaml")
+	taskURI := workspace.URIFromPath(taskPath)
+	pipelineURI := workspace.URIFromPath(filepath.Join(dir, "pipeline.yaml"))
+	build := index.Key{Group: "tekton.dev", Kind: "Task", Name: "build"}
+
+	s := New("test-lsp", "0.1.0")
+	s.cache.Open(pipelineURI, "yaml", 1, `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: ci
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+`)
+
+	// Created.
+	require.NoError(t, os.WriteFile(taskPath, []byte("apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build\n"), 0o644))
+	n := &notifications{}
+	require.NoError(t, s.didChangeWatchedFiles(n.context(), &protocol.DidChangeWatchedFilesParams{
+		Changes: []protocol.FileEvent{{URI: taskURI, Type: protocol.FileChangeTypeCreated}},
+	}))
+	assert.NotNil(t, s.cache.Index().Resolve(build))
+	assert.Eventually(t, func() bool { return len(n.published()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{pipelineURI}, n.published(), "the open pipeline referencing the task is re-validated")
+
+	// Deleted.
+	require.NoError(t, os.Remove(taskPath))
+	n = &notifications{}
+	require.NoError(t, s.didChangeWatchedFiles(n.context(), &protocol.DidChangeWatchedFilesParams{
+		Changes: []protocol.FileEvent{{URI: taskURI, Type: protocol.FileChangeTypeDeleted}},
+	}))
+	assert.Nil(t, s.cache.Index().Resolve(build))
+	assert.Eventually(t, func() bool { return len(n.published()) == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestDidChangeWatchedFiles_KeepsOpenBuffers(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "task.yaml")
+	uri := workspace.URIFromPath(path)
+	require.NoError(t, os.WriteFile(path, []byte("kind: Task # disk\n"), 0o644))
+
+	s := New("test-lsp", "0.1.0")
+	s.cache.Open(uri, "yaml", 1, "kind: Task # editor\n")
+
+	n := &notifications{}
+	require.NoError(t, s.didChangeWatchedFiles(n.context(), &protocol.DidChangeWatchedFilesParams{
+		Changes: []protocol.FileEvent{
+			{URI: uri, Type: protocol.FileChangeTypeChanged},
+			{URI: workspace.URIFromPath(filepath.Join(dir, "README.md")), Type: protocol.FileChangeTypeCreated},
+		},
+	}))
+
+	entry, ok := s.cache.Get(uri)
+	require.True(t, ok)
+	assert.Equal(t, "kind: Task # editor\n", entry.Content)
+}
+
+func TestInitialize_WatchedFilesCapability(t *testing.T) {
+	s := New("test-lsp", "0.1.0")
+	var params protocol.InitializeParams
+	require.NoError(t, json.Unmarshal([]byte(`{"capabilities":{"workspace":{"didChangeWatchedFiles":{"dynamicRegistration":true}}}}`), &params))
+
+	_, err := s.initialize(&glsp.Context{}, &params)
+	require.NoError(t, err)
+	assert.True(t, s.watchFiles)
+}
+
+func TestDidChangeWatchedFiles_GitIgnore(t *testing.T) {
+	dir := t.TempDir()
+	ignorePath := filepath.Join(dir, ".gitignore")
+	taskPath := filepath.Join(dir, "generated", "task.yaml")
+	taskURI := workspace.URIFromPath(taskPath)
+	require.NoError(t, os.WriteFile(ignorePath, []byte("generated/\n"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Dir(taskPath), 0o755))
+	require.NoError(t, os.WriteFile(taskPath, []byte(buildTaskYAML), 0o644))
+
+	s := New("test-lsp", "0.1.0")
+	s.roots = []string{workspace.URIFromPath(dir)}
+
+	n := &notifications{}
+	require.NoError(t, s.didChangeWatchedFiles(n.context(), &protocol.DidChangeWatchedFilesParams{
+		Changes: []protocol.FileEvent{{URI: taskURI, Type: protocol.FileChangeTypeCreated}},
+	}))
+	_, ok := s.cache.Get(taskURI)
+	assert.False(t, ok, "files ignored by git are not indexed")
+
+	// Editing the .gitignore rescans the workspace.
+	require.NoError(t, os.WriteFile(ignorePath, []byte("# nothing ignored\n"), 0o644))
+	require.NoError(t, s.didChangeWatchedFiles(n.context(), &protocol.DidChangeWatchedFilesParams{
+		Changes: []protocol.FileEvent{{URI: workspace.URIFromPath(ignorePath), Type: protocol.FileChangeTypeChanged}},
+	}))
+	assert.Eventually(t, func() bool {
+		_, ok := s.cache.Get(taskURI)
+		return ok
+	}, time.Second, 10*time.Millisecond)
+}
@@ -1,8 +1,7 @@
 package symbols
 
 import (
-	"strings"
-
+	"github.com/vdemeester/tekton-lsp-go/pkg/model"
 	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
 )
 
@@ -25,7 +24,7 @@ type Symbol struct {
 
 // DocumentSymbols extracts an outline of symbols from a Tekton document.
 func DocumentSymbols(doc *parser.Document) []Symbol {
-	if !strings.Contains(doc.APIVersion, "tekton.dev") {
+	if !model.IsTekton(doc) {
 		return nil
 	}
 
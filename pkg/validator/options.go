@@ -0,0 +1,96 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/index"
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+// Options configures validation.
+type Options struct {
+	// Severities overrides the severity of rules, by rule name.
+	Severities map[string]Severity
+	// Disabled turns rules off, by rule name.
+	Disabled map[string]bool
+	// APIVersion is the targeted tekton.dev API version (e.g. "v1").
+	// Resources using an older version are reported with
+	// RuleDeprecatedAPIVersion. Empty disables the check.
+	APIVersion string
+	// Index, when set, enables the checks involving other files of the
+	// workspace, restricted to the files in Scope (nil for every file).
+	Index *index.Index
+	Scope func(uri string) bool
+}
+
+// ParseSeverity parses a severity name ("error", "warning", "info", "hint").
+func ParseSeverity(name string) (Severity, bool) {
+	switch strings.ToLower(name) {
+	case "error":
+		return SeverityError, true
+	case "warning":
+		return SeverityWarning, true
+	case "info", "information":
+		return SeverityInfo, true
+	case "hint":
+		return SeverityHint, true
+	}
+	return 0, false
+}
+
+// ValidateWithOptions validates a parsed YAML document like Validate, then
+// applies the rule configuration in opts.
+func ValidateWithOptions(doc *parser.Document, opts Options) []Diagnostic {
+	diags := Validate(doc)
+	var extra []Diagnostic
+	if d, ok := checkAPIVersion(doc, opts.APIVersion); ok {
+		extra = append(extra, d)
+	}
+	if opts.Index != nil && isTektonResource(doc) {
+		extra = append(extra, checkWorkspace(doc, opts.Index, opts.Scope)...)
+	}
+	diags = append(diags, suppress(doc, extra)...)
+
+	result := diags[:0]
+	for _, d := range diags {
+		if opts.Disabled[d.Code] {
+			continue
+		}
+		if severity, ok := opts.Severities[d.Code]; ok {
+			d.Severity = severity
+		}
+		result = append(result, d)
+	}
+	return result
+}
+
+// apiVersionRanks orders the tekton.dev API versions.
+var apiVersionRanks = map[string]int{
+	"v1alpha1": 1,
+	"v1beta1":  2,
+	"v1":       3,
+}
+
+// checkAPIVersion reports a tekton.dev resource older than target.
+func checkAPIVersion(doc *parser.Document, target string) (Diagnostic, bool) {
+	group, version, found := strings.Cut(doc.APIVersion, "/")
+	if target == "" || !found || group != "tekton.dev" {
+		return Diagnostic{}, false
+	}
+	rank, known := apiVersionRanks[version]
+	if !known || rank >= apiVersionRanks[target] {
+		return Diagnostic{}, false
+	}
+	node := doc.Root.Get("apiVersion")
+	if node == nil {
+		return Diagnostic{}, false
+	}
+	return Diagnostic{
+		Range:    node.ValueRange(0, len(node.ScalarValue)),
+		Severity: SeverityWarning,
+		Source:   "tekton-lsp",
+		Code:     RuleDeprecatedAPIVersion,
+		Message:  fmt.Sprintf("%s is older than the targeted API version tekton.dev/%s", doc.APIVersion, target),
+	}, true
+}
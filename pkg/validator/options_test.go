@@ -0,0 +1,64 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+func TestValidateWithOptions_Severities(t *testing.T) {
+	doc, err := parser.ParseYAML("test.yaml", `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  bogus: true
+  steps:
+    - name: build
+      image: golang
+      script: echo $(params.missing)
+`)
+	require.NoError(t, err)
+
+	diags := ValidateWithOptions(doc, Options{
+		Severities: map[string]Severity{RuleUnknownField: SeverityHint},
+		Disabled:   map[string]bool{RuleUndeclaredParam: true},
+	})
+	require.Len(t, diags, 1)
+	assert.Equal(t, RuleUnknownField, diags[0].Code)
+	assert.Equal(t, SeverityHint, diags[0].Severity)
+}
+
+func TestValidateWithOptions_APIVersion(t *testing.T) {
+	doc, err := parser.ParseYAML("test.yaml", `apiVersion: tekton.dev/v1beta1
+kind: Task
+metadata:
+  name: build
+spec:
+  steps:
+    - name: build
+      image: golang
+`)
+	require.NoError(t, err)
+
+	assert.Empty(t, ValidateWithOptions(doc, Options{}))
+	assert.Empty(t, ValidateWithOptions(doc, Options{APIVersion: "v1beta1"}))
+
+	diags := ValidateWithOptions(doc, Options{APIVersion: "v1"})
+	require.Len(t, diags, 1)
+	assert.Equal(t, RuleDeprecatedAPIVersion, diags[0].Code)
+	assert.Equal(t, uint32(0), diags[0].Range.Start.Line)
+	assert.Equal(t, uint32(12), diags[0].Range.Start.Character)
+}
+
+func TestParseSeverity(t *testing.T) {
+	sev, ok := ParseSeverity("Warning")
+	assert.True(t, ok)
+	assert.Equal(t, SeverityWarning, sev)
+
+	_, ok = ParseSeverity("fatal")
+	assert.False(t, ok)
+}
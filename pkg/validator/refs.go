@@ -4,25 +4,19 @@ import (
 	"fmt"
 	"regexp"
 
+	"github.com/vdemeester/tekton-lsp-go/pkg/model"
 	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
 )
 
 // paramRefRe matches $(params.NAME) references.
 var paramRefRe = regexp.MustCompile(`\$\(params\.([a-zA-Z_][\w-]*)\)`)
 
-// collectDeclaredParams extracts declared parameter names from a spec.
-func collectDeclaredParams(spec *parser.Node) map[string]bool {
-	params := make(map[string]bool)
-	if spec == nil {
-		return params
-	}
-	p := spec.Get("params")
-	if p == nil || !p.IsSequence() {
-		return params
-	}
-	for _, item := range p.AsSequence() {
-		if n := item.Get("name"); n != nil {
-			params[n.AsScalar()] = true
+// declaredParams returns the names of declared parameters.
+func declaredParams(specs []*model.ParamSpec) map[string]bool {
+	params := make(map[string]bool, len(specs))
+	for _, p := range specs {
+		if p.Name.IsSet() {
+			params[p.Name.Value] = true
 		}
 	}
 	return params
@@ -38,14 +32,16 @@ func findParamRefs(node *parser.Node, declared map[string]bool) []Diagnostic {
 	var diags []Diagnostic
 
 	if node.IsScalar() {
-		matches := paramRefRe.FindAllStringSubmatch(node.AsScalar(), -1)
+		value := node.AsScalar()
+		matches := paramRefRe.FindAllStringSubmatchIndex(value, -1)
 		for _, m := range matches {
-			name := m[1]
+			name := value[m[2]:m[3]]
 			if !declared[name] {
 				diags = append(diags, Diagnostic{
-					Range:    node.Range,
+					Range:    node.ValueRange(m[0], m[1]),
 					Severity: SeverityWarning,
 					Source:   "tekton-lsp",
+					Code:     RuleUndeclaredParam,
 					Message:  fmt.Sprintf("Reference to undeclared parameter '%s'", name),
 				})
 			}
@@ -66,24 +62,22 @@ func findParamRefs(node *parser.Node, declared map[string]bool) []Diagnostic {
 	return diags
 }
 
-// validateStepImages checks that every step has an image field.
-func validateStepImages(steps *parser.Node) []Diagnostic {
-	if steps == nil || !steps.IsSequence() {
-		return nil
-	}
-
+// validateStepImages checks that every step has an image field, unless it
+// runs a StepAction through a ref.
+func validateStepImages(steps []*model.Step) []Diagnostic {
 	var diags []Diagnostic
-	for _, step := range steps.AsSequence() {
-		if step.Get("image") == nil {
+	for _, step := range steps {
+		if !step.Image.IsSet() && step.Ref == nil {
 			// Get step name for better diagnostics.
 			stepName := "unnamed"
-			if n := step.Get("name"); n != nil {
-				stepName = n.AsScalar()
+			if step.Name.IsSet() {
+				stepName = step.Name.Value
 			}
 			diags = append(diags, Diagnostic{
-				Range:    step.Range,
+				Range:    step.Node.Range,
 				Severity: SeverityError,
 				Source:   "tekton-lsp",
+				Code:     RuleMissingField,
 				Message:  fmt.Sprintf("Step '%s' is missing required field 'image'", stepName),
 			})
 		}
@@ -92,38 +86,35 @@ func validateStepImages(steps *parser.Node) []Diagnostic {
 }
 
 // validatePipelineTasks checks taskRef.name and duplicate task names.
-func validatePipelineTasks(tasks *parser.Node) []Diagnostic {
-	if tasks == nil || !tasks.IsSequence() {
-		return nil
-	}
-
+func validatePipelineTasks(tasks []*model.PipelineTask) []Diagnostic {
 	var diags []Diagnostic
-	seen := make(map[string]*parser.Node)
+	seen := make(map[string]bool)
 
-	for _, task := range tasks.AsSequence() {
+	for _, task := range tasks {
 		// Check for duplicate task names.
-		if nameNode := task.Get("name"); nameNode != nil {
-			name := nameNode.AsScalar()
-			if prev, ok := seen[name]; ok {
-				_ = prev
+		if task.Name.IsSet() {
+			name := task.Name.Value
+			if seen[name] {
 				diags = append(diags, Diagnostic{
-					Range:    nameNode.Range,
+					Range:    task.Name.Node.Range,
 					Severity: SeverityWarning,
 					Source:   "tekton-lsp",
+					Code:     RuleDuplicateTaskName,
 					Message:  fmt.Sprintf("Duplicate task name '%s' in pipeline", name),
 				})
 			} else {
-				seen[name] = nameNode
+				seen[name] = true
 			}
 		}
 
-		// Check taskRef has name.
-		if taskRef := task.Get("taskRef"); taskRef != nil {
-			if taskRef.Get("name") == nil {
+		// Check taskRef has name, unless a resolver fetches the Task.
+		if ref := task.TaskRef; ref != nil {
+			if !ref.Name.IsSet() && !ref.Resolver.IsSet() {
 				diags = append(diags, Diagnostic{
-					Range:    taskRef.Range,
+					Range:    ref.Node.Range,
 					Severity: SeverityError,
 					Source:   "tekton-lsp",
+					Code:     RuleMissingField,
 					Message:  "Field 'taskRef' requires a 'name' field",
 				})
 			}
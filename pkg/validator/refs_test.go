@@ -27,6 +27,29 @@ spec:
 	assert.Contains(t, warnings[0].Message, "missing-param")
 }
 
+func TestValidate_ParamRefRangeInBlockScalar(t *testing.T) {
+	doc := parse(t, `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: test-task
+spec:
+  steps:
+    - name: build
+      image: golang:1.25
+      script: |
+        set -e
+        echo "building" $(params.missing)
+`)
+	diags := Validate(doc)
+	warnings := filterBySeverity(diags, SeverityWarning)
+	require.Len(t, warnings, 1)
+	// Points at "$(params.missing)" on line 10, not the whole script node.
+	assert.Equal(t, uint32(10), warnings[0].Range.Start.Line)
+	assert.Equal(t, uint32(24), warnings[0].Range.Start.Character)
+	assert.Equal(t, uint32(10), warnings[0].Range.End.Line)
+	assert.Equal(t, uint32(41), warnings[0].Range.End.Character)
+}
+
 func TestValidate_ParamRefToExistingParam(t *testing.T) {
 	doc := parse(t, `apiVersion: tekton.dev/v1
 kind: Task
@@ -124,6 +147,20 @@ spec:
 	assert.Empty(t, diags)
 }
 
+func TestValidate_Task_StepRefNeedsNoImage(t *testing.T) {
+	doc := parse(t, `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: test-task
+spec:
+  steps:
+    - name: build
+      ref:
+        name: go-build
+`)
+	assert.Empty(t, Validate(doc), "the StepAction provides the image")
+}
+
 // -- taskRef.name required --
 
 func TestValidate_Pipeline_TaskRefMissingName(t *testing.T) {
@@ -147,6 +184,23 @@ spec:
 	assert.True(t, hasRefError, "taskRef without name should produce error")
 }
 
+func TestValidate_Pipeline_TaskRefResolver(t *testing.T) {
+	doc := parse(t, `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: test
+spec:
+  tasks:
+    - name: clone
+      taskRef:
+        resolver: hub
+        params:
+          - name: name
+            value: git-clone
+`)
+	assert.Empty(t, Validate(doc), "resolver references have no name")
+}
+
 // -- duplicate task names --
 
 func TestValidate_Pipeline_DuplicateTaskNames(t *testing.T) {
@@ -0,0 +1,105 @@
+package validator
+
+import (
+	"strings"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+// suppressionPrefix starts an inline suppression comment:
+//
+//	bogus: value # tekton-lsp: ignore=unknown-field
+//	# tekton-lsp: ignore
+//	- name: step
+//
+// A trailing comment applies to the node on its line, a comment on its own
+// line to the node below it; a comment at the top of a document therefore
+// applies to the whole document. Without "=rules" every rule is suppressed.
+const suppressionPrefix = "tekton-lsp:"
+
+// suppression silences diagnostics for some rules within a range.
+type suppression struct {
+	scope parser.Range
+	rules map[string]bool // nil means all rules
+}
+
+func (s suppression) matches(d Diagnostic) bool {
+	if !positionInRange(d.Range.Start, s.scope) {
+		return false
+	}
+	return s.rules == nil || s.rules[d.Code]
+}
+
+// suppress drops diagnostics silenced by inline suppression comments.
+func suppress(doc *parser.Document, diags []Diagnostic) []Diagnostic {
+	sups := collectSuppressions(doc)
+	if len(sups) == 0 || len(diags) == 0 {
+		return diags
+	}
+
+	result := diags[:0]
+	for _, d := range diags {
+		suppressed := false
+		for _, s := range sups {
+			if s.matches(d) {
+				suppressed = true
+				break
+			}
+		}
+		if !suppressed {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+func collectSuppressions(doc *parser.Document) []suppression {
+	var sups []suppression
+	for _, c := range doc.Comments {
+		if c.Node == nil {
+			continue
+		}
+		rules, ok := parseSuppression(c.Text)
+		if !ok {
+			continue
+		}
+		sups = append(sups, suppression{scope: c.Node.Range, rules: rules})
+	}
+	return sups
+}
+
+// parseSuppression parses "tekton-lsp: ignore[=rule,...]". It returns nil
+// rules when every rule is suppressed.
+func parseSuppression(text string) (map[string]bool, bool) {
+	rest, ok := strings.CutPrefix(text, suppressionPrefix)
+	if !ok {
+		return nil, false
+	}
+	directive, list, hasList := strings.Cut(strings.TrimSpace(rest), "=")
+	if strings.TrimSpace(directive) != "ignore" {
+		return nil, false
+	}
+	if !hasList {
+		return nil, true
+	}
+	rules := make(map[string]bool)
+	for _, r := range strings.Split(list, ",") {
+		if r = strings.TrimSpace(r); r != "" {
+			rules[r] = true
+		}
+	}
+	return rules, true
+}
+
+func positionInRange(pos parser.Position, r parser.Range) bool {
+	if pos.Line < r.Start.Line || pos.Line > r.End.Line {
+		return false
+	}
+	if pos.Line == r.Start.Line && pos.Character < r.Start.Character {
+		return false
+	}
+	if pos.Line == r.End.Line && pos.Character > r.End.Character {
+		return false
+	}
+	return true
+}
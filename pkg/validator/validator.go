@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/vdemeester/tekton-lsp-go/pkg/model"
 	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+	"github.com/vdemeester/tekton-lsp-go/pkg/schema"
 )
 
 // Severity represents the severity of a diagnostic.
@@ -17,11 +19,44 @@ const (
 	SeverityHint    Severity = 4
 )
 
+// Rule identifiers, reported as the diagnostic code. They are also the names
+// used by inline suppressions (# tekton-lsp: ignore=unknown-field).
+const (
+	RuleMissingField      = "missing-field"
+	RuleUnknownField      = "unknown-field"
+	RuleInvalidType       = "invalid-type"
+	RuleEmptyList         = "empty-list"
+	RuleDuplicateTaskName = "duplicate-task-name"
+	RuleUndeclaredParam   = "undeclared-param"
+	// RuleDuplicateResource, RuleUnknownParam and RuleMissingParam are only
+	// reported by ValidateWithOptions, with an index.
+	RuleDuplicateResource = "duplicate-resource"
+	RuleUnknownParam      = "unknown-param"
+	RuleMissingParam      = "missing-param"
+	// RuleDeprecatedAPIVersion is only reported by ValidateWithOptions.
+	RuleDeprecatedAPIVersion = "deprecated-api-version"
+)
+
+// Rules lists every rule identifier.
+var Rules = []string{
+	RuleMissingField,
+	RuleUnknownField,
+	RuleInvalidType,
+	RuleEmptyList,
+	RuleDuplicateTaskName,
+	RuleUndeclaredParam,
+	RuleDeprecatedAPIVersion,
+	RuleDuplicateResource,
+	RuleUnknownParam,
+	RuleMissingParam,
+}
+
 // Diagnostic represents a validation issue at a specific location.
 type Diagnostic struct {
 	Range    parser.Range
 	Severity Severity
 	Source   string
+	Code     string
 	Message  string
 }
 
@@ -34,98 +69,6 @@ var tektonAPIVersions = []string{
 	"triggers.tekton.dev/v1alpha1",
 }
 
-// knownPipelineSpecFields are the valid fields in a Pipeline spec.
-var knownPipelineSpecFields = map[string]bool{
-	"tasks":       true,
-	"params":      true,
-	"workspaces":  true,
-	"results":     true,
-	"finally":     true,
-	"description": true,
-}
-
-// knownTaskSpecFields are the valid fields in a Task spec.
-var knownTaskSpecFields = map[string]bool{
-	"steps":        true,
-	"params":       true,
-	"workspaces":   true,
-	"results":      true,
-	"description":  true,
-	"volumes":      true,
-	"sidecars":     true,
-	"stepTemplate": true,
-}
-
-// knownParamFields are the valid fields in a param definition.
-var knownParamFields = map[string]bool{
-	"name":        true,
-	"type":        true,
-	"description": true,
-	"default":     true,
-	"properties":  true,
-	"enum":        true,
-}
-
-// knownStepFields are the valid fields in a step definition.
-var knownStepFields = map[string]bool{
-	"name":             true,
-	"image":            true,
-	"command":          true,
-	"args":             true,
-	"script":           true,
-	"env":              true,
-	"envFrom":          true,
-	"workingDir":       true,
-	"volumeMounts":     true,
-	"resources":        true,
-	"securityContext":  true,
-	"timeout":          true,
-	"onError":          true,
-	"stdoutConfig":     true,
-	"stderrConfig":     true,
-	"params":           true,
-	"ref":              true,
-	"computeResources": true,
-	"workspaces":       true,
-	"results":          true,
-}
-
-// knownWorkspaceFields are the valid fields in a workspace declaration.
-var knownWorkspaceFields = map[string]bool{
-	"name":        true,
-	"description": true,
-	"mountPath":   true,
-	"readOnly":    true,
-	"optional":    true,
-}
-
-// knownResultFields are the valid fields in a result definition.
-var knownResultFields = map[string]bool{
-	"name":        true,
-	"type":        true,
-	"description": true,
-	"properties":  true,
-	"value":       true,
-}
-
-// knownPipelineTaskFields are the valid fields in a pipeline task entry.
-var knownPipelineTaskFields = map[string]bool{
-	"name":        true,
-	"taskRef":     true,
-	"taskSpec":    true,
-	"runAfter":    true,
-	"params":      true,
-	"workspaces":  true,
-	"matrix":      true,
-	"when":        true,
-	"timeout":     true,
-	"retries":     true,
-	"onError":     true,
-	"displayName": true,
-	"description": true,
-	"pipelineRef": true,
-}
-
 // isTektonResource checks if the document is a Tekton resource.
 func isTektonResource(doc *parser.Document) bool {
 	for _, prefix := range tektonAPIVersions {
@@ -142,42 +85,65 @@ func Validate(doc *parser.Document) []Diagnostic {
 		return nil
 	}
 
-	var diags []Diagnostic
+	obj := model.FromDocument(doc)
+	if obj == nil {
+		return nil
+	}
 
 	// Validate metadata
-	diags = append(diags, validateMetadata(doc)...)
+	diags := validateMetadata(obj.Meta())
 
 	// Resource-specific validation
-	switch doc.Kind {
-	case "Pipeline":
-		diags = append(diags, validatePipeline(doc)...)
-	case "Task", "ClusterTask":
-		diags = append(diags, validateTask(doc)...)
+	switch obj := obj.(type) {
+	case *model.Pipeline:
+		diags = append(diags, validatePipeline(obj)...)
+	case *model.Task:
+		diags = append(diags, validateTask(obj)...)
 	}
 
-	return diags
+	return suppress(doc, dedupe(diags))
+}
+
+// dedupe drops repeated diagnostics. Nodes shared through YAML aliases and
+// merge keys are visited once per use but report at the anchor's location.
+func dedupe(diags []Diagnostic) []Diagnostic {
+	if len(diags) < 2 {
+		return diags
+	}
+	seen := make(map[Diagnostic]bool, len(diags))
+	result := diags[:0]
+	for _, d := range diags {
+		if seen[d] {
+			continue
+		}
+		seen[d] = true
+		result = append(result, d)
+	}
+	return result
 }
 
-func validateMetadata(doc *parser.Document) []Diagnostic {
+func validateMetadata(r *model.Resource) []Diagnostic {
 	var diags []Diagnostic
 
-	metadata := doc.Root.Get("metadata")
+	metadata := r.Doc.Root.Get("metadata")
 	if metadata == nil {
 		// metadata itself is missing — point to root
 		diags = append(diags, Diagnostic{
-			Range:    doc.Root.Range,
+			Range:    r.Doc.Root.Range,
 			Severity: SeverityError,
 			Source:   "tekton-lsp",
+			Code:     RuleMissingField,
 			Message:  "Required field 'metadata' is missing",
 		})
 		return diags
 	}
 
-	if metadata.Get("name") == nil && metadata.Get("generateName") == nil {
+	if !r.Name.IsSet() && !r.GenerateName.IsSet() {
 		diags = append(diags, Diagnostic{
 			Range:    metadata.Range,
 			Severity: SeverityError,
 			Source:   "tekton-lsp",
+			Code:     RuleMissingField,
 			Message:  "Required field 'metadata.name' or 'metadata.generateName' is missing",
 		})
 	}
@@ -185,16 +151,16 @@ func validateMetadata(doc *parser.Document) []Diagnostic {
 	return diags
 }
 
-func validatePipeline(doc *parser.Document) []Diagnostic {
+func validatePipeline(p *model.Pipeline) []Diagnostic {
 	var diags []Diagnostic
 
-	spec := doc.Root.Get("spec")
+	spec := p.Spec
 	if spec == nil {
 		return diags
 	}
 
 	// Check for unknown fields
-	diags = append(diags, checkUnknownFields(spec, knownPipelineSpecFields)...)
+	diags = append(diags, checkUnknownFields(p.Doc.Kind, spec, "spec", "spec")...)
 
 	// Validate tasks
 	tasks := spec.Get("tasks")
@@ -207,6 +173,7 @@ func validatePipeline(doc *parser.Document) []Diagnostic {
 			Range:    tasks.Range,
 			Severity: SeverityError,
 			Source:   "tekton-lsp",
+			Code:     RuleInvalidType,
 			Message:  "Field 'tasks' must be an array/sequence",
 		})
 		return diags
@@ -217,53 +184,48 @@ func validatePipeline(doc *parser.Document) []Diagnostic {
 			Range:    tasks.Range,
 			Severity: SeverityError,
 			Source:   "tekton-lsp",
+			Code:     RuleEmptyList,
 			Message:  "Pipeline must have at least one task",
 		})
 	}
 
 	// Validate individual tasks (taskRef.name, duplicate names)
-	diags = append(diags, validatePipelineTasks(tasks)...)
+	diags = append(diags, validatePipelineTasks(p.Tasks)...)
 
 	// Validate pipeline task fields
-	diags = append(diags, validateSequenceItems(tasks, knownPipelineTaskFields, "pipeline task")...)
+	diags = append(diags, validateSequenceItems(p.Doc.Kind, tasks, "pipeline task", "spec", "tasks", "")...)
 
 	// Validate param fields
-	if params := spec.Get("params"); params != nil {
-		diags = append(diags, validateSequenceItems(params, knownParamFields, "param")...)
-	}
+	diags = append(diags, validateSequenceItems(p.Doc.Kind, spec.Get("params"), "param", "spec", "params", "")...)
 
 	// Validate workspace fields
-	if workspaces := spec.Get("workspaces"); workspaces != nil {
-		diags = append(diags, validateSequenceItems(workspaces, knownWorkspaceFields, "workspace")...)
-	}
+	diags = append(diags, validateSequenceItems(p.Doc.Kind, spec.Get("workspaces"), "workspace", "spec", "workspaces", "")...)
 
 	// Validate result fields
-	if results := spec.Get("results"); results != nil {
-		diags = append(diags, validateSequenceItems(results, knownResultFields, "result")...)
-	}
+	diags = append(diags, validateSequenceItems(p.Doc.Kind, spec.Get("results"), "result", "spec", "results", "")...)
 
 	// Validate finally task fields
-	if finally := spec.Get("finally"); finally != nil && finally.IsSequence() {
-		diags = append(diags, validateSequenceItems(finally, knownPipelineTaskFields, "finally task")...)
-	}
+	diags = append(diags, validateSequenceItems(p.Doc.Kind, spec.Get("finally"), "finally task", "spec", "finally", "")...)
 
 	// Validate param references
-	declaredParams := collectDeclaredParams(spec)
-	diags = append(diags, findParamRefs(tasks, declaredParams)...)
+	declaredParams := declaredParams(p.Params)
+	for _, t := range p.Tasks {
+		diags = append(diags, findParamRefs(t.Node, declaredParams)...)
+	}
 
 	return diags
 }
 
-func validateTask(doc *parser.Document) []Diagnostic {
+func validateTask(t *model.Task) []Diagnostic {
 	var diags []Diagnostic
 
-	spec := doc.Root.Get("spec")
+	spec := t.Spec
 	if spec == nil {
 		return diags
 	}
 
 	// Check for unknown fields
-	diags = append(diags, checkUnknownFields(spec, knownTaskSpecFields)...)
+	diags = append(diags, checkUnknownFields(t.Doc.Kind, spec, "spec", "spec")...)
 
 	// Validate steps
 	steps := spec.Get("steps")
@@ -272,6 +234,7 @@ func validateTask(doc *parser.Document) []Diagnostic {
 			Range:    spec.Range,
 			Severity: SeverityError,
 			Source:   "tekton-lsp",
+			Code:     RuleMissingField,
 			Message:  "Required field 'steps' is missing in Task spec",
 		})
 		return diags
@@ -282,6 +245,7 @@ func validateTask(doc *parser.Document) []Diagnostic {
 			Range:    steps.Range,
 			Severity: SeverityError,
 			Source:   "tekton-lsp",
+			Code:     RuleInvalidType,
 			Message:  "Field 'steps' must be an array/sequence",
 		})
 		return diags
@@ -292,53 +256,48 @@ func validateTask(doc *parser.Document) []Diagnostic {
 			Range:    steps.Range,
 			Severity: SeverityError,
 			Source:   "tekton-lsp",
+			Code:     RuleEmptyList,
 			Message:  "Task must have at least one step",
 		})
 	}
 
 	// Validate step fields
-	diags = append(diags, validateStepImages(steps)...)
-	diags = append(diags, validateSequenceItems(steps, knownStepFields, "step")...)
+	diags = append(diags, validateStepImages(t.Steps)...)
+	diags = append(diags, validateSequenceItems(t.Doc.Kind, steps, "step", "spec", "steps", "")...)
 
 	// Validate param fields
-	if params := spec.Get("params"); params != nil {
-		diags = append(diags, validateSequenceItems(params, knownParamFields, "param")...)
-	}
+	diags = append(diags, validateSequenceItems(t.Doc.Kind, spec.Get("params"), "param", "spec", "params", "")...)
 
 	// Validate workspace fields
-	if workspaces := spec.Get("workspaces"); workspaces != nil {
-		diags = append(diags, validateSequenceItems(workspaces, knownWorkspaceFields, "workspace")...)
-	}
+	diags = append(diags, validateSequenceItems(t.Doc.Kind, spec.Get("workspaces"), "workspace", "spec", "workspaces", "")...)
 
 	// Validate result fields
-	if results := spec.Get("results"); results != nil {
-		diags = append(diags, validateSequenceItems(results, knownResultFields, "result")...)
-	}
+	diags = append(diags, validateSequenceItems(t.Doc.Kind, spec.Get("results"), "result", "spec", "results", "")...)
 
 	// Validate param references
-	declaredParams := collectDeclaredParams(spec)
-	diags = append(diags, findParamRefs(spec, declaredParams)...)
+	diags = append(diags, findParamRefs(spec, declaredParams(t.Params))...)
 
 	return diags
 }
 
-func checkUnknownFields(node *parser.Node, knownFields map[string]bool) []Diagnostic {
-	return checkUnknownFieldsContext(node, knownFields, "spec")
-}
-
-func checkUnknownFieldsContext(node *parser.Node, knownFields map[string]bool, context string) []Diagnostic {
+// checkUnknownFields reports the keys of node that are not fields of the
+// object at path in the schema of kind, the same fields completion offers.
+// Maps and values of any type accept every key.
+func checkUnknownFields(kind string, node *parser.Node, context string, path ...string) []Diagnostic {
 	var diags []Diagnostic
 
-	if !node.IsMapping() {
+	f := schema.For(kind).At(path...)
+	if f == nil || f.Type != schema.Object || !node.IsMapping() {
 		return diags
 	}
 
 	for key, child := range node.MappingChildren {
-		if !knownFields[key] {
+		if f.Field(key) == nil {
 			diags = append(diags, Diagnostic{
 				Range:    child.Range,
 				Severity: SeverityWarning,
 				Source:   "tekton-lsp",
+				Code:     RuleUnknownField,
 				Message:  fmt.Sprintf("Unknown field '%s' in %s", key, context),
 			})
 		}
@@ -347,16 +306,15 @@ func checkUnknownFieldsContext(node *parser.Node, knownFields map[string]bool, c
 	return diags
 }
 
-// validateSequenceItems validates each item in a sequence against known fields.
-func validateSequenceItems(node *parser.Node, knownFields map[string]bool, context string) []Diagnostic {
+// validateSequenceItems checks the fields of each item of a sequence, whose
+// items are at path in the schema of kind.
+func validateSequenceItems(kind string, node *parser.Node, context string, path ...string) []Diagnostic {
 	var diags []Diagnostic
 	if node == nil || !node.IsSequence() {
 		return diags
 	}
 	for _, item := range node.AsSequence() {
-		if item.IsMapping() {
-			diags = append(diags, checkUnknownFieldsContext(item, knownFields, context)...)
-		}
+		diags = append(diags, checkUnknownFields(kind, item, context, path...)...)
 	}
 	return diags
 }
@@ -238,3 +238,82 @@ func filterBySeverity(diags []Diagnostic, severity Severity) []Diagnostic {
 	}
 	return result
 }
+
+func TestValidate_Task_MergeKeyProvidesImage(t *testing.T) {
+	doc := parse(t, `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build-task
+spec:
+  stepTemplate: &defaults
+    image: golang:1.25
+  steps:
+    - name: build
+      <<: *defaults
+    - name: test
+      <<: *defaults
+`)
+	diags := Validate(doc)
+	assert.Empty(t, diags, "merged image should satisfy the step image requirement")
+}
+
+func TestValidate_Task_SharedAnchorReportedOnce(t *testing.T) {
+	doc := parse(t, `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build-task
+spec:
+  stepTemplate: &defaults
+    image: golang:1.25
+    bogus: true
+  steps:
+    - name: build
+      <<: *defaults
+    - name: test
+      <<: *defaults
+`)
+	diags := Validate(doc)
+	require.Len(t, diags, 1, "diagnostic at the anchor should be reported once")
+	assert.Contains(t, diags[0].Message, "bogus")
+	assert.Equal(t, uint32(7), diags[0].Range.Start.Line)
+}
+
+func TestValidate_InlineSuppression(t *testing.T) {
+	doc := parse(t, `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build-task
+spec:
+  steps:
+    - name: build
+      image: golang:1.25
+      bogus: true # tekton-lsp: ignore=unknown-field
+    # tekton-lsp: ignore
+    - name: test
+      image: golang:1.25
+      other: true
+    - name: lint
+      image: golang:1.25
+      kept: true # tekton-lsp: ignore=missing-field
+`)
+	diags := Validate(doc)
+	require.Len(t, diags, 1, "only the diagnostic for a different rule should remain")
+	assert.Contains(t, diags[0].Message, "kept")
+	assert.Equal(t, RuleUnknownField, diags[0].Code)
+}
+
+func TestValidate_DocumentSuppression(t *testing.T) {
+	doc := parse(t, `# tekton-lsp: ignore=unknown-field
+apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build-task
+spec:
+  bogus: true
+  steps:
+    - name: build
+`)
+	diags := Validate(doc)
+	require.Len(t, diags, 1)
+	assert.Equal(t, RuleMissingField, diags[0].Code)
+}
@@ -0,0 +1,112 @@
+package validator
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/index"
+	"github.com/vdemeester/tekton-lsp-go/pkg/model"
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+// checkWorkspace runs the checks involving the other files of the workspace
+// indexed in idx, among those in scope.
+func checkWorkspace(doc *parser.Document, idx *index.Index, scope func(uri string) bool) []Diagnostic {
+	diags := checkDuplicate(doc, idx, scope)
+	var spec *model.PipelineSpec
+	switch obj := model.FromDocument(doc).(type) {
+	case *model.Pipeline:
+		spec = &obj.PipelineSpec
+	case *model.PipelineRun:
+		spec = obj.PipelineSpec
+	}
+	if spec != nil {
+		for _, t := range spec.AllTasks() {
+			diags = append(diags, checkTaskParams(t, doc, idx, scope)...)
+		}
+	}
+	return diags
+}
+
+// checkTaskParams checks the params a pipeline task passes against those
+// declared by the Task its taskRef resolves to: each must be declared, and
+// the params without a default must be passed, directly or by the matrix.
+// Tasks that do not resolve, e.g. installed in the cluster, are not checked.
+func checkTaskParams(t *model.PipelineTask, doc *parser.Document, idx *index.Index, scope func(uri string) bool) []Diagnostic {
+	if t.TaskRef == nil || t.TaskSpec != nil {
+		return nil
+	}
+	spec := idx.TaskSpec(t, doc, scope)
+	if spec == nil {
+		return nil
+	}
+	task := t.TaskRef.Name.Value
+
+	var diags []Diagnostic
+	passed := make(map[string]bool)
+	check := func(params []*model.Param) {
+		for _, p := range params {
+			name := p.Name.Value
+			passed[name] = true
+			if p.Name.IsSet() && spec.Param(name) == nil {
+				diags = append(diags, Diagnostic{
+					Range:    p.Name.Node.ValueRange(0, len(p.Name.Node.ScalarValue)),
+					Severity: SeverityWarning,
+					Source:   "tekton-lsp",
+					Code:     RuleUnknownParam,
+					Message:  fmt.Sprintf("Task '%s' has no param '%s'", task, name),
+				})
+			}
+		}
+	}
+	check(t.Params)
+	if t.Matrix != nil {
+		check(t.Matrix.Params)
+		for _, include := range t.Matrix.Include {
+			check(include.Params)
+		}
+	}
+
+	for _, p := range spec.Params {
+		if p.Default != nil || passed[p.Name.Value] || t.Name.Node == nil {
+			continue
+		}
+		diags = append(diags, Diagnostic{
+			Range:    t.Name.Node.ValueRange(0, len(t.Name.Node.ScalarValue)),
+			Severity: SeverityError,
+			Source:   "tekton-lsp",
+			Code:     RuleMissingParam,
+			Message:  fmt.Sprintf("Pipeline task '%s' does not pass param '%s' required by Task '%s'", t.Name.Value, p.Name.Value, task),
+		})
+	}
+	return diags
+}
+
+// checkDuplicate reports a resource also defined in other files: references
+// to it resolve to only one of the definitions.
+func checkDuplicate(doc *parser.Document, idx *index.Index, scope func(uri string) bool) []Diagnostic {
+	key, ok := index.KeyOf(doc)
+	if !ok {
+		return nil
+	}
+	var others []string
+	for _, r := range idx.Lookup(key) {
+		if r.URI != doc.Filename && (scope == nil || scope(r.URI)) {
+			others = append(others, path.Base(r.URI))
+		}
+	}
+	if len(others) == 0 {
+		return nil
+	}
+	sort.Strings(others)
+	name := doc.Root.Get("metadata").Get("name")
+	return []Diagnostic{{
+		Range:    name.ValueRange(0, len(name.ScalarValue)),
+		Severity: SeverityWarning,
+		Source:   "tekton-lsp",
+		Code:     RuleDuplicateResource,
+		Message:  fmt.Sprintf("%s '%s' is also defined in %s", key.Kind, key.Name, strings.Join(others, ", ")),
+	}}
+}
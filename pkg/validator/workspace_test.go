@@ -0,0 +1,124 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/index"
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+// workspaceIndex indexes the given files, by URI.
+func workspaceIndex(t *testing.T, files map[string]string) *index.Index {
+	t.Helper()
+	idx := index.New()
+	for uri, content := range files {
+		docs, err := parser.ParseAllYAML(uri, content)
+		require.NoError(t, err)
+		idx.Update(uri, docs)
+	}
+	return idx
+}
+
+const duplicatedTask = `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  steps:
+    - name: build
+      image: golang
+`
+
+func TestValidateWithOptions_DuplicateResource(t *testing.T) {
+	idx := workspaceIndex(t, map[string]string{
+		"file:///a/build.yaml": duplicatedTask,
+		"file:///a/copy.yaml":  duplicatedTask,
+		"file:///b/build.yaml": duplicatedTask,
+	})
+	doc, err := parser.ParseYAML("file:///a/build.yaml", duplicatedTask)
+	require.NoError(t, err)
+
+	diags := ValidateWithOptions(doc, Options{Index: idx})
+	require.Len(t, diags, 1)
+	assert.Equal(t, RuleDuplicateResource, diags[0].Code)
+	assert.Equal(t, "Task 'build' is also defined in build.yaml, copy.yaml", diags[0].Message)
+	assert.Equal(t, parser.Position{Line: 3, Character: 8}, diags[0].Range.Start)
+
+	inA := func(uri string) bool { return uri[:10] == "file:///a/" }
+	diags = ValidateWithOptions(doc, Options{Index: idx, Scope: inA})
+	require.Len(t, diags, 1)
+	assert.Equal(t, "Task 'build' is also defined in copy.yaml", diags[0].Message)
+
+	assert.Empty(t, ValidateWithOptions(doc, Options{}), "without an index")
+}
+
+func TestValidateWithOptions_TaskParams(t *testing.T) {
+	idx := workspaceIndex(t, map[string]string{
+		"file:///task.yaml": `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  params:
+    - name: revision
+    - name: platform
+    - name: flags
+      default: ""
+  steps:
+    - name: build
+      image: golang
+`,
+	})
+	doc, err := parser.ParseYAML("file:///pipeline.yaml", `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: ci
+spec:
+  tasks:
+    - name: build
+      taskRef:
+        name: build
+      params:
+        - name: revision
+          value: main
+        - name: verbose
+          value: "true"
+    - name: build-all
+      taskRef:
+        name: build
+      params:
+        - name: revision
+          value: main
+      matrix:
+        params:
+          - name: platform
+            value: [linux, darwin]
+    - name: remote
+      taskRef:
+        name: installed-in-the-cluster
+      params:
+        - name: anything
+          value: goes
+`)
+	require.NoError(t, err)
+
+	diags := ValidateWithOptions(doc, Options{Index: idx})
+	require.Len(t, diags, 2)
+	byRule := map[string]Diagnostic{}
+	for _, d := range diags {
+		byRule[d.Code] = d
+	}
+	unknown := byRule[RuleUnknownParam]
+	assert.Equal(t, "Task 'build' has no param 'verbose'", unknown.Message)
+	assert.Equal(t, parser.Position{Line: 12, Character: 16}, unknown.Range.Start)
+	missing := byRule[RuleMissingParam]
+	assert.Equal(t, SeverityError, missing.Severity)
+	assert.Equal(t, "Pipeline task 'build' does not pass param 'platform' required by Task 'build'", missing.Message)
+	assert.Equal(t, parser.Position{Line: 6, Character: 12}, missing.Range.Start)
+
+	assert.Empty(t, ValidateWithOptions(doc, Options{Index: idx, Scope: func(string) bool { return false }}),
+		"Tasks out of scope are not checked")
+}
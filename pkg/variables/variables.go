@@ -0,0 +1,320 @@
+// Package variables lists the $(...) variables Tekton substitutes at a given
+// place in a resource: params, task results, workspaces, results paths and
+// context variables, each with the declaration it comes from.
+package variables
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/index"
+	"github.com/vdemeester/tekton-lsp-go/pkg/model"
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+// Variable is a variable that can be referenced as $(Name).
+type Variable struct {
+	// Name is the variable without "$(" and ")", e.g. "params.revision".
+	Name string
+	// Detail is a short description, e.g. "string param".
+	Detail string
+	// Documentation describes the variable and where it is declared, in
+	// markdown.
+	Documentation string
+}
+
+// Options configures the lookup of the Tasks referenced by pipeline tasks,
+// whose results can be referenced.
+type Options struct {
+	// Index, when set, resolves taskRefs to find their results.
+	Index *index.Index
+	// Scope, when set, restricts resolved Tasks to the files for which it
+	// returns true.
+	Scope func(uri string) bool
+}
+
+// In returns the variables valid in doc inside the nodes for which within
+// returns true: it tells which pipeline task, inline taskSpec or step the
+// place is in.
+func In(doc *parser.Document, within func(*parser.Node) bool, opts Options) []Variable {
+	switch obj := model.FromDocument(doc).(type) {
+	case *model.Pipeline:
+		return pipelineVariables(&obj.PipelineSpec, within, doc, opts)
+	case *model.PipelineRun:
+		if obj.PipelineSpec != nil {
+			return pipelineVariables(obj.PipelineSpec, within, doc, opts)
+		}
+	case *model.Task:
+		return taskVariables(&obj.TaskSpec, within, nil)
+	case *model.TaskRun:
+		if obj.TaskSpec != nil {
+			return taskVariables(obj.TaskSpec, within, nil)
+		}
+	case *model.StepAction:
+		vars := paramVariables(obj.Params, "StepAction", false)
+		for _, r := range obj.Results {
+			vars = append(vars, stepResultPath(r))
+		}
+		return vars
+	}
+	return nil
+}
+
+// pipelineContext are the context variables of pipelines.
+var pipelineContext = []Variable{
+	contextVariable("context.pipelineRun.name", "The name of the PipelineRun running the Pipeline."),
+	contextVariable("context.pipelineRun.namespace", "The namespace of the PipelineRun running the Pipeline."),
+	contextVariable("context.pipelineRun.uid", "The UID of the PipelineRun running the Pipeline."),
+	contextVariable("context.pipeline.name", "The name of the Pipeline."),
+	contextVariable("context.pipelineTask.retries", "The number of retries of the pipeline task."),
+}
+
+// taskContext are the context variables of tasks.
+var taskContext = []Variable{
+	contextVariable("context.taskRun.name", "The name of the TaskRun running the Task."),
+	contextVariable("context.taskRun.namespace", "The namespace of the TaskRun running the Task."),
+	contextVariable("context.taskRun.uid", "The UID of the TaskRun running the Task."),
+	contextVariable("context.task.name", "The name of the Task."),
+	contextVariable("context.task.retry-count", "The current retry number of the Task."),
+	contextVariable("credentials.path", "The path credentials are initialized at, `/tekton/creds` or the home directory of the step."),
+}
+
+func contextVariable(name, doc string) Variable {
+	return Variable{Name: name, Detail: "context", Documentation: fmt.Sprintf("`$(%s)`\n\n%s", name, doc)}
+}
+
+func pipelineVariables(spec *model.PipelineSpec, within func(*parser.Node) bool, doc *parser.Document, opts Options) []Variable {
+	var current *model.PipelineTask
+	for _, t := range spec.AllTasks() {
+		if within(t.Node) {
+			current = t
+		}
+	}
+	if current != nil && current.TaskSpec != nil && within(current.TaskSpec.Node) {
+		// Pipeline params propagate to inline specs, and context variables
+		// are substituted in the whole pipeline task.
+		return append(taskVariables(current.TaskSpec, within, spec.Params), pipelineContext...)
+	}
+
+	vars := paramVariables(spec.Params, "Pipeline", false)
+	for _, w := range spec.Workspaces {
+		name := w.Name.Value
+		vars = append(vars, Variable{
+			Name:          "workspaces." + name + ".bound",
+			Detail:        "workspace",
+			Documentation: fmt.Sprintf("`$(workspaces.%s.bound)`\n\n`true` if workspace `%s` is bound by the PipelineRun, `false` otherwise.", name, name),
+		})
+	}
+	for _, t := range spec.AllTasks() {
+		// Finally tasks see the other tasks, which never see them.
+		if t == current || current != nil && t.Finally {
+			continue
+		}
+		name := t.Name.Value
+		var results []*model.Result
+		if spec := opts.Index.TaskSpec(t, doc, opts.Scope); spec != nil {
+			results = spec.Results
+		}
+		for _, r := range results {
+			vars = append(vars, resultVariables("tasks."+name+".results.", r, "of "+name, "Produced by pipeline task `"+name+"`"+producer(t)+".")...)
+		}
+		if current != nil && current.Finally {
+			vars = append(vars,
+				Variable{
+					Name:          "tasks." + name + ".status",
+					Detail:        "status of " + name,
+					Documentation: fmt.Sprintf("`$(tasks.%s.status)`\n\nThe status of pipeline task `%s`: `Succeeded`, `Failed` or `None` if it did not run.", name, name),
+				},
+				Variable{
+					Name:          "tasks." + name + ".reason",
+					Detail:        "reason of " + name,
+					Documentation: fmt.Sprintf("`$(tasks.%s.reason)`\n\nThe reason of the status of pipeline task `%s`.", name, name),
+				})
+		}
+	}
+	if current != nil && current.Finally {
+		vars = append(vars, Variable{
+			Name:          "tasks.status",
+			Detail:        "status of the tasks",
+			Documentation: "`$(tasks.status)`\n\nThe aggregate status of the tasks: `Succeeded`, `Failed`, `Completed` (some were skipped) or `None`.",
+		})
+	}
+	return append(vars, pipelineContext...)
+}
+
+// producer describes the Task a pipeline task runs.
+func producer(t *model.PipelineTask) string {
+	if t.TaskRef != nil && t.TaskRef.Name.Value != "" {
+		kind := t.TaskRef.Kind.Value
+		if kind == "" {
+			kind = "Task"
+		}
+		return fmt.Sprintf(" (%s `%s`)", kind, t.TaskRef.Name.Value)
+	}
+	return ""
+}
+
+// taskVariables returns the variables of a Task spec. propagated are the
+// params of the enclosing Pipeline, for inline specs.
+func taskVariables(spec *model.TaskSpec, within func(*parser.Node) bool, propagated []*model.ParamSpec) []Variable {
+	vars := paramVariables(spec.Params, "Task", false)
+	declared := make(map[string]bool)
+	for _, p := range spec.Params {
+		declared[p.Name.Value] = true
+	}
+	for _, p := range propagated {
+		if !declared[p.Name.Value] {
+			vars = append(vars, paramVariables([]*model.ParamSpec{p}, "Pipeline", true)...)
+		}
+	}
+	for _, w := range spec.Workspaces {
+		name := w.Name.Value
+		for _, v := range []struct{ field, doc string }{
+			{"path", "The path workspace `%s` is mounted at."},
+			{"bound", "`true` if workspace `%s` is bound, `false` otherwise."},
+			{"claim", "The name of the PersistentVolumeClaim of workspace `%s`, empty for other volume sources."},
+			{"volume", "The name of the volume of workspace `%s`."},
+		} {
+			full := "workspaces." + name + "." + v.field
+			vars = append(vars, Variable{
+				Name:          full,
+				Detail:        "workspace",
+				Documentation: fmt.Sprintf("`$(%s)`\n\n"+v.doc, full, name),
+			})
+		}
+	}
+	for _, r := range spec.Results {
+		full := "results." + r.Name.Value + ".path"
+		vars = append(vars, Variable{
+			Name:          full,
+			Detail:        "result path",
+			Documentation: resultDocumentation(full, r, "The file to write result `"+r.Name.Value+"` to."),
+		})
+	}
+	for _, s := range spec.Steps {
+		if within(s.Node) {
+			for _, r := range s.Results {
+				vars = append(vars, stepResultPath(r))
+			}
+			continue
+		}
+		for _, r := range s.Results {
+			vars = append(vars, resultVariables("steps."+s.Name.Value+".results.", r, "of step "+s.Name.Value, "Produced by step `"+s.Name.Value+"`.")...)
+		}
+	}
+	return append(vars, taskContext...)
+}
+
+func stepResultPath(r *model.Result) Variable {
+	full := "step.results." + r.Name.Value + ".path"
+	return Variable{
+		Name:          full,
+		Detail:        "step result path",
+		Documentation: resultDocumentation(full, r, "The file to write step result `"+r.Name.Value+"` to."),
+	}
+}
+
+// paramVariables returns the variables of declared params: arrays and
+// objects are referenced whole with [*], object keys one by one.
+func paramVariables(params []*model.ParamSpec, owner string, propagated bool) []Variable {
+	var vars []Variable
+	for _, p := range params {
+		name, typ := p.Name.Value, p.ParamType()
+		origin := "Declared by the " + owner + "."
+		if propagated {
+			origin = "Propagated from the " + owner + "."
+		}
+		doc := paramDocumentation(p, origin)
+		switch typ {
+		case "array":
+			vars = append(vars, Variable{Name: "params." + name + "[*]", Detail: "array param", Documentation: doc})
+		case "object":
+			vars = append(vars, Variable{Name: "params." + name + "[*]", Detail: "object param", Documentation: doc})
+			for _, prop := range p.Properties {
+				vars = append(vars, Variable{
+					Name:          "params." + name + "." + prop.Name.Value,
+					Detail:        "object param key",
+					Documentation: doc,
+				})
+			}
+		default:
+			vars = append(vars, Variable{Name: "params." + name, Detail: typ + " param", Documentation: doc})
+		}
+	}
+	return vars
+}
+
+func paramDocumentation(p *model.ParamSpec, origin string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s** (%s param)\n", p.Name.Value, p.ParamType())
+	if d := strings.TrimSpace(p.Description.Value); d != "" {
+		fmt.Fprintf(&b, "\n%s\n", d)
+	}
+	if p.Default != nil {
+		fmt.Fprintf(&b, "\nDefault: `%s`\n", Value(p.Default))
+	}
+	fmt.Fprintf(&b, "\n%s", origin)
+	return b.String()
+}
+
+// resultVariables returns the variables of a result produced elsewhere,
+// named prefix and the result name: as for params, arrays and objects are
+// referenced whole with [*], object keys one by one. detail completes the
+// kind of variable, e.g. "of build".
+func resultVariables(prefix string, r *model.Result, detail, origin string) []Variable {
+	name := prefix + r.Name.Value
+	switch typ := r.Type.Value; typ {
+	case "array", "object":
+		whole := name + "[*]"
+		vars := []Variable{{Name: whole, Detail: typ + " result " + detail, Documentation: resultDocumentation(whole, r, origin)}}
+		for _, prop := range r.Properties {
+			key := name + "." + prop.Name.Value
+			vars = append(vars, Variable{
+				Name:          key,
+				Detail:        "object result key " + detail,
+				Documentation: resultDocumentation(key, r, origin),
+			})
+		}
+		return vars
+	default:
+		return []Variable{{Name: name, Detail: "result " + detail, Documentation: resultDocumentation(name, r, origin)}}
+	}
+}
+
+func resultDocumentation(name string, r *model.Result, origin string) string {
+	var b strings.Builder
+	typ := r.Type.Value
+	if typ == "" {
+		typ = "string"
+	}
+	fmt.Fprintf(&b, "`$(%s)` (%s)\n", name, typ)
+	if d := strings.TrimSpace(r.Description.Value); d != "" {
+		fmt.Fprintf(&b, "\n%s\n", d)
+	}
+	fmt.Fprintf(&b, "\n%s", origin)
+	return b.String()
+}
+
+// Value renders a value node on one line: scalars as is, sequences and
+// mappings in flow style.
+func Value(n *parser.Node) string {
+	switch {
+	case n.IsScalar():
+		return n.AsScalar()
+	case n.IsSequence():
+		items := make([]string, len(n.SequenceChildren))
+		for i, item := range n.SequenceChildren {
+			items[i] = Value(item)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case n.IsMapping():
+		keys := make([]string, 0, len(n.MappingChildren))
+		for key, child := range n.MappingChildren {
+			keys = append(keys, key+": "+Value(child))
+		}
+		slices.Sort(keys)
+		return "{" + strings.Join(keys, ", ") + "}"
+	}
+	return ""
+}
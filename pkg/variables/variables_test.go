@@ -0,0 +1,182 @@
+package variables
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/vdemeester/tekton-lsp-go/pkg/index"
+	"github.com/vdemeester/tekton-lsp-go/pkg/parser"
+)
+
+const pipelineYAML = `apiVersion: tekton.dev/v1
+kind: Pipeline
+metadata:
+  name: ci
+spec:
+  params:
+    - name: revision
+      description: The revision to build.
+      default: main
+    - name: platforms
+      type: array
+      default: [linux, darwin]
+  workspaces:
+    - name: source
+  tasks:
+    - name: fetch
+      taskSpec:
+        params:
+          - name: revision
+        steps:
+          - name: clone
+            image: git
+            script: git checkout $(params.revision)
+    - name: build
+      taskRef:
+        name: build
+  finally:
+    - name: notify
+      params:
+        - name: status
+          value: $(tasks.status)
+`
+
+const taskYAML = `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  results:
+    - name: digest
+      type: string
+      description: The image digest.
+    - name: tags
+      type: array
+    - name: image
+      type: object
+      properties:
+        url: {type: string}
+        digest: {type: string}
+  steps:
+    - name: build
+      image: golang
+`
+
+func parse(t *testing.T, uri, yaml string) *parser.Document {
+	t.Helper()
+	doc, err := parser.ParseYAML(uri, yaml)
+	require.NoError(t, err)
+	return doc
+}
+
+// at returns the variables at pos, in the nodes that contain it.
+func at(doc *parser.Document, pos parser.Position, opts Options) map[string]Variable {
+	within := func(n *parser.Node) bool {
+		return n != nil && after(pos, n.Range.Start) && after(n.Range.End, pos)
+	}
+	vars := make(map[string]Variable)
+	for _, v := range In(doc, within, opts) {
+		vars[v.Name] = v
+	}
+	return vars
+}
+
+// after reports whether a is at or after b.
+func after(a, b parser.Position) bool {
+	return a.Line > b.Line || a.Line == b.Line && a.Character >= b.Character
+}
+
+func TestIn_Pipeline(t *testing.T) {
+	idx := index.New()
+	idx.Update("file:///build.yaml", []*parser.Document{parse(t, "file:///build.yaml", taskYAML)})
+	doc := parse(t, "file:///pipeline.yaml", pipelineYAML)
+	opts := Options{Index: idx}
+
+	// In the build task.
+	vars := at(doc, parser.Position{Line: 24, Character: 8}, opts)
+	assert.Contains(t, vars, "params.revision")
+	assert.Contains(t, vars, "params.platforms[*]")
+	assert.Contains(t, vars, "workspaces.source.bound")
+	assert.Contains(t, vars, "context.pipelineRun.name")
+	assert.NotContains(t, vars, "tasks.build.results.digest", "a task does not see its own results")
+	assert.NotContains(t, vars, "tasks.status", "only finally tasks see the status")
+
+	revision := vars["params.revision"]
+	assert.Equal(t, "string param", revision.Detail)
+	assert.Equal(t, "**revision** (string param)\n\nThe revision to build.\n\nDefault: `main`\n\nDeclared by the Pipeline.", revision.Documentation)
+	assert.Contains(t, vars["params.platforms[*]"].Documentation, "Default: `[linux, darwin]`")
+
+	// In the finally task.
+	vars = at(doc, parser.Position{Line: 30, Character: 20}, opts)
+	digest, ok := vars["tasks.build.results.digest"]
+	require.True(t, ok, "results of referenced tasks are resolved through the index")
+	assert.Equal(t, "result of build", digest.Detail)
+	assert.Contains(t, digest.Documentation, "The image digest.")
+	assert.Contains(t, digest.Documentation, "Produced by pipeline task `build` (Task `build`).")
+	assert.Contains(t, vars, "tasks.build.status")
+	assert.Contains(t, vars, "tasks.status")
+
+	// Array and object results are referenced whole with [*], object keys
+	// one by one.
+	assert.Equal(t, "array result of build", vars["tasks.build.results.tags[*]"].Detail)
+	assert.NotContains(t, vars, "tasks.build.results.tags")
+	assert.Equal(t, "object result of build", vars["tasks.build.results.image[*]"].Detail)
+	assert.Equal(t, "object result key of build", vars["tasks.build.results.image.url"].Detail)
+	assert.Contains(t, vars["tasks.build.results.image.digest"].Documentation, "`$(tasks.build.results.image.digest)` (object)")
+	assert.NotContains(t, vars, "tasks.build.results.image")
+
+	// Without an index, only inline specs give results.
+	vars = at(doc, parser.Position{Line: 30, Character: 20}, Options{})
+	assert.NotContains(t, vars, "tasks.build.results.digest")
+	assert.Contains(t, vars, "tasks.build.status")
+
+	// In the inline taskSpec, its own param wins over the propagated one.
+	vars = at(doc, parser.Position{Line: 22, Character: 30}, opts)
+	assert.Contains(t, vars["params.revision"].Documentation, "Declared by the Task.")
+	assert.Contains(t, vars["params.platforms[*]"].Documentation, "Propagated from the Pipeline.")
+	assert.Contains(t, vars, "context.taskRun.name")
+	assert.Contains(t, vars, "context.pipelineRun.name")
+	assert.NotContains(t, vars, "workspaces.source.bound")
+}
+
+func TestIn_Task(t *testing.T) {
+	doc := parse(t, "file:///task.yaml", `apiVersion: tekton.dev/v1
+kind: Task
+metadata:
+  name: build
+spec:
+  workspaces:
+    - name: src
+  results:
+    - name: digest
+  steps:
+    - name: a
+      image: alpine
+      results:
+        - name: out
+        - name: files
+          type: array
+    - name: b
+      image: alpine
+      script: echo
+`)
+	vars := at(doc, parser.Position{Line: 18, Character: 16}, Options{})
+	for _, name := range []string{
+		"workspaces.src.path", "workspaces.src.bound", "workspaces.src.claim", "workspaces.src.volume",
+		"results.digest.path", "steps.a.results.out", "steps.a.results.files[*]", "context.task.retry-count", "credentials.path",
+	} {
+		assert.Contains(t, vars, name)
+	}
+	assert.NotContains(t, vars, "step.results.out.path", "step results belong to their step")
+
+	vars = at(doc, parser.Position{Line: 11, Character: 10}, Options{})
+	assert.Contains(t, vars, "step.results.out.path")
+	assert.NotContains(t, vars, "steps.a.results.out")
+}
+
+func TestIn_Other(t *testing.T) {
+	doc := parse(t, "file:///cm.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: x\n")
+	assert.Empty(t, In(doc, func(*parser.Node) bool { return true }, Options{}))
+}
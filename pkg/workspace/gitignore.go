@@ -0,0 +1,106 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GitIgnoreFile is the name of the files listing paths git ignores.
+const GitIgnoreFile = ".gitignore"
+
+// ignoreRule is one pattern of a .gitignore file.
+type ignoreRule struct {
+	// base is the directory of the .gitignore file, relative to the root;
+	// empty for the root itself. The pattern only applies below it.
+	base    string
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+// gitIgnore holds the rules of the .gitignore files loaded so far, outer
+// directories first, so the last matching rule wins as it does for git.
+type gitIgnore struct {
+	rules []ignoreRule
+}
+
+// load adds the rules of the .gitignore file of dir, relative to root, if
+// there is one.
+func (g *gitIgnore) load(root, dir string) {
+	if dir == "." {
+		dir = ""
+	}
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(dir), GitIgnoreFile))
+	if err != nil {
+		return
+	}
+	g.add(dir, string(data))
+}
+
+// add parses the content of the .gitignore file of directory base.
+func (g *gitIgnore) add(base, content string) {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimRight(line, "\r ")
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		r := ignoreRule{base: base}
+		if line[0] == '!' {
+			r.negate = true
+			line = line[1:]
+		}
+		// A backslash escapes a leading "#" or "!".
+		line = strings.TrimPrefix(line, `\`)
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		r.pattern = line
+		g.rules = append(g.rules, r)
+	}
+}
+
+// ignored reports whether the file or directory at rel, relative to the
+// root, is ignored by the rules. It does not look at parent directories:
+// callers stop at the first ignored one, as git does not re-include files
+// of an ignored directory.
+func (g *gitIgnore) ignored(rel string, dir bool) bool {
+	ignored := false
+	for _, r := range g.rules {
+		if r.dirOnly && !dir {
+			continue
+		}
+		name := rel
+		if r.base != "" {
+			var ok bool
+			if name, ok = strings.CutPrefix(rel, r.base+"/"); !ok {
+				continue
+			}
+		}
+		if Match(r.pattern, name) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// GitIgnored reports whether the slash-separated path rel, relative to root,
+// is ignored by the .gitignore files of root and of the directories between
+// root and the file.
+func GitIgnored(root, rel string) bool {
+	g := &gitIgnore{}
+	g.load(root, "")
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		dir := strings.Join(parts[:i], "/")
+		if g.ignored(dir, true) {
+			return true
+		}
+		g.load(root, dir)
+	}
+	return g.ignored(rel, false)
+}
@@ -0,0 +1,50 @@
+package workspace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitIgnore_Rules(t *testing.T) {
+	var g gitIgnore
+	g.add("", "# comment\n\n*.log\n/dist\nout/\n!important.log\n\\#hash.yaml\ndocs/**/*.tmp\n")
+	g.add("sub", "local.yaml\n")
+
+	tests := []struct {
+		rel     string
+		dir     bool
+		ignored bool
+	}{
+		{"debug.log", false, true},
+		{"a/b/debug.log", false, true},
+		{"important.log", false, false},
+		{"dist", true, true},
+		{"a/dist", true, false},
+		{"out", true, true},
+		{"out", false, false},
+		{"#hash.yaml", false, true},
+		{"docs/a/b/x.tmp", false, true},
+		{"sub/local.yaml", false, true},
+		{"sub/x/local.yaml", false, true},
+		{"local.yaml", false, false},
+		{"task.yaml", false, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.ignored, g.ignored(tt.rel, tt.dir), tt.rel)
+	}
+}
+
+func TestGitIgnored(t *testing.T) {
+	dir := setupWorkspace(t, map[string]string{
+		".gitignore":      "vendor/\n",
+		"a/.gitignore":    "*.gen.yaml\n",
+		"a/task.gen.yaml": "",
+	})
+
+	assert.True(t, GitIgnored(dir, "vendor/task.yaml"))
+	assert.True(t, GitIgnored(dir, "a/task.gen.yaml"))
+	assert.True(t, GitIgnored(dir, "a/b/task.gen.yaml"))
+	assert.False(t, GitIgnored(dir, "b/task.gen.yaml"))
+	assert.False(t, GitIgnored(dir, "a/task.yaml"))
+}
@@ -0,0 +1,74 @@
+package workspace
+
+import (
+	"path"
+	"strings"
+)
+
+// Match reports whether a slash-separated path relative to the workspace
+// root matches a glob pattern. Besides path.Match syntax, "**" matches any
+// number of directories and "{a,b}" matches either alternative. A pattern
+// without a slash matches the base name at any depth, like .gitignore; a
+// leading slash anchors it to the root.
+func Match(pattern, name string) bool {
+	for _, p := range expandBraces(pattern) {
+		if !strings.Contains(p, "/") {
+			p = "**/" + p
+		}
+		p = strings.TrimPrefix(p, "/")
+		if matchSegments(strings.Split(p, "/"), strings.Split(name, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAny reports whether name matches one of the patterns.
+func MatchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if Match(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// expandBraces expands the first {a,b} group of a pattern, recursively.
+func expandBraces(pattern string) []string {
+	open := strings.IndexByte(pattern, '{')
+	if open < 0 {
+		return []string{pattern}
+	}
+	end := strings.IndexByte(pattern[open:], '}')
+	if end < 0 {
+		return []string{pattern}
+	}
+	end += open
+	var result []string
+	for _, alt := range strings.Split(pattern[open+1:end], ",") {
+		result = append(result, expandBraces(pattern[:open]+alt+pattern[end+1:])...)
+	}
+	return result
+}
@@ -0,0 +1,29 @@
+package workspace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"tasks/*.yaml", "tasks/build.yaml", true},
+		{"tasks/*.yaml", "tasks/go/build.yaml", false},
+		{"tasks/**/*.yaml", "tasks/go/build.yaml", true},
+		{"tasks/**/*.yaml", "tasks/build.yaml", true},
+		{"**/*.{yaml,yml}", "a/b/c.yml", true},
+		{"vendor", "vendor", true},
+		{"vendor", "third_party/vendor", true},
+		{"/vendor", "third_party/vendor", false},
+		{"*.yaml", "deep/dir/file.yaml", true},
+		{"charts/**", "charts/app/values.yaml", true},
+		{"charts/**", "tasks/build.yaml", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Match(tt.pattern, tt.name), "%s ~ %s", tt.pattern, tt.name)
+	}
+}
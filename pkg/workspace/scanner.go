@@ -2,46 +2,239 @@
 package workspace
 
 import (
+	"context"
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/vdemeester/tekton-lsp-go/pkg/cache"
 )
 
-// Scan walks a workspace root directory and indexes all YAML files into the cache.
-// The rootURI should be a file:// URI. Returns the number of files indexed.
+// Options selects the files a scan indexes.
+type Options struct {
+	// Include restricts the scan to files matching one of these globs,
+	// relative to the root. Empty means every YAML file.
+	Include []string
+	// Exclude skips files and directories matching one of these globs.
+	Exclude []string
+	// GitIgnore skips the files and directories ignored by the .gitignore
+	// files of the root and its subdirectories.
+	GitIgnore bool
+	// Ignore, when set, skips the files for which it returns true. It is
+	// given the file's absolute path.
+	Ignore func(path string) bool
+	// MaxFileSize skips files larger than this many bytes. Zero means no
+	// limit.
+	MaxFileSize int64
+	// Workers is the number of files read and parsed concurrently. Zero
+	// means GOMAXPROCS.
+	Workers int
+	// Progress, when set, is called with the number of files indexed so far
+	// and the number of files to index, first with zero done. Calls are
+	// serialized.
+	Progress func(done, total int)
+}
+
+func (o Options) workers() int {
+	if o.Workers > 0 {
+		return o.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// Selects reports whether the YAML file at rel, relative to the root, is
+// indexed with these options. A file is excluded when it or one of its
+// parent directories matches an exclude pattern, or when it is in a hidden
+// directory other than .tekton. .gitignore files are not looked at.
+func (o Options) Selects(rel string) bool {
+	rel = filepath.ToSlash(rel)
+	for dir := rel; dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if MatchAny(o.Exclude, dir) || (dir != rel && Hidden(path.Base(dir))) {
+			return false
+		}
+	}
+	return len(o.Include) == 0 || MatchAny(o.Include, rel)
+}
+
+// TektonDir is the directory Pipelines-as-Code reads its PipelineRuns from.
+const TektonDir = ".tekton"
+
+// Hidden reports whether a directory name is hidden and skipped by scans:
+// dot directories (.git, .github, .vscode...) except TektonDir.
+func Hidden(name string) bool {
+	return strings.HasPrefix(name, ".") && name != "." && name != ".." && name != TektonDir
+}
+
+// Scan walks a workspace root directory and indexes all YAML files into the
+// cache, except those ignored by git. The rootURI should be a file:// URI.
+// Returns the number of files indexed.
 func Scan(rootURI string, c *cache.Cache) (int, error) {
-	root := strings.TrimPrefix(rootURI, "file://")
+	return ScanWith(rootURI, c, Options{GitIgnore: true})
+}
+
+// ScanWith is Scan restricted to the files selected by opts. Files under the
+// root that are cached but no longer selected, or no longer exist, are
+// dropped unless they are open in the editor.
+func ScanWith(rootURI string, c *cache.Cache, opts Options) (int, error) {
+	return ScanContext(context.Background(), rootURI, c, opts)
+}
 
-	count := 0
+// ScanContext is ScanWith stopping early when ctx is cancelled, in which
+// case it returns the context's error and leaves the files not indexed yet
+// as they were. Files are read and parsed by a pool of opts.Workers
+// goroutines once the tree has been walked, so progress can be reported
+// against the total.
+func ScanContext(ctx context.Context, rootURI string, c *cache.Cache, opts Options) (int, error) {
+	root := PathFromURI(rootURI)
+
+	var (
+		paths  []string
+		ignore gitIgnore
+	)
 	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
 			return nil // Skip directories we can't read.
 		}
+		rel, _ := filepath.Rel(root, path)
+		rel = filepath.ToSlash(rel)
 		if d.IsDir() {
-			// Skip hidden directories.
-			if strings.HasPrefix(d.Name(), ".") && d.Name() != "." {
+			if rel == "." {
+				if opts.GitIgnore {
+					ignore.load(root, rel)
+				}
+				return nil
+			}
+			if Hidden(d.Name()) || MatchAny(opts.Exclude, rel) {
 				return filepath.SkipDir
 			}
+			if opts.GitIgnore {
+				if ignore.ignored(rel, true) {
+					return filepath.SkipDir
+				}
+				ignore.load(root, rel)
+			}
 			return nil
 		}
 
-		ext := filepath.Ext(path)
-		if ext != ".yaml" && ext != ".yml" {
+		if !IsYAML(path) || !opts.Selects(rel) {
 			return nil
 		}
-
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return nil // Skip files we can't read.
+		if opts.GitIgnore && ignore.ignored(rel, false) {
+			return nil
 		}
-
-		uri := "file://" + path
-		c.Insert(uri, "yaml", 0, string(content))
-		count++
+		if opts.Ignore != nil && opts.Ignore(path) {
+			return nil
+		}
+		if opts.MaxFileSize > 0 {
+			if info, err := d.Info(); err != nil || info.Size() > opts.MaxFileSize {
+				return nil // Skip huge files, typically generated manifests.
+			}
+		}
+		paths = append(paths, path)
 		return nil
 	})
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		mu    sync.Mutex
+		seen  = make(map[string]bool, len(paths))
+		count int
+	)
+	if opts.Progress != nil {
+		opts.Progress(0, len(paths))
+	}
+	work := make(chan string)
+	var wg sync.WaitGroup
+	for range opts.workers() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range work {
+				content, err := os.ReadFile(path)
+				if err != nil {
+					continue // Skip files we can't read.
+				}
+				// Documents open in the editor keep their buffer content.
+				uri := URIFromPath(path)
+				c.Load(uri, string(content))
+
+				mu.Lock()
+				seen[uri] = true
+				count++
+				if opts.Progress != nil {
+					opts.Progress(count, len(paths))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for _, path := range paths {
+		select {
+		case work <- path:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+	if ctx.Err() != nil {
+		return count, ctx.Err()
+	}
+
+	for _, e := range c.All() {
+		if !e.Open && !seen[e.URI] && Contains(rootURI, e.URI) {
+			c.Remove(e.URI)
+		}
+	}
+	return count, nil
+}
+
+// IsYAML reports whether a path or URI names a YAML file.
+func IsYAML(path string) bool {
+	ext := filepath.Ext(path)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// Reload refreshes a document from disk, typically after it was closed in
+// the editor. If the file no longer exists (or cannot be read) it is removed
+// from the cache. Documents open in the editor are left untouched. It returns
+// true if the document is still cached.
+func Reload(uri string, c *cache.Cache) bool {
+	if c.IsOpen(uri) {
+		return true
+	}
+	content, err := os.ReadFile(PathFromURI(uri))
+	if err != nil {
+		c.Remove(uri)
+		return false
+	}
+	c.Load(uri, string(content))
+	return true
+}
+
+// Contains reports whether uri refers to a file inside the root directory
+// rootURI.
+func Contains(rootURI, uri string) bool {
+	_, ok := Rel(rootURI, uri)
+	return ok
+}
 
-	return count, err
+// Rel returns the slash-separated path of uri relative to the root
+// directory rootURI. It returns false if uri is outside of the root.
+func Rel(rootURI, uri string) (string, bool) {
+	root := filepath.Clean(PathFromURI(rootURI))
+	rel, err := filepath.Rel(root, filepath.Clean(PathFromURI(uri)))
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
 }
@@ -1,8 +1,10 @@
 package workspace
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -99,9 +101,67 @@ spec:
 	c := cache.New()
 	n, err := Scan("file://"+dir, c)
 	require.NoError(t, err)
-	// Both files are scanned (YAML) but only Tekton ones are useful
-	// The scanner indexes all YAML files; filtering is done by features
 	assert.Equal(t, 2, n, "should index all YAML files")
+
+	// Files without Tekton resources are kept as a summary: no content, but
+	// still indexed.
+	configMap, ok := c.Get(URIFromPath(filepath.Join(dir, "configmap.yaml")))
+	require.True(t, ok)
+	assert.True(t, configMap.Summary)
+	assert.Empty(t, configMap.Content)
+	task, ok := c.Get(URIFromPath(filepath.Join(dir, "task.yaml")))
+	require.True(t, ok)
+	assert.False(t, task.Summary)
+	assert.NotEmpty(t, task.Content)
+}
+
+func TestScan_HiddenDirectories(t *testing.T) {
+	dir := setupWorkspace(t, map[string]string{
+		".tekton/pull-request.yaml": "apiVersion: tekton.dev/v1\nkind: PipelineRun\n",
+		".github/workflows/ci.yaml": "on: push\n",
+		".git/config.yaml":          "bare: false\n",
+		".gitlab-ci.yml":            "stages: [build]\n",
+	})
+	c := cache.New()
+
+	n, err := Scan(URIFromPath(dir), c)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n, ".tekton is scanned, other hidden directories are not")
+	_, ok := c.Get(URIFromPath(filepath.Join(dir, ".tekton/pull-request.yaml")))
+	assert.True(t, ok)
+	_, ok = c.Get(URIFromPath(filepath.Join(dir, ".gitlab-ci.yml")))
+	assert.True(t, ok, "hidden files are scanned")
+
+	assert.True(t, Options{}.Selects(".tekton/pull-request.yaml"))
+	assert.False(t, Options{}.Selects(".github/workflows/ci.yaml"))
+}
+
+func TestScan_GitIgnore(t *testing.T) {
+	dir := setupWorkspace(t, map[string]string{
+		".gitignore":                "/build/\n*.generated.yaml\n!keep.generated.yaml\n",
+		"build/task.yaml":           "kind: Task\n",
+		"tasks/a.generated.yaml":    "kind: Task\n",
+		"tasks/keep.generated.yaml": "kind: Task\n",
+		"tasks/.gitignore":          "local/\n",
+		"tasks/local/task.yaml":     "kind: Task\n",
+		"tasks/task.yaml":           "kind: Task\n",
+		"other/local/task.yaml":     "kind: Task\n",
+	})
+	c := cache.New()
+
+	_, err := Scan(URIFromPath(dir), c)
+	require.NoError(t, err)
+	var indexed []string
+	for _, e := range c.All() {
+		rel, _ := Rel(URIFromPath(dir), e.URI)
+		indexed = append(indexed, rel)
+	}
+	assert.ElementsMatch(t, []string{"tasks/keep.generated.yaml", "tasks/task.yaml", "other/local/task.yaml"}, indexed)
+
+	// ScanWith only honours .gitignore files when asked to.
+	n, err := ScanWith(URIFromPath(dir), cache.New(), Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 6, n)
 }
 
 func TestScan_RecursesSubdirectories(t *testing.T) {
@@ -149,3 +209,123 @@ spec:
 	require.NoError(t, err)
 	assert.Equal(t, 1, n, "should handle .yml extension")
 }
+
+func TestScan_KeepsOpenBuffers(t *testing.T) {
+	dir := setupWorkspace(t, map[string]string{
+		"task.yaml": "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: disk\n",
+	})
+	uri := URIFromPath(filepath.Join(dir, "task.yaml"))
+
+	c := cache.New()
+	c.Open(uri, "yaml", 1, "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: editor\n")
+	_, err := Scan(URIFromPath(dir), c)
+	require.NoError(t, err)
+
+	entry, ok := c.Get(uri)
+	require.True(t, ok)
+	assert.Contains(t, entry.Content, "name: editor")
+}
+
+func TestReload(t *testing.T) {
+	dir := setupWorkspace(t, map[string]string{
+		"task.yaml": "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: build\n",
+	})
+	uri := URIFromPath(filepath.Join(dir, "task.yaml"))
+
+	c := cache.New()
+	c.Open(uri, "yaml", 4, "apiVersion: tekton.dev/v1\nkind: Task\nmetadata:\n  name: unsaved\n")
+	c.Close(uri)
+
+	require.True(t, Reload(uri, c))
+	entry, ok := c.Get(uri)
+	require.True(t, ok)
+	assert.Contains(t, entry.Content, "name: build", "closed documents revert to the file on disk")
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "task.yaml")))
+	assert.False(t, Reload(uri, c))
+	_, ok = c.Get(uri)
+	assert.False(t, ok, "deleted files are dropped")
+}
+
+func TestContains(t *testing.T) {
+	assert.True(t, Contains("file:///ws", "file:///ws/tasks/build.yaml"))
+	assert.True(t, Contains("file:///ws/", "file:///ws/build.yaml"))
+	assert.False(t, Contains("file:///ws", "file:///other/build.yaml"))
+	assert.False(t, Contains("file:///ws", "file:///ws2/build.yaml"))
+}
+
+func TestURIFromPath(t *testing.T) {
+	uri := URIFromPath("/tmp/my tasks/build.yaml")
+	assert.Equal(t, "file:///tmp/my%20tasks/build.yaml", uri)
+	assert.Equal(t, "/tmp/my tasks/build.yaml", PathFromURI(uri))
+}
+
+func TestScanWith_IncludeExclude(t *testing.T) {
+	dir := setupWorkspace(t, map[string]string{
+		"tekton/build.yaml":      "kind: Task\n",
+		"tekton/vendor/cat.yaml": "kind: Task\n",
+		"charts/values.yaml":     "replicas: 1\n",
+	})
+	c := cache.New()
+
+	n, err := ScanWith(URIFromPath(dir), c, Options{Include: []string{"tekton/**"}, Exclude: []string{"vendor"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	_, ok := c.Get(URIFromPath(filepath.Join(dir, "tekton/build.yaml")))
+	assert.True(t, ok)
+
+	// Rescanning with other options drops what is no longer selected.
+	n, err = ScanWith(URIFromPath(dir), c, Options{Include: []string{"charts/*.yaml"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Len(t, c.All(), 1)
+	_, ok = c.Get(URIFromPath(filepath.Join(dir, "charts/values.yaml")))
+	assert.True(t, ok)
+}
+
+func TestScanContext_ProgressAndWorkers(t *testing.T) {
+	files := make(map[string]string)
+	for _, name := range []string{"a.yaml", "b.yaml", "c/d.yaml", "c/e.yml"} {
+		files[name] = "kind: Task\n"
+	}
+	dir := setupWorkspace(t, files)
+
+	c := cache.New()
+	var reports [][2]int
+	n, err := ScanContext(context.Background(), URIFromPath(dir), c, Options{
+		Workers:  2,
+		Progress: func(done, total int) { reports = append(reports, [2]int{done, total}) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.Len(t, c.All(), 4)
+	assert.Equal(t, [][2]int{{0, 4}, {1, 4}, {2, 4}, {3, 4}, {4, 4}}, reports)
+}
+
+func TestScanContext_Cancelled(t *testing.T) {
+	dir := setupWorkspace(t, map[string]string{"task.yaml": "kind: Task\n"})
+	c := cache.New()
+	stale := URIFromPath(filepath.Join(dir, "deleted.yaml"))
+	c.Load(stale, "kind: Task\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	n, err := ScanContext(ctx, URIFromPath(dir), c, Options{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, n)
+	_, ok := c.Get(stale)
+	assert.True(t, ok, "an interrupted scan does not prune the cache")
+}
+
+func TestScanWith_MaxFileSize(t *testing.T) {
+	dir := setupWorkspace(t, map[string]string{
+		"task.yaml":      "kind: Task\n",
+		"generated.yaml": "kind: List\n" + strings.Repeat("# padding\n", 100),
+	})
+	c := cache.New()
+	n, err := ScanWith(URIFromPath(dir), c, Options{MaxFileSize: 100})
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	_, ok := c.Get(URIFromPath(filepath.Join(dir, "generated.yaml")))
+	assert.False(t, ok)
+}
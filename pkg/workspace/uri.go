@@ -0,0 +1,24 @@
+package workspace
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// PathFromURI converts a file:// URI to a local path. Percent-encoded
+// characters are decoded; anything that is not a valid URI is treated as
+// a path with the scheme stripped.
+func PathFromURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return strings.TrimPrefix(uri, "file://")
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// URIFromPath converts a local path to a file:// URI.
+func URIFromPath(path string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}